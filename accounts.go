@@ -0,0 +1,45 @@
+package ynabber
+
+import "context"
+
+// AccountDetails describes an account a reader has access to.
+type AccountDetails struct {
+	// Account is set exactly like on the transactions the reader returns, so
+	// writers map it the same way.
+	Account Account
+
+	// Name is the name of the account at the bank, like "Brukskonto".
+	Name string
+
+	// Type is the account type reported by the bank, like "CACC" or "CARD".
+	Type string
+
+	// Currency is the ISO 4217 currency code of the account.
+	Currency string
+}
+
+// Key returns the identifier to use for the account in account maps. It is
+// the IBAN, or for accounts without one the most stable identifier the reader
+// knows, falling back to the account ID.
+func (a AccountDetails) Key() string {
+	if a.Account.IBAN != "" {
+		return a.Account.IBAN
+	}
+	return string(a.Account.ID)
+}
+
+// AccountLister is implemented by readers that can list their accounts from
+// the consent they already have, like a saved session or requisition. It must
+// not start a new authorization and should return an error asking to run
+// Ynabber first if there is no consent yet.
+type AccountLister interface {
+	Accounts(ctx context.Context) ([]AccountDetails, error)
+}
+
+// AccountMapper is implemented by writers that map reader accounts to
+// accounts of their own, typically through an account map setting.
+type AccountMapper interface {
+	// MapAccount returns the writer account that account is written to, or
+	// false if it is not mapped.
+	MapAccount(account Account) (string, bool)
+}
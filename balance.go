@@ -0,0 +1,153 @@
+package ynabber
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Balance is the booked balance of an account at the bank.
+type Balance struct {
+	// Account is set exactly like on the transactions the reader returns, so
+	// writers map it the same way.
+	Account Account
+
+	// Amount is the booked balance.
+	Amount Milliunits
+
+	// Currency is the ISO 4217 code of the currency of Amount.
+	Currency string
+
+	// Date is the date the balance is for, if the bank reports it.
+	Date time.Time
+}
+
+// BalanceReader is implemented by readers that can fetch the booked balance
+// of their accounts. Like AccountLister, it must use the consent the reader
+// already has and not start a new authorization.
+type BalanceReader interface {
+	Balances(ctx context.Context) ([]Balance, error)
+}
+
+// Reconciler is implemented by writers that can compare their accounts with
+// the balance at the bank. Amounts are signed like the transactions the
+// writer receives, so writers that swap inflow and outflow of an account
+// must swap them here too.
+type Reconciler interface {
+	// ClearedBalance returns the cleared balance of the writer account that
+	// account is written to, or false if account isn't mapped.
+	ClearedBalance(ctx context.Context, account Account) (Milliunits, bool, error)
+
+	// Adjust adds a cleared transaction of amount, dated date, to the writer
+	// account that account is written to, labeled as a reconciliation
+	// adjustment.
+	Adjust(ctx context.Context, account Account, amount Milliunits, date time.Time) error
+}
+
+// BalanceTransformer is implemented by transformers that change the sign of
+// the amounts of an account, like swapflow. Reconcile passes balances through
+// these only, since transformers that rewrite or skip transactions by their
+// content have no meaning for a balance.
+type BalanceTransformer interface {
+	TransformBalance(balance Balance) Balance
+}
+
+// AdjustmentPayee is the payee of the transactions Reconcile adds to correct
+// a difference.
+const AdjustmentPayee = "Reconciliation Balance Adjustment"
+
+// Reconciliation is the result of comparing one account of a writer with the
+// bank.
+type Reconciliation struct {
+	Writer  string
+	Reader  string
+	Account Account
+	// Bank is the booked balance at the bank.
+	Bank Milliunits
+	// Budget is the cleared balance in the writer, before any adjustment.
+	Budget Milliunits
+	// Adjusted is set if the difference was corrected.
+	Adjusted bool
+	// Err is set if the writer account couldn't be compared or adjusted.
+	Err error
+}
+
+// Difference returns how much the bank balance exceeds the budget balance.
+func (r Reconciliation) Difference() Milliunits {
+	return r.Bank - r.Budget
+}
+
+// Reconcile fetches the balances from every reader implementing BalanceReader
+// and compares them with the cleared balance of every writer implementing
+// Reconciler that the account is routed to. Balances pass through the
+// transformers implementing BalanceTransformer, so swapflow applies to them.
+// With adjust, every difference is corrected with an adjustment transaction
+// dated today.
+func (y *Ynabber) Reconcile(ctx context.Context, adjust bool) ([]Reconciliation, error) {
+	var results []Reconciliation
+	for _, reader := range y.Readers {
+		balanceReader, ok := reader.(BalanceReader)
+		if !ok {
+			y.logger.Info("reader can't fetch balances", "reader", reader)
+			continue
+		}
+		balances, err := balanceReader.Balances(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("fetching balances with %s: %w", reader, err)
+		}
+
+		for _, balance := range balances {
+			balance := y.transformBalance(balance)
+			for _, writer := range y.Writers {
+				reconciler, ok := writer.(Reconciler)
+				if !ok || len(y.Routes.Filter(writer.String(), reader.String(), []Transaction{{Account: balance.Account}})) == 0 {
+					continue
+				}
+				result, ok := y.reconcileAccount(ctx, reconciler, balance, adjust)
+				if !ok {
+					continue
+				}
+				result.Writer, result.Reader = writer.String(), reader.String()
+				results = append(results, result)
+			}
+		}
+	}
+	return results, nil
+}
+
+// reconcileAccount compares balance with the writer account it is written to
+// and adjusts it if asked to. It returns false if the account isn't mapped.
+func (y *Ynabber) reconcileAccount(ctx context.Context, reconciler Reconciler, balance Balance, adjust bool) (Reconciliation, bool) {
+	result := Reconciliation{Account: balance.Account, Bank: balance.Amount}
+	budget, ok, err := reconciler.ClearedBalance(ctx, balance.Account)
+	if err != nil {
+		result.Err = fmt.Errorf("getting cleared balance: %w", err)
+		return result, true
+	}
+	if !ok {
+		return result, false
+	}
+	result.Budget = budget
+	if !adjust || result.Difference() == 0 {
+		return result, true
+	}
+
+	date := time.Now().UTC()
+	if err := reconciler.Adjust(ctx, balance.Account, result.Difference(), date); err != nil {
+		result.Err = fmt.Errorf("adjusting: %w", err)
+		return result, true
+	}
+	result.Adjusted = true
+	return result, true
+}
+
+// transformBalance passes balance through the transformers implementing
+// BalanceTransformer.
+func (y *Ynabber) transformBalance(balance Balance) Balance {
+	for _, transformer := range y.Transformers {
+		if t, ok := transformer.(BalanceTransformer); ok {
+			balance = t.TransformBalance(balance)
+		}
+	}
+	return balance
+}
@@ -0,0 +1,129 @@
+package ynabber
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// Mock reader that also reports balances
+type mockBalanceReader struct {
+	mockOneShotReader
+	balances []Balance
+}
+
+func (r *mockBalanceReader) Balances(ctx context.Context) ([]Balance, error) {
+	return r.balances, nil
+}
+
+// Mock reconciler with the cleared balance of every mapped IBAN
+type mockReconciler struct {
+	mockWriter
+	cleared  map[string]Milliunits
+	err      error
+	adjusted map[string]Milliunits
+}
+
+func (w *mockReconciler) String() string { return "mock-reconciler" }
+
+func (w *mockReconciler) ClearedBalance(ctx context.Context, account Account) (Milliunits, bool, error) {
+	if w.err != nil {
+		return 0, false, w.err
+	}
+	balance, ok := w.cleared[account.IBAN]
+	return balance, ok, nil
+}
+
+func (w *mockReconciler) Adjust(ctx context.Context, account Account, amount Milliunits, date time.Time) error {
+	w.adjusted[account.IBAN] = amount
+	return nil
+}
+
+// Mock transformer that swaps inflow and outflow
+type negateTransformer struct{}
+
+func (negateTransformer) String() string { return "negate" }
+
+func (negateTransformer) Transform(ctx context.Context, batch []Transaction) ([]Transaction, error) {
+	out := make([]Transaction, len(batch))
+	for i, tx := range batch {
+		tx.Amount = tx.Amount.Negate()
+		out[i] = tx
+	}
+	return out, nil
+}
+
+func (negateTransformer) TransformBalance(balance Balance) Balance {
+	balance.Amount = balance.Amount.Negate()
+	return balance
+}
+
+// Mock transformer that skips every transaction, like a rule would
+type skipTransformer struct{}
+
+func (skipTransformer) String() string { return "skip" }
+
+func (skipTransformer) Transform(ctx context.Context, batch []Transaction) ([]Transaction, error) {
+	return nil, nil
+}
+
+func TestReconcile(t *testing.T) {
+	reader := &mockBalanceReader{balances: []Balance{
+		{Account: Account{IBAN: "NO1"}, Amount: -1000},
+		{Account: Account{IBAN: "NO2"}, Amount: -2000},
+		{Account: Account{IBAN: "NO3"}, Amount: -3000},
+	}}
+
+	for _, adjust := range []bool{false, true} {
+		reconciler := &mockReconciler{
+			cleared:  map[string]Milliunits{"NO1": 1000, "NO2": 1500},
+			adjusted: make(map[string]Milliunits),
+		}
+		y := &Ynabber{
+			Readers:      []Reader{reader},
+			Transformers: []Transformer{negateTransformer{}, skipTransformer{}},
+			Writers:      []Writer{reconciler, &mockWriter{}},
+			logger:       *slog.Default(),
+		}
+
+		results, err := y.Reconcile(context.Background(), adjust)
+		if err != nil {
+			t.Fatalf("Reconcile(%v) error = %v", adjust, err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Reconcile(%v) returned %d results, want 2: %+v", adjust, len(results), results)
+		}
+		if got := results[0]; got.Difference() != 0 || got.Adjusted {
+			t.Errorf("Reconcile(%v) NO1 = %+v, want no difference", adjust, got)
+		}
+		if got := results[1]; got.Writer != "mock-reconciler" || got.Bank != 2000 || got.Difference() != 500 || got.Adjusted != adjust {
+			t.Errorf("Reconcile(%v) NO2 = %+v, want difference 500", adjust, got)
+		}
+		if adjust && (len(reconciler.adjusted) != 1 || reconciler.adjusted["NO2"] != 500) {
+			t.Errorf("Reconcile(%v) adjusted %v, want NO2 by 500", adjust, reconciler.adjusted)
+		}
+		if !adjust && len(reconciler.adjusted) != 0 {
+			t.Errorf("Reconcile(%v) adjusted %v, want nothing", adjust, reconciler.adjusted)
+		}
+	}
+}
+
+func TestReconcileReportsWriterErrors(t *testing.T) {
+	reader := &mockBalanceReader{balances: []Balance{{Account: Account{IBAN: "NO1"}, Amount: 1000}}}
+	reconciler := &mockReconciler{err: errors.New("budget unavailable")}
+	y := &Ynabber{
+		Readers: []Reader{reader},
+		Writers: []Writer{reconciler},
+		logger:  *slog.Default(),
+	}
+
+	results, err := y.Reconcile(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Err == nil {
+		t.Errorf("Reconcile() = %+v, want one result with an error", results)
+	}
+}
@@ -0,0 +1,122 @@
+package ynabber
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Categories maps the categories of a budget to their IDs. Writers look up
+// the category of a transaction by its name, or by its group and name
+// separated by a colon and a space, both case-insensitive.
+type Categories map[string]string
+
+// Add adds the category name in group. The first category of a name wins,
+// the others are found by qualifying them with their group.
+func (c Categories) Add(group, name, id string) {
+	c[categoryKey(group+": "+name)] = id
+	if _, ok := c[categoryKey(name)]; !ok {
+		c[categoryKey(name)] = id
+	}
+}
+
+// Lookup returns the ID of the category named name.
+func (c Categories) Lookup(name string) (string, bool) {
+	id, ok := c[categoryKey(name)]
+	return id, ok
+}
+
+// categoryKey normalizes a category name for lookup.
+func categoryKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// missingCategoryTTL is how long a category missing from the budget after a
+// fetch is not fetched again for. It keeps a rule naming a category the
+// budget doesn't have from fetching the categories on every delivery, while
+// a category added to the budget is still found within the hour.
+const missingCategoryTTL = time.Hour
+
+// CategoryCache holds the categories of a budget once a writer has fetched
+// them, so they are only fetched again when a category is missing. The zero
+// value is an empty cache.
+type CategoryCache struct {
+	mu         sync.Mutex
+	categories Categories
+	// missing holds when each category, by categoryKey, was last found
+	// missing from a fetch
+	missing map[string]time.Time
+	// now keeps the expiry of missing deterministic in tests.
+	now func() time.Time
+}
+
+// Get returns the cached categories, calling fetch when the cache is empty or
+// a category of batch is missing from it, so categories added to the budget
+// while Ynabber runs are found. Categories still missing after a fetch are
+// only fetched for again once missingCategoryTTL has passed. A nil cache
+// calls fetch every time.
+func (c *CategoryCache) Get(ctx context.Context, batch []Transaction, fetch func(context.Context) (Categories, error)) (Categories, error) {
+	if c == nil {
+		return fetch(ctx)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.now != nil {
+		now = c.now()
+	}
+	if c.categories != nil && !slices.ContainsFunc(c.categories.missing(batch), func(key string) bool {
+		found, ok := c.missing[key]
+		return !ok || now.Sub(found) >= missingCategoryTTL
+	}) {
+		return c.categories, nil
+	}
+
+	categories, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.categories = categories
+	if c.missing == nil {
+		c.missing = make(map[string]time.Time)
+	}
+	for key, found := range c.missing {
+		if _, ok := categories[key]; ok || now.Sub(found) >= missingCategoryTTL {
+			delete(c.missing, key)
+		}
+	}
+	for _, key := range categories.missing(batch) {
+		c.missing[key] = now
+	}
+	return categories, nil
+}
+
+// missing returns the categoryKey of every category of a transaction in
+// batch, or of a part of a split transaction, that is missing from c.
+func (c Categories) missing(batch []Transaction) []string {
+	var keys []string
+	add := func(name string) {
+		if _, ok := c.Lookup(name); name != "" && !ok {
+			keys = append(keys, categoryKey(name))
+		}
+	}
+	for _, t := range batch {
+		add(t.Category)
+		for _, s := range t.Splits {
+			add(s.Category)
+		}
+	}
+	return keys
+}
+
+// HasCategories reports whether any transaction in batch, or any part of a
+// split transaction, has a category.
+func HasCategories(batch []Transaction) bool {
+	return slices.ContainsFunc(batch, func(t Transaction) bool {
+		return t.Category != "" || slices.ContainsFunc(t.Splits, func(s Split) bool {
+			return s.Category != ""
+		})
+	})
+}
@@ -0,0 +1,87 @@
+package ynabber
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCategoriesLookup(t *testing.T) {
+	categories := make(Categories)
+	categories.Add("Everyday", "Groceries", "cat-groceries")
+	categories.Add("Everyday", "Eating Out", "cat-eating-out")
+	categories.Add("Other", "Eating Out", "cat-other-eating-out")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Groceries", want: "cat-groceries"},
+		{name: " groceries ", want: "cat-groceries"},
+		{name: "Eating Out", want: "cat-eating-out"},
+		{name: "Other: Eating Out", want: "cat-other-eating-out"},
+		{name: "other: eating out", want: "cat-other-eating-out"},
+		{name: "Missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := categories.Lookup(tt.name)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("Lookup(%q) = %q, %v, want %q", tt.name, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestCategoryCacheFetchesMissing(t *testing.T) {
+	fetches := 0
+	budget := Categories{}
+	fetch := func(context.Context) (Categories, error) {
+		fetches++
+		return maps.Clone(budget), nil
+	}
+	budget.Add("Everyday", "Groceries", "cat-groceries")
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cache := &CategoryCache{now: func() time.Time { return now }}
+	groceries := []Transaction{{Category: "Groceries"}}
+	split := []Transaction{{Splits: []Split{{Category: "Groceries"}, {Category: "New"}}}}
+	unknown := []Transaction{{Category: "Unknown"}}
+	tests := []struct {
+		name  string
+		batch []Transaction
+		after time.Duration
+		add   string
+		want  int
+		id    string
+	}{
+		{name: "empty cache", batch: groceries, want: 1},
+		{name: "cached", batch: groceries, want: 1},
+		{name: "no category", batch: []Transaction{{}}, want: 1},
+		{name: "missing", batch: split, want: 2},
+		{name: "still missing", batch: split, after: 10 * time.Minute, want: 2},
+		{name: "added but remembered missing", batch: split, add: "New", want: 2},
+		{name: "fetched for other missing", batch: unknown, want: 3, id: "cat-new"},
+		{name: "found", batch: split, want: 3, id: "cat-new"},
+		{name: "other still missing", batch: unknown, after: 30 * time.Minute, want: 3},
+		{name: "missing expired", batch: unknown, after: missingCategoryTTL, want: 4},
+	}
+	for _, tt := range tests {
+		now = now.Add(tt.after)
+		if tt.add != "" {
+			budget.Add("Everyday", tt.add, "cat-"+strings.ToLower(tt.add))
+		}
+		categories, err := cache.Get(context.Background(), tt.batch, fetch)
+		if err != nil {
+			t.Fatalf("%s: Get() error = %v", tt.name, err)
+		}
+		if fetches != tt.want {
+			t.Errorf("%s: fetched %d times, want %d", tt.name, fetches, tt.want)
+		}
+		if id, _ := categories.Lookup("New"); tt.id != "" && id != tt.id {
+			t.Errorf("%s: Lookup(New) = %q, want %q", tt.name, id, tt.id)
+		}
+	}
+}
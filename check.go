@@ -0,0 +1,172 @@
+package ynabber
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/martinohansen/ynabber/internal/log"
+)
+
+// Checker is implemented by configs that can check their settings without
+// network access. It lets `ynabber config check` catch mistakes before the
+// first fetch instead of hours into a run. Check should report every problem
+// it finds, joined with errors.Join, and name the environment variable to fix.
+type Checker interface {
+	Check() error
+}
+
+// CheckReader loads the config of the reader called name, which may include
+// an instance name, from the environment and checks it. The reader itself is
+// not created, so nothing touches the network.
+func CheckReader(name string) error {
+	kind, instance, err := ParseInstance(name)
+	if err != nil {
+		return err
+	}
+	r, err := lookup(registry.readers, "reader", kind)
+	if err != nil {
+		return err
+	}
+	return checkConfig(EnvPrefix(instance), r.Config)
+}
+
+// CheckWriter loads the config of the writer called name and checks it, see
+// CheckReader.
+func CheckWriter(name string) error {
+	r, err := lookup(registry.writers, "writer", name)
+	if err != nil {
+		return err
+	}
+	return checkConfig("", r.Config)
+}
+
+// CheckTransformer loads the config of the transformer called name and checks
+// it, see CheckReader.
+func CheckTransformer(name string) error {
+	r, err := lookup(registry.transformers, "transformer", name)
+	if err != nil {
+		return err
+	}
+	return checkConfig("", r.Config)
+}
+
+// checkConfig processes a fresh copy of the config struct template points to
+// and runs its Check method, if any.
+func checkConfig(prefix string, template any) error {
+	if template == nil {
+		return nil
+	}
+	cfg := reflect.New(reflect.TypeOf(template).Elem()).Interface()
+	if err := ProcessEnv(prefix, cfg); err != nil {
+		return err
+	}
+	if c, ok := cfg.(Checker); ok {
+		return c.Check()
+	}
+	return nil
+}
+
+// Check implements Checker. It checks that every configured component exists
+// and that routes only name configured writers.
+func (c *Config) Check() error {
+	var errs []error
+	if _, err := log.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("YNABBER_LOG_LEVEL: %w", err))
+	}
+	switch strings.ToLower(c.LogFormat) {
+	case "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("YNABBER_LOG_FORMAT: unknown format %q, must be text or json", c.LogFormat))
+	}
+
+	if len(c.Readers) == 0 {
+		errs = append(errs, errors.New("YNABBER_READERS: no readers configured"))
+	}
+	seen := make(map[string]bool)
+	for _, name := range c.Readers {
+		if seen[name] {
+			errs = append(errs, fmt.Errorf("YNABBER_READERS: %q is listed more than once, give each one an instance name like enablebanking:dnb", name))
+		}
+		seen[name] = true
+		kind, _, err := ParseInstance(name)
+		if err == nil {
+			_, err = lookup(registry.readers, "reader", kind)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("YNABBER_READERS: %w", err))
+		}
+	}
+	for _, name := range c.Transformers {
+		if _, err := lookup(registry.transformers, "transformer", name); err != nil {
+			errs = append(errs, fmt.Errorf("YNABBER_TRANSFORMERS: %w", err))
+		}
+	}
+	if len(c.Writers) == 0 {
+		errs = append(errs, errors.New("YNABBER_WRITERS: no writers configured"))
+	}
+	for _, name := range c.Writers {
+		if _, err := lookup(registry.writers, "writer", name); err != nil {
+			errs = append(errs, fmt.Errorf("YNABBER_WRITERS: %w", err))
+		}
+	}
+
+	for writer := range c.Routes {
+		if !slices.Contains(c.Writers, writer) {
+			errs = append(errs, fmt.Errorf("YNABBER_ROUTES: routes for %q, which is not in YNABBER_WRITERS", writer))
+		}
+	}
+	if c.WriterAttempts < 1 {
+		errs = append(errs, fmt.Errorf("YNABBER_WRITER_ATTEMPTS: must be at least 1, got %d", c.WriterAttempts))
+	}
+	return errors.Join(errs...)
+}
+
+// iban matches identifiers shaped like an IBAN: a country code, two check
+// digits and an alphanumeric account number.
+var iban = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]+$`)
+
+// CheckAccount reports whether id looks like an account identifier used in
+// account maps: either an IBAN with a valid checksum, or an account ID or
+// BBAN from a reader. Anything shaped like an IBAN must be a valid one, so
+// typos in IBANs are caught.
+func CheckAccount(id string) error {
+	if id == "" {
+		return errors.New("empty account")
+	}
+	if strings.TrimSpace(id) != id || strings.ContainsAny(id, " \t\n") {
+		return fmt.Errorf("account %q contains whitespace", id)
+	}
+	if !iban.MatchString(id) {
+		return nil
+	}
+	if len(id) < 15 || len(id) > 34 {
+		return fmt.Errorf("account %q looks like an IBAN but has %d characters, IBANs have 15 to 34", id, len(id))
+	}
+	if !validIBANChecksum(id) {
+		return fmt.Errorf("account %q looks like an IBAN but its checksum is wrong", id)
+	}
+	return nil
+}
+
+// validIBANChecksum verifies the ISO 13616 mod 97 checksum of iban.
+func validIBANChecksum(iban string) bool {
+	rearranged := iban[4:] + iban[:4]
+	var digits strings.Builder
+	for _, r := range rearranged {
+		if r >= 'A' && r <= 'Z' {
+			fmt.Fprintf(&digits, "%d", r-'A'+10)
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
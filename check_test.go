@@ -0,0 +1,108 @@
+package ynabber
+
+import (
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterReader("check-test", nil, nil)
+}
+
+func TestCheckAccount(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr bool
+	}{
+		{"NO8330001234567", false},
+		{"DE89370400440532013000", false},
+		{"GB82WEST12345698765432", false},
+		{"NO8330001234568", true},   // wrong checksum
+		{"NO83300", true},           // too short for an IBAN
+		{"12345678901", false},      // BBAN
+		{"540111******9999", false}, // masked card number
+		{"f2b1c0a8-8d6e-4b6c-9f3e-2f1d7c5b9a10", false},
+		{"NO83 3000 1234 567", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if err := CheckAccount(tt.id); (err != nil) != tt.wantErr {
+				t.Errorf("CheckAccount(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigCheck(t *testing.T) {
+	valid := func() Config {
+		return Config{
+			LogLevel:       "info",
+			LogFormat:      "text",
+			Readers:        []string{"check-test"},
+			Writers:        []string{"configfile-test"},
+			WriterAttempts: 5,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   []string
+	}{
+		{
+			name:   "valid",
+			modify: func(c *Config) { c.Readers = append(c.Readers, "check-test:dnb") },
+		},
+		{
+			name: "unknown components",
+			modify: func(c *Config) {
+				c.Readers = []string{"nope"}
+				c.Transformers = []string{"nope"}
+				c.Writers = []string{"nope"}
+			},
+			want: []string{"YNABBER_READERS", "YNABBER_TRANSFORMERS", "YNABBER_WRITERS"},
+		},
+		{
+			name:   "duplicate reader",
+			modify: func(c *Config) { c.Readers = []string{"check-test", "check-test"} },
+			want:   []string{"listed more than once"},
+		},
+		{
+			name: "route to unknown writer",
+			modify: func(c *Config) {
+				c.Routes = Routes{"ynab": {{Account: "NO8330001234567"}}}
+			},
+			want: []string{"YNABBER_ROUTES"},
+		},
+		{
+			name: "log settings",
+			modify: func(c *Config) {
+				c.LogLevel = "loud"
+				c.LogFormat = "xml"
+			},
+			want: []string{"YNABBER_LOG_LEVEL", "YNABBER_LOG_FORMAT"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(&cfg)
+			err := cfg.Check()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Check() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Check() = nil, want error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Check() = %v, want it to mention %s", err, want)
+				}
+			}
+		})
+	}
+}
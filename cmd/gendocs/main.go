@@ -164,6 +164,9 @@ func main() {
 
 			// Description – gather from Doc or Comment associated to field.
 			desc := extractDoc(field)
+			if isSecret(field.Tag) {
+				desc += fmt.Sprintf("<br><br>Can also be read from a file with %s_FILE.", envTag)
+			}
 			// Escape characters that interfere with markdown tables and HTML
 			// rendering.
 			desc = strings.ReplaceAll(desc, "|", "\\|")
@@ -228,6 +231,19 @@ func parseTags(tagLit *ast.BasicLit) (envVar, def string) {
 	return envVar, def
 }
 
+// isSecret reports whether a struct field tag marks the field as a secret that
+// can be read from a file.
+func isSecret(tagLit *ast.BasicLit) bool {
+	if tagLit == nil {
+		return false
+	}
+	tagValue, err := strconv.Unquote(tagLit.Value)
+	if err != nil {
+		return false
+	}
+	return reflect.StructTag(tagValue).Get("secret") == "true"
+}
+
 // extractDoc merges Doc and Comment groups for a struct field.
 func extractDoc(field *ast.Field) string {
 	var parts []string
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/internal/log"
+)
+
+// readerAccounts are the accounts of a single reader.
+type readerAccounts struct {
+	reader   string
+	accounts []ynabber.AccountDetails
+}
+
+// accounts lists the accounts of every configured reader from the consent it
+// already has, and whether each writer maps them.
+func accounts(args []string) {
+	flags := flag.NewFlagSet("accounts", flag.ExitOnError)
+	printMap := flags.Bool("map", false, "also print an account map for every writer, with unmapped accounts left blank")
+	flags.Parse(args)
+
+	cfg := setup()
+	logger := slog.Default()
+	opts := ynabber.Options{DataDir: cfg.DataDir, Logger: logger}
+	readers := newReaders(cfg, opts)
+	writers := newWriters(cfg, opts)
+
+	failed := false
+	var listed []readerAccounts
+	for _, reader := range readers {
+		lister, ok := reader.(ynabber.AccountLister)
+		if !ok {
+			logger.Info("reader can't list its accounts", "reader", reader)
+			continue
+		}
+		accounts, err := lister.Accounts(context.Background())
+		if err != nil {
+			logger.Error("listing accounts", "reader", reader, "error", err)
+			failed = true
+			continue
+		}
+		listed = append(listed, readerAccounts{reader.String(), accounts})
+	}
+
+	var mappers []namedMapper
+	for _, writer := range writers {
+		if mapper, ok := writer.(ynabber.AccountMapper); ok {
+			mappers = append(mappers, namedMapper{writer.String(), mapper})
+		}
+	}
+
+	printAccounts(os.Stdout, listed, mappers)
+	if *printMap {
+		if err := printAccountMaps(os.Stdout, listed, mappers); err != nil {
+			log.Fatal(logger, "printing account maps", "error", err)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+type namedMapper struct {
+	name string
+	ynabber.AccountMapper
+}
+
+// printAccounts writes a table of accounts with a column per writer showing
+// the account it is mapped to.
+func printAccounts(out io.Writer, listed []readerAccounts, mappers []namedMapper) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	header := []string{"READER", "NAME", "TYPE", "CURRENCY", "ACCOUNT"}
+	for _, m := range mappers {
+		header = append(header, strings.ToUpper(m.name))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, r := range listed {
+		for _, a := range r.accounts {
+			row := []string{r.reader, dash(a.Name), dash(a.Type), dash(a.Currency), a.Key()}
+			for _, m := range mappers {
+				mapped, _ := m.MapAccount(a.Account)
+				row = append(row, dash(mapped))
+			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	}
+	tw.Flush()
+}
+
+// printAccountMaps writes an account map per writer with every listed
+// account, keeping the existing mappings.
+func printAccountMaps(out io.Writer, listed []readerAccounts, mappers []namedMapper) error {
+	for _, m := range mappers {
+		accountMap := make(map[string]string)
+		for _, r := range listed {
+			for _, a := range r.accounts {
+				accountMap[a.Key()], _ = m.MapAccount(a.Account)
+			}
+		}
+		data, err := json.MarshalIndent(accountMap, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "\n# %s\n%s\n", m.name, data)
+	}
+	return nil
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// runOnce parses the flags of the run command and runs Ynabber.
+func runOnce(args []string) {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	once := flags.Bool("once", false, "fetch from every reader a single time and exit once all transactions are written")
+	flags.Parse(args)
+
+	run(ynabber.Options{Once: *once})
+}
+
+// backfill reads the transactions of a date range from every reader a single
+// time, without changing the date range or schedule in their config.
+func backfill(args []string) {
+	flags := flag.NewFlagSet("backfill", flag.ExitOnError)
+	from := flags.String("from", "", "first date to read, like 2024-01-01 (required)")
+	to := flags.String("to", "", "last date to read, like 2024-06-30 (default today)")
+	flags.Parse(args)
+
+	window, err := parseWindow(*from, *to, time.Now())
+	if err != nil {
+		fmt.Fprintf(flags.Output(), "backfill: %v\n", err)
+		flags.Usage()
+		os.Exit(2)
+	}
+	run(ynabber.Options{Once: true, Window: window})
+}
+
+// parseWindow parses the dates of a backfill. to defaults to the date of now.
+func parseWindow(from, to string, now time.Time) (ynabber.Window, error) {
+	if from == "" {
+		return ynabber.Window{}, fmt.Errorf("-from is required")
+	}
+	start, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return ynabber.Window{}, fmt.Errorf("-from: %w", err)
+	}
+	end := now.UTC()
+	if to != "" {
+		if end, err = time.Parse(time.DateOnly, to); err != nil {
+			return ynabber.Window{}, fmt.Errorf("-to: %w", err)
+		}
+	}
+	if end.Before(start) {
+		return ynabber.Window{}, fmt.Errorf("-to %s is before -from %s", end.Format(time.DateOnly), from)
+	}
+	return ynabber.Window{From: start, To: end}, nil
+}
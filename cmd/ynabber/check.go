@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// configCheck loads the config like a normal run and checks the settings of
+// Ynabber and every configured reader, transformer and writer without
+// touching the network. It prints a report to out and returns false if
+// anything needs fixing.
+func configCheck(out io.Writer) bool {
+	ok := true
+	report := func(name string, err error) {
+		if err == nil {
+			fmt.Fprintf(out, "ok    %s\n", name)
+			return
+		}
+		ok = false
+		fmt.Fprintf(out, "FAIL  %s\n", name)
+		for line := range strings.SplitSeq(err.Error(), "\n") {
+			fmt.Fprintf(out, "      - %s\n", line)
+		}
+	}
+
+	if path := os.Getenv("YNABBER_CONFIG"); path != "" {
+		if err := ynabber.LoadConfigFile(path); err != nil {
+			report("config file", err)
+			return false
+		}
+		report("config file", nil)
+	}
+	var cfg ynabber.Config
+	if err := ynabber.ProcessEnv("", &cfg); err != nil {
+		report("ynabber", err)
+		return false
+	}
+	report("ynabber", cfg.Check())
+
+	for _, name := range cfg.Readers {
+		report("reader "+name, ynabber.CheckReader(name))
+	}
+	for _, name := range cfg.Transformers {
+		report("transformer "+name, ynabber.CheckTransformer(name))
+	}
+	for _, name := range cfg.Writers {
+		report("writer "+name, ynabber.CheckWriter(name))
+	}
+
+	if ok {
+		fmt.Fprintln(out, "\nconfig looks good")
+	} else {
+		fmt.Fprintln(out, "\nconfig has problems, fix the settings listed above")
+	}
+	return ok
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/martinohansen/ynabber/internal/metrics"
+	"github.com/martinohansen/ynabber/internal/status"
+)
+
+// serveHTTP starts serving the metrics, health and readiness endpoints on addr
+// in the background. It only returns an error if addr cannot be listened on.
+func serveHTTP(logger *slog.Logger, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Default)
+	mux.Handle("GET /healthz", status.Default.HealthHandler())
+	mux.Handle("GET /readyz", status.Default.ReadyHandler())
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			logger.Error("serving http", "error", err)
+		}
+	}()
+
+	logger.Info("serving http", "addr", listener.Addr().String())
+	return nil
+}
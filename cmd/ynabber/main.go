@@ -1,20 +1,32 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
 
 	"github.com/carlmjohnson/versioninfo"
-	"github.com/kelseyhightower/envconfig"
 	"github.com/martinohansen/ynabber"
 	"github.com/martinohansen/ynabber/internal/log"
-	"github.com/martinohansen/ynabber/reader/enablebanking"
-	"github.com/martinohansen/ynabber/reader/generator"
-	"github.com/martinohansen/ynabber/reader/nordigen"
-	"github.com/martinohansen/ynabber/writer/actual"
-	"github.com/martinohansen/ynabber/writer/json"
-	"github.com/martinohansen/ynabber/writer/ynab"
+
+	// Readers, transformers and writers register themselves when imported
+	_ "github.com/martinohansen/ynabber/reader/enablebanking"
+	_ "github.com/martinohansen/ynabber/reader/generator"
+	_ "github.com/martinohansen/ynabber/reader/nordigen"
+	_ "github.com/martinohansen/ynabber/transformer/category"
+	_ "github.com/martinohansen/ynabber/transformer/rules"
+	_ "github.com/martinohansen/ynabber/transformer/strip"
+	_ "github.com/martinohansen/ynabber/transformer/swapflow"
+	_ "github.com/martinohansen/ynabber/transformer/transfer"
+	_ "github.com/martinohansen/ynabber/writer/actual"
+	_ "github.com/martinohansen/ynabber/writer/json"
+	_ "github.com/martinohansen/ynabber/writer/ynab"
 )
 
 func setupLogging(logLevel, logFormat string) error {
@@ -35,9 +47,45 @@ func setupLogging(logLevel, logFormat string) error {
 }
 
 func main() {
-	// Read config from env
+	flag.Usage = usage
+	flag.Parse()
+
+	switch args := flag.Args(); {
+	case len(args) == 0:
+		run(ynabber.Options{})
+	case args[0] == "run":
+		runOnce(args[1:])
+	case args[0] == "backfill":
+		backfill(args[1:])
+	case args[0] == "plan":
+		plan(args[1:])
+	case args[0] == "reconcile":
+		reconcile(args[1:])
+	case args[0] == "accounts":
+		accounts(args[1:])
+	case len(args) >= 2 && args[0] == "rules" && args[1] == "test":
+		rulesTest(args[2:])
+	case len(args) == 2 && args[0] == "config" && args[1] == "check":
+		if !configCheck(os.Stdout) {
+			os.Exit(1)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// setup reads the config from the file in YNABBER_CONFIG, if any, and the
+// environment and sets up logging. It exits if either fails.
+func setup() ynabber.Config {
+	if path := os.Getenv("YNABBER_CONFIG"); path != "" {
+		if err := ynabber.LoadConfigFile(path); err != nil {
+			fmt.Printf("error loading config file: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	var cfg ynabber.Config
-	err := envconfig.Process("", &cfg)
+	err := ynabber.ProcessEnv("", &cfg)
 	if err != nil {
 		fmt.Printf("error processing config: %v\n", err)
 		os.Exit(1)
@@ -48,58 +96,141 @@ func main() {
 		fmt.Printf("error setting up logging: %v\n", err)
 		os.Exit(1)
 	}
+	return cfg
+}
 
+// run reads transactions and writes them until shut down, or until every
+// reader is done. Once and Window of opts override the schedule and date
+// range of every reader.
+func run(opts ynabber.Options) {
+	cfg := setup()
 	logger := slog.Default()
 	logger.Info("starting...", "version", versioninfo.Short())
+	opts.DataDir, opts.Logger = cfg.DataDir, logger
 
-	y := ynabber.NewYnabber(&cfg)
-	for _, reader := range cfg.Readers {
-		switch reader {
-		case "nordigen":
-			nordigenReader, err := nordigen.NewReader(cfg.DataDir)
-			if err != nil {
-				log.Fatal(logger, "creating nordigen reader", "error", err)
-			}
-			y.Readers = append(y.Readers, nordigenReader)
-		case "enablebanking":
-			enableBankingReader, err := enablebanking.NewReader(logger, cfg.DataDir)
-			if err != nil {
-				log.Fatal(logger, "creating enablebanking reader", "error", err)
-			}
-			y.Readers = append(y.Readers, enableBankingReader)
-		case "generator":
-			generatorReader, err := generator.NewReader()
-			if err != nil {
-				log.Fatal(logger, "creating generator reader", "error", err)
-			}
-			y.Readers = append(y.Readers, generatorReader)
-		default:
-			log.Fatal(logger, "unknown reader", "name", reader)
+	if cfg.HTTPAddr != "" {
+		if err := serveHTTP(logger, cfg.HTTPAddr); err != nil {
+			log.Fatal(logger, "starting http server", "error", err)
 		}
 	}
-	for _, writer := range cfg.Writers {
-		switch writer {
-		case "actual":
-			actualWriter, err := actual.NewWriter()
-			if err != nil {
-				log.Fatal(logger, "creating actual writer", "error", err)
-			}
-			y.Writers = append(y.Writers, actualWriter)
-		case "ynab":
-			ynabWriter, err := ynab.NewWriter()
-			if err != nil {
-				log.Fatal(logger, "creating ynab writer", "error", err)
-			}
-			y.Writers = append(y.Writers, ynabWriter)
-		case "json":
-			y.Writers = append(y.Writers, json.Writer{})
-		default:
-			log.Fatal(logger, "unknown writer", "name", writer)
+
+	y := newYnabber(&cfg, opts)
+
+	// Run Ynabber until it completes or a signal asks it to shut down
+	ctx, signaled := notifyShutdown(logger)
+	err := y.RunContext(ctx)
+	if sig, ok := signaled(); ok {
+		// Exit like a shell reports a process ended by a signal, so a
+		// shutdown is told apart from both success and failure.
+		if err != nil {
+			logger.Error("shutdown", "error", err)
 		}
+		os.Exit(128 + int(sig))
 	}
-
-	// Run Ynabber
-	if err := y.Run(); err != nil {
+	if err != nil {
 		log.Fatal(logger, err.Error())
 	}
 }
+
+// newYnabber creates Ynabber with the configured ledger, readers,
+// transformers and writers. It exits if any of them can't be created.
+func newYnabber(cfg *ynabber.Config, opts ynabber.Options) *ynabber.Ynabber {
+	logger := opts.Logger
+	y := ynabber.NewYnabber(cfg)
+	if cfg.Ledger {
+		ledger, err := ynabber.OpenLedger(filepath.Join(cfg.DataDir, "ledger.json"))
+		if err != nil {
+			log.Fatal(logger, "opening ledger", "error", err)
+		}
+		y.Ledger = ledger
+	}
+	y.Readers = newReaders(*cfg, opts)
+	// Pending transactions written before are still promoted after pending
+	// import is disabled, until the tracker file is removed
+	pendingPath := filepath.Join(cfg.DataDir, "pending.json")
+	if _, err := os.Stat(pendingPath); err == nil || readsPending(y.Readers) {
+		pending, err := ynabber.OpenPendingTracker(pendingPath)
+		if err != nil {
+			log.Fatal(logger, "opening pending transactions", "error", err)
+		}
+		y.Pending = pending
+	}
+	for _, name := range cfg.Transformers {
+		transformer, err := ynabber.NewTransformer(name, opts)
+		if err != nil {
+			log.Fatal(logger, "creating transformer", "name", name, "error", err)
+		}
+		y.Transformers = append(y.Transformers, transformer)
+	}
+	y.Writers = newWriters(*cfg, opts)
+	return y
+}
+
+// readsPending reports whether any of readers returns pending transactions.
+func readsPending(readers []ynabber.Reader) bool {
+	return slices.ContainsFunc(readers, func(r ynabber.Reader) bool {
+		pending, ok := r.(ynabber.PendingReader)
+		return ok && pending.ReadsPending()
+	})
+}
+
+// newReaders creates the configured readers with opts. It exits if any of
+// them can't be created.
+func newReaders(cfg ynabber.Config, opts ynabber.Options) []ynabber.Reader {
+	logger := opts.Logger
+	seen := make(map[string]bool)
+	var readers []ynabber.Reader
+	for _, name := range cfg.Readers {
+		if seen[name] {
+			log.Fatal(logger, "reader listed more than once, give each one an instance name like enablebanking:dnb", "name", name)
+		}
+		seen[name] = true
+
+		reader, err := ynabber.NewReader(name, opts)
+		if err != nil {
+			log.Fatal(logger, "creating reader", "name", name, "error", err)
+		}
+		readers = append(readers, reader)
+	}
+	return readers
+}
+
+// newWriters creates the configured writers with opts. It exits if any of
+// them can't be created.
+func newWriters(cfg ynabber.Config, opts ynabber.Options) []ynabber.Writer {
+	logger := opts.Logger
+	var writers []ynabber.Writer
+	for _, name := range cfg.Writers {
+		writer, err := ynabber.NewWriter(name, opts)
+		if err != nil {
+			log.Fatal(logger, "creating writer", "name", name, "error", err)
+		}
+		writers = append(writers, writer)
+	}
+	return writers
+}
+
+// notifyShutdown returns a context that is cancelled on the first SIGINT or
+// SIGTERM, and a function reporting which signal was received. A second
+// signal kills the process right away.
+func notifyShutdown(logger *slog.Logger) (context.Context, func() (syscall.Signal, bool)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	var received syscall.Signal
+	go func() {
+		sig := <-signals
+		signal.Stop(signals)
+		logger.Info("received signal, shutting down", "signal", sig)
+		received = sig.(syscall.Signal)
+		cancel()
+	}()
+
+	return ctx, func() (syscall.Signal, bool) {
+		if ctx.Err() == nil {
+			return 0, false
+		}
+		return received, true
+	}
+}
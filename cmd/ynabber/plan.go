@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/internal/log"
+)
+
+// plan reads from every reader once and prints what each writer would change,
+// without writing anything.
+func plan(args []string) {
+	flags := flag.NewFlagSet("plan", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the plan as JSON instead of a table")
+	flags.Parse(args)
+
+	cfg := setup()
+	logger := slog.Default()
+	opts := ynabber.Options{DataDir: cfg.DataDir, Logger: logger, Once: true}
+
+	y := newYnabber(&cfg, opts)
+	plans, err := y.Plan(context.Background())
+	if err != nil {
+		log.Fatal(logger, "planning", "error", err)
+	}
+
+	if *asJSON {
+		err = printPlansJSON(os.Stdout, plans)
+	} else {
+		printPlans(os.Stdout, plans)
+	}
+	if err != nil {
+		log.Fatal(logger, "printing plan", "error", err)
+	}
+	for _, p := range plans {
+		if p.Err != nil {
+			os.Exit(1)
+		}
+	}
+}
+
+// printPlans writes a table of the changes of every writer followed by a
+// summary per writer.
+func printPlans(out io.Writer, plans []ynabber.WriterPlan) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "WRITER\tACTION\tDATE\tFROM\tTO\tAMOUNT\tPAYEE\tREASON")
+	for _, p := range plans {
+		for _, c := range p.Changes {
+			t := c.Transaction
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				p.Writer,
+				c.Action,
+				t.Date.Format(time.DateOnly),
+				dash(accountKey(t.Account)),
+				dash(c.Account),
+				formatAmount(t.Amount),
+				dash(t.Payee),
+				dash(c.Reason),
+			)
+		}
+	}
+	tw.Flush()
+
+	fmt.Fprintln(out)
+	for _, p := range plans {
+		if p.Err != nil {
+			fmt.Fprintf(out, "%s: %v\n", p.Writer, p.Err)
+			continue
+		}
+		counts := make(map[ynabber.Action]int)
+		for _, c := range p.Changes {
+			counts[c.Action]++
+		}
+		fmt.Fprintf(out, "%s: %d to create, %d to match, %d to update, %d to promote, %d to remove, %d duplicate(s), %d skipped\n",
+			p.Writer,
+			counts[ynabber.ActionCreate],
+			counts[ynabber.ActionMatch],
+			counts[ynabber.ActionUpdate],
+			counts[ynabber.ActionPromote],
+			counts[ynabber.ActionRemove],
+			counts[ynabber.ActionDuplicate],
+			counts[ynabber.ActionSkip],
+		)
+	}
+}
+
+// writerPlanJSON is the JSON form of a ynabber.WriterPlan.
+type writerPlanJSON struct {
+	Writer  string           `json:"writer"`
+	Error   string           `json:"error,omitempty"`
+	Changes []ynabber.Change `json:"changes"`
+}
+
+// printPlansJSON writes the plans as a JSON array.
+func printPlansJSON(out io.Writer, plans []ynabber.WriterPlan) error {
+	result := make([]writerPlanJSON, len(plans))
+	for i, p := range plans {
+		result[i] = writerPlanJSON{Writer: p.Writer, Changes: p.Changes}
+		if p.Err != nil {
+			result[i].Error = p.Err.Error()
+		}
+		if result[i].Changes == nil {
+			result[i].Changes = []ynabber.Change{}
+		}
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+// accountKey returns the identifier of account used in account maps.
+func accountKey(account ynabber.Account) string {
+	return ynabber.AccountDetails{Account: account}.Key()
+}
+
+// formatAmount formats m as a decimal amount with two decimals, or three if
+// needed.
+func formatAmount(m ynabber.Milliunits) string {
+	sign := ""
+	if m < 0 {
+		sign, m = "-", -m
+	}
+	units, milli := m/1000, m%1000
+	if milli%10 == 0 {
+		return fmt.Sprintf("%s%d.%02d", sign, units, milli/10)
+	}
+	return fmt.Sprintf("%s%d.%03d", sign, units, milli)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/internal/log"
+)
+
+// reconcile compares the booked balance of every bank account with the
+// cleared balance of the budget accounts it is written to. It exits with 1 if
+// any account differs and wasn't adjusted, or couldn't be compared.
+func reconcile(args []string) {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	adjust := flags.Bool("adjust", false, "add a transaction correcting every difference")
+	asJSON := flags.Bool("json", false, "print the result as JSON instead of a table")
+	flags.Parse(args)
+
+	cfg := setup()
+	logger := slog.Default()
+	opts := ynabber.Options{DataDir: cfg.DataDir, Logger: logger, Once: true}
+
+	y := newYnabber(&cfg, opts)
+	results, err := y.Reconcile(context.Background(), *adjust)
+	if err != nil {
+		log.Fatal(logger, "reconciling", "error", err)
+	}
+
+	if *asJSON {
+		err = printReconciliationsJSON(os.Stdout, results)
+	} else {
+		printReconciliations(os.Stdout, results)
+	}
+	if err != nil {
+		log.Fatal(logger, "printing reconciliation", "error", err)
+	}
+	for _, r := range results {
+		if r.Err != nil || (r.Difference() != 0 && !r.Adjusted) {
+			os.Exit(1)
+		}
+	}
+}
+
+// reconciliationStatus describes r in a word or two.
+func reconciliationStatus(r ynabber.Reconciliation) string {
+	switch {
+	case r.Err != nil:
+		return "error: " + r.Err.Error()
+	case r.Difference() == 0:
+		return "ok"
+	case r.Adjusted:
+		return "adjusted"
+	}
+	return "differs"
+}
+
+// printReconciliations writes a table of the balances of every account.
+func printReconciliations(out io.Writer, results []ynabber.Reconciliation) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "WRITER\tACCOUNT\tBANK\tBUDGET\tDIFFERENCE\tSTATUS")
+	for _, r := range results {
+		budget, difference := "-", "-"
+		if r.Err == nil {
+			budget, difference = formatAmount(r.Budget), formatAmount(r.Difference())
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Writer,
+			dash(accountKey(r.Account)),
+			formatAmount(r.Bank),
+			budget,
+			difference,
+			reconciliationStatus(r),
+		)
+	}
+	tw.Flush()
+}
+
+// reconciliationJSON is the JSON form of a ynabber.Reconciliation.
+type reconciliationJSON struct {
+	Writer     string             `json:"writer"`
+	Reader     string             `json:"reader"`
+	Account    ynabber.Account    `json:"account"`
+	Bank       ynabber.Milliunits `json:"bank"`
+	Budget     ynabber.Milliunits `json:"budget"`
+	Difference ynabber.Milliunits `json:"difference"`
+	Adjusted   bool               `json:"adjusted"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// printReconciliationsJSON writes the results as a JSON array.
+func printReconciliationsJSON(out io.Writer, results []ynabber.Reconciliation) error {
+	result := make([]reconciliationJSON, len(results))
+	for i, r := range results {
+		result[i] = reconciliationJSON{
+			Writer:     r.Writer,
+			Reader:     r.Reader,
+			Account:    r.Account,
+			Bank:       r.Bank,
+			Budget:     r.Budget,
+			Difference: r.Difference(),
+			Adjusted:   r.Adjusted,
+		}
+		if r.Err != nil {
+			result[i].Error = r.Err.Error()
+		}
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/internal/log"
+	"github.com/martinohansen/ynabber/transformer/rules"
+)
+
+// rulesTest applies the rules of a rules file to sample transactions and
+// prints what every rule did to them, without reading or writing anything.
+// The transactions are read as a JSON array, like the JSON writer prints
+// them, from the file given as argument or from stdin.
+func rulesTest(args []string) {
+	flags := flag.NewFlagSet("rules test", flag.ExitOnError)
+	path := flags.String("file", "", "rules file to test (default: RULES_FILE)")
+	asJSON := flags.Bool("json", false, "print the result as JSON instead of a table")
+	flags.Parse(args)
+
+	setup()
+	logger := slog.Default()
+	if *path == "" {
+		var cfg rules.Config
+		if err := ynabber.ProcessEnv("", &cfg); err != nil {
+			log.Fatal(logger, "processing config", "error", err)
+		}
+		*path = cfg.File
+	}
+	if *path == "" {
+		log.Fatal(logger, "no rules file, set RULES_FILE or pass -file")
+	}
+	ruleset, err := rules.Load(*path)
+	if err != nil {
+		log.Fatal(logger, "loading rules", "error", err)
+	}
+
+	input := io.Reader(os.Stdin)
+	if flags.NArg() > 0 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			log.Fatal(logger, "opening transactions", "error", err)
+		}
+		defer f.Close()
+		input = f
+	}
+	var transactions []ynabber.Transaction
+	if err := json.NewDecoder(input).Decode(&transactions); err != nil {
+		log.Fatal(logger, "parsing transactions", "error", err)
+	}
+
+	results := make([]ruleResult, len(transactions))
+	for i, t := range transactions {
+		result, applied, keep := ruleset.Apply(t)
+		results[i] = ruleResult{Transaction: t, Result: result, Rules: applied, Skipped: !keep}
+	}
+
+	if *asJSON {
+		err = printRuleResultsJSON(os.Stdout, results)
+	} else {
+		printRuleResults(os.Stdout, results)
+	}
+	if err != nil {
+		log.Fatal(logger, "printing result", "error", err)
+	}
+}
+
+// ruleResult is what the rules did to a transaction.
+type ruleResult struct {
+	Transaction ynabber.Transaction `json:"transaction"`
+	Result      ynabber.Transaction `json:"result"`
+	Rules       []string            `json:"rules"`
+	Skipped     bool                `json:"skipped"`
+}
+
+// printRuleResults writes a table of every transaction after the rules were
+// applied, with the old value of every field they changed.
+func printRuleResults(out io.Writer, results []ruleResult) {
+	changed := func(before, after string) string {
+		if before == after {
+			return dash(after)
+		}
+		return fmt.Sprintf("%s -> %s", dash(before), dash(after))
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tACCOUNT\tAMOUNT\tPAYEE\tMEMO\tCATEGORY\tFLAG\tRULES")
+	for _, r := range results {
+		before, after := r.Transaction, r.Result
+		rules := dash(strings.Join(r.Rules, ", "))
+		if r.Skipped {
+			rules += " (skipped)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			after.Date.Format(time.DateOnly),
+			dash(accountKey(after.Account)),
+			changed(formatAmount(before.Amount), formatAmount(after.Amount)),
+			changed(before.Payee, after.Payee),
+			changed(before.Memo, after.Memo),
+			changed(categories(before), categories(after)),
+			changed(before.Flag, after.Flag),
+			rules,
+		)
+	}
+	tw.Flush()
+}
+
+// categories returns the category of t, or the categories and amounts of its
+// parts if it is split.
+func categories(t ynabber.Transaction) string {
+	if len(t.Splits) == 0 {
+		return t.Category
+	}
+	parts := make([]string, len(t.Splits))
+	for i, split := range t.Splits {
+		parts[i] = fmt.Sprintf("%s %s", dash(split.Category), formatAmount(split.Amount))
+	}
+	return "split: " + strings.Join(parts, ", ")
+}
+
+// printRuleResultsJSON writes the results as a JSON array.
+func printRuleResultsJSON(out io.Writer, results []ruleResult) error {
+	for i := range results {
+		if results[i].Rules == nil {
+			results[i].Rules = []string{}
+		}
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
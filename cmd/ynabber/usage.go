@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/martinohansen/ynabber"
+)
+
+// configFormat lists the environment variables of a config struct along with
+// their defaults.
+const configFormat = `{{range .}}    {{usage_key .}}	{{with usage_default .}}(default: {{.}}){{end}}
+{{end}}`
+
+// usage prints how to run Ynabber along with every registered reader,
+// transformer and writer and the environment variables configuring them.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, `Usage: ynabber [command]
+
+Ynabber reads transactions from your bank and writes them to your budget. It is
+configured with environment variables, see CONFIGURATION.md for details.
+
+Commands:
+  (none)        read and write transactions
+  run -once     fetch from every reader once and exit when everything is
+                written, ignoring the reader intervals
+  backfill -from 2024-01-01 [-to 2024-06-30]
+                read a date range from every reader once, split into
+                windows the bank accepts, ignoring the configured dates
+  plan [-json]  read from every reader once and show what each writer would
+                create, skip or reject as a duplicate, without writing
+  reconcile [-adjust] [-json]
+                compare the bank balance of every account with the cleared
+                balance in each writer, add -adjust to correct differences
+  accounts      list the accounts of every reader and how writers map them,
+                add -map to print account maps ready to paste
+  rules test [-file rules.yaml] [-json] [transactions.json]
+                apply a rules file to transactions, a JSON array like the
+                JSON writer prints, and show what every rule changed
+  config check  check the config of every configured component without
+                touching the network
+
+`)
+	printConfig(out, "Ynabber", &ynabber.Config{})
+	printComponents(out, "Readers (YNABBER_READERS)", ynabber.Readers())
+	printComponents(out, "Transformers (YNABBER_TRANSFORMERS)", ynabber.Transformers())
+	printComponents(out, "Writers (YNABBER_WRITERS)", ynabber.Writers())
+	flag.PrintDefaults()
+}
+
+func printComponents(out io.Writer, title string, components []ynabber.Component) {
+	fmt.Fprintf(out, "%s:\n", title)
+	for _, c := range components {
+		fmt.Fprintf(out, "  %s\n", c.Name)
+		if c.Config != nil {
+			printVariables(out, c.Config)
+		}
+	}
+	fmt.Fprintln(out)
+}
+
+func printConfig(out io.Writer, title string, config any) {
+	fmt.Fprintf(out, "%s:\n", title)
+	printVariables(out, config)
+	fmt.Fprintln(out)
+}
+
+func printVariables(out io.Writer, config any) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if err := envconfig.Usagef("", config, tw, configFormat); err != nil {
+		fmt.Fprintf(tw, "    error listing config: %v\n", err)
+	}
+	tw.Flush()
+}
@@ -1,10 +1,18 @@
 // Ynabber moves transactions from reader to writer in a fan-out fashion. Every
-// writer will receive all transactions from all readers.
+// writer will receive all transactions from all readers, after they have passed
+// through the transformers, unless routes limit what a writer receives.
 package ynabber
 
-//go:generate go run ./cmd/gendocs -file config.go -file reader/*/config.go -file writer/*/config.go -o CONFIGURATION.md
+import "time"
+
+//go:generate go run ./cmd/gendocs -file config.go -file reader/*/config.go -file transformer/*/config.go -file writer/*/config.go -o CONFIGURATION.md
 
 type Config struct {
+	// ConfigFile is the path to a YAML file setting any of the environment
+	// variables listed here. Variables set in the environment override the
+	// file. See the README for the format.
+	ConfigFile string `envconfig:"YNABBER_CONFIG"`
+
 	// DataDir is the path for storing files
 	DataDir string `envconfig:"YNABBER_DATADIR" default:"."`
 
@@ -14,9 +22,50 @@ type Config struct {
 	// LogFormat sets the logging format (text, json)
 	LogFormat string `envconfig:"YNABBER_LOG_FORMAT" default:"text"`
 
-	// Readers is a list of sources to read transactions from.
+	// HTTPAddr is the address to serve Prometheus metrics on at /metrics,
+	// and the state of every reader and writer on /healthz and /readyz,
+	// e.g. ":9090". Leave it empty to disable the HTTP server.
+	HTTPAddr string `envconfig:"YNABBER_HTTP_ADDR"`
+
+	// Readers is a list of sources to read transactions from. Add an instance
+	// name to run a reader more than once, e.g.
+	// "enablebanking:dnb,enablebanking:nordea". Each instance reads its
+	// config from variables prefixed with the upper-case name, like
+	// DNB_ENABLEBANKING_ASPSP, falling back to the unprefixed variables.
 	Readers []string `envconfig:"YNABBER_READERS" default:"nordigen"`
 
+	// Transformers is an ordered list of transformers applied to every batch
+	// of transactions before it is sent to the writers.
+	Transformers []string `envconfig:"YNABBER_TRANSFORMERS"`
+
 	// Writers is a list of destinations to write transactions to.
 	Writers []string `envconfig:"YNABBER_WRITERS" default:"ynab"`
+
+	// Routes decide which writers receive which transactions, as a JSON
+	// object mapping writer names to a list of routes. A transaction is sent
+	// to a writer if any of its routes match, and writers without routes
+	// receive everything. A route matches on any combination of "account"
+	// (IBAN or ID), "reader" and "sign" ("inflow" or "outflow").
+	// Example: '{"ynab": [{"account": "NO8330001234567"}], "actual":
+	// [{"reader": "enablebanking", "sign": "outflow"}]}'
+	Routes Routes `envconfig:"YNABBER_ROUTES"`
+
+	// WriterAttempts is how many times a failing batch is written before it
+	// is dropped. Each writer retries on its own, so a failing writer never
+	// stops readers or other writers.
+	WriterAttempts int `envconfig:"YNABBER_WRITER_ATTEMPTS" default:"5"`
+
+	// WriterBackoff is the delay before the first retry of a failing batch.
+	// It doubles on every attempt, up to 30 minutes.
+	WriterBackoff time.Duration `envconfig:"YNABBER_WRITER_BACKOFF" default:"30s"`
+
+	// ShutdownTimeout is how long writers get to finish the batch they are
+	// writing after SIGINT or SIGTERM. Readers stop right away.
+	ShutdownTimeout time.Duration `envconfig:"YNABBER_SHUTDOWN_TIMEOUT" default:"30s"`
+
+	// Ledger records which transactions have been delivered to each writer in
+	// ledger.json inside DataDir. Writers then only receive transactions that
+	// are new or have changed since they were last written, and the file
+	// shows exactly what was pushed where.
+	Ledger bool `envconfig:"YNABBER_LEDGER" default:"false"`
 }
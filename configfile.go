@@ -0,0 +1,171 @@
+package ynabber
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfigFile reads the YAML config file at path and keeps the settings it
+// configures in memory, where ProcessEnv looks up every variable that is not
+// set in the environment. The environment thus overrides the file, and the
+// settings, secrets included, are never exported to child processes.
+//
+// Keys are the names of the environment variables, either flat or nested by
+// their underscore-separated parts, and are case-insensitive:
+//
+//	ynabber:
+//	  readers: [enablebanking:dnb]
+//	ynab:
+//	  token: secret
+//	  accountmap:
+//	    NO8330001234567: 5a5b7a5c-0000-0000-0000-000000000000
+//	dnb:
+//	  enablebanking:
+//	    aspsp: DNB
+//
+// Lists become comma-separated values, while maps and lists of maps, like
+// account maps and routes, become JSON.
+func LoadConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	if len(root.Content) == 0 {
+		return nil // Empty file
+	}
+
+	values := make(map[string]string)
+	if err := flattenConfig(root.Content[0], nil, knownConfigKeys(), values); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	configFile.Lock()
+	defer configFile.Unlock()
+	configFile.values = values
+	return nil
+}
+
+// flattenConfig walks node and stores the value of every known environment
+// variable in values. Mappings are descended into until their path names a
+// known variable.
+func flattenConfig(node *yaml.Node, path []string, known []string, values map[string]string) error {
+	key := strings.ToUpper(strings.Join(path, "_"))
+	if len(path) > 0 && isConfigKey(key, known) {
+		value, err := configValue(node)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		values[key] = value
+		return nil
+	}
+
+	if node.Kind != yaml.MappingNode {
+		if len(path) == 0 {
+			return fmt.Errorf("expected a mapping at the top level")
+		}
+		return fmt.Errorf("unknown setting %s", key)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name := node.Content[i].Value
+		if err := flattenConfig(node.Content[i+1], append(slices.Clone(path), name), known, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isConfigKey reports whether key is one of the known environment variables,
+// possibly prefixed by the name of a reader instance.
+func isConfigKey(key string, known []string) bool {
+	for _, k := range known {
+		if key == k || strings.HasSuffix(key, "_"+k) && instanceName.MatchString(strings.TrimSuffix(key, "_"+k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// configValue encodes node the way envconfig expects to decode it.
+func configValue(node *yaml.Node) (string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value, nil
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return configJSON(node)
+			}
+			items = append(items, item.Value)
+		}
+		return strings.Join(items, ","), nil
+	case yaml.MappingNode:
+		return configJSON(node)
+	case yaml.AliasNode:
+		return configValue(node.Alias)
+	default:
+		return "", fmt.Errorf("unsupported value")
+	}
+}
+
+func configJSON(node *yaml.Node) (string, error) {
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("encoding as JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// knownConfigKeys returns the environment variables of Config and of every
+// registered component.
+func knownConfigKeys() []string {
+	keys := envconfigKeys(&Config{})
+	for _, components := range [][]Component{Readers(), Writers(), Transformers()} {
+		for _, c := range components {
+			keys = append(keys, envconfigKeys(c.Config)...)
+		}
+	}
+	return keys
+}
+
+// envconfigKeys returns the envconfig tags of the fields of the struct config
+// points to, including the _FILE variants of secrets.
+func envconfigKeys(config any) []string {
+	if config == nil {
+		return nil
+	}
+	t := reflect.TypeOf(config)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var keys []string
+	for field := range t.Fields() {
+		key := field.Tag.Get("envconfig")
+		if key == "" {
+			continue
+		}
+		keys = append(keys, key)
+		if field.Tag.Get("secret") == "true" {
+			keys = append(keys, key+fileSuffix)
+		}
+	}
+	return keys
+}
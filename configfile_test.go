@@ -0,0 +1,107 @@
+package ynabber
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type configFileTestConfig struct {
+	AccountMap map[string]string `envconfig:"CONFIGFILETEST_ACCOUNTMAP"`
+	Token      string            `envconfig:"CONFIGFILETEST_TOKEN"`
+	Interval   string            `envconfig:"CONFIGFILETEST_INTERVAL"`
+}
+
+func init() {
+	RegisterWriter("configfile-test", nil, &configFileTestConfig{})
+}
+
+// unsetenv unsets keys for the duration of the test
+func unsetenv(t *testing.T, keys ...string) {
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "ynabber.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	unsetenv(t,
+		"YNABBER_READERS", "YNABBER_ROUTES", "CONFIGFILETEST_ACCOUNTMAP",
+		"CONFIGFILETEST_INTERVAL", "DNB_CONFIGFILETEST_INTERVAL",
+	)
+	t.Setenv("CONFIGFILETEST_TOKEN", "from-env")
+
+	path := writeConfigFile(t, `
+ynabber:
+  readers: [enablebanking:dnb, nordigen]
+  routes:
+    ynab:
+      - account: NO1
+configfiletest:
+  token: from-file
+  accountmap:
+    NO1: abc
+CONFIGFILETEST_INTERVAL: 6h
+dnb:
+  configfiletest:
+    interval: 1h
+`)
+	if err := LoadConfigFile(path); err != nil {
+		t.Fatalf("LoadConfigFile() failed: %v", err)
+	}
+
+	t.Cleanup(func() { configFile.values = nil })
+
+	for key, want := range map[string]string{
+		"YNABBER_READERS":             "enablebanking:dnb,nordigen",
+		"YNABBER_ROUTES":              `{"ynab":[{"account":"NO1"}]}`,
+		"CONFIGFILETEST_ACCOUNTMAP":   `{"NO1":"abc"}`,
+		"CONFIGFILETEST_TOKEN":        "from-env",
+		"CONFIGFILETEST_INTERVAL":     "6h",
+		"DNB_CONFIGFILETEST_INTERVAL": "1h",
+	} {
+		if got, _ := lookupConfig(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if _, ok := os.LookupEnv("CONFIGFILETEST_ACCOUNTMAP"); ok {
+		t.Error("setting from the config file was exported to the environment")
+	}
+
+	var cfg struct {
+		Interval string `envconfig:"CONFIGFILETEST_INTERVAL"`
+		Token    string `envconfig:"CONFIGFILETEST_TOKEN" secret:"true"`
+	}
+	if err := ProcessEnv("dnb", &cfg); err != nil {
+		t.Fatalf("ProcessEnv() failed: %v", err)
+	}
+	if cfg.Interval != "1h" || cfg.Token != "from-env" {
+		t.Errorf("ProcessEnv() = %+v, want the prefixed interval from the file and the token from the environment", cfg)
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown setting": "ynab:\n  tokn: secret\n",
+		"not a mapping":   "- readers\n",
+		"invalid yaml":    "ynabber: [\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := LoadConfigFile(writeConfigFile(t, content)); err == nil {
+				t.Error("LoadConfigFile() error = nil, want error")
+			}
+		})
+	}
+
+	if err := LoadConfigFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadConfigFile() error = nil for missing file, want error")
+	}
+}
@@ -0,0 +1,224 @@
+package ynabber
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// fileSuffix is appended to the environment variable of a secret to read its
+// value from a file instead, e.g. YNAB_TOKEN_FILE=/run/secrets/ynab_token.
+const fileSuffix = "_FILE"
+
+// configFile holds the settings read by LoadConfigFile, keyed by environment
+// variable. They are kept in memory rather than exported to the environment.
+var configFile struct {
+	sync.RWMutex
+	values map[string]string
+}
+
+// lookupConfig returns the value of the environment variable key or, if it
+// isn't set, of the same setting in the config file.
+func lookupConfig(key string) (string, bool) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true
+	}
+	configFile.RLock()
+	defer configFile.RUnlock()
+	value, ok := configFile.values[key]
+	return value, ok
+}
+
+// ProcessEnv populates spec from environment variables like envconfig.Process
+// does, honoring the envconfig, default and required tags. Settings read by
+// LoadConfigFile are used for variables that are not set in the environment.
+// In addition, string fields tagged with secret:"true" can be read from the
+// file named by the variable with a _FILE suffix, which is how Docker and
+// Kubernetes secrets are usually mounted. Variables are looked up in the order
+// PREFIX_KEY, PREFIX_KEY_FILE, KEY and KEY_FILE and the first one set wins.
+//
+// Neither the config file nor secrets are exported to the environment, so
+// child processes like the Nordigen requisition hook don't inherit them.
+func ProcessEnv(prefix string, spec any) error {
+	v := reflect.ValueOf(spec)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return envconfig.ErrInvalidSpecification
+	}
+	v = v.Elem()
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !v.Field(i).CanSet() || field.Tag.Get("ignored") == "true" {
+			continue
+		}
+		key := strings.ToUpper(field.Tag.Get("envconfig"))
+		if key == "" {
+			key = strings.ToUpper(field.Name)
+		}
+
+		value, ok, err := lookupField(prefix, key, field)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if value, ok = field.Tag.Lookup("default"); !ok || value == "" {
+				if field.Tag.Get("required") == "true" {
+					return fmt.Errorf("required key %s missing value", key)
+				}
+				continue
+			}
+		}
+		if err := decodeConfig(value, v.Field(i)); err != nil {
+			return &envconfig.ParseError{
+				KeyName:   key,
+				FieldName: field.Name,
+				TypeName:  field.Type.String(),
+				Value:     value,
+				Err:       err,
+			}
+		}
+	}
+	return nil
+}
+
+// lookupField returns the value configured for the field with key, trying
+// the prefixed variable first.
+func lookupField(prefix, key string, field reflect.StructField) (string, bool, error) {
+	if field.Tag.Get("secret") == "true" && field.Type.Kind() == reflect.String {
+		return lookupSecret(prefix, key)
+	}
+	if prefix != "" {
+		if value, ok := lookupConfig(strings.ToUpper(prefix) + "_" + key); ok {
+			return value, true, nil
+		}
+	}
+	value, ok := lookupConfig(key)
+	return value, ok, nil
+}
+
+// lookupSecret returns the value of the secret key, reading it from a file if
+// only the _FILE variant is set. It returns false if the secret is not set
+// anywhere.
+func lookupSecret(prefix, key string) (string, bool, error) {
+	keys := []string{key}
+	if prefix != "" {
+		keys = []string{strings.ToUpper(prefix) + "_" + key, key}
+	}
+	for _, key := range keys {
+		value, set := lookupConfig(key)
+		path, fromFile := lookupConfig(key + fileSuffix)
+		switch {
+		case set && fromFile:
+			return "", false, fmt.Errorf("both %s and %s are set, use only one", key, key+fileSuffix)
+		case set:
+			return value, true, nil
+		case fromFile:
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", false, fmt.Errorf("reading %s: %w", key+fileSuffix, err)
+			}
+			return strings.TrimRight(string(data), "\r\n"), true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// decodeConfig decodes value into field the way envconfig does: with its
+// envconfig.Decoder or encoding.TextUnmarshaler implementation if it has one,
+// lists as comma-separated values and maps as comma-separated key:value
+// pairs.
+func decodeConfig(value string, field reflect.Value) error {
+	if field.CanAddr() {
+		switch decoder := field.Addr().Interface().(type) {
+		case envconfig.Decoder:
+			return decoder.Decode(value)
+		case encoding.TextUnmarshaler:
+			return decoder.UnmarshalText([]byte(value))
+		}
+	}
+
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return decodeConfig(value, field.Elem())
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.Type() == reflect.TypeFor[time.Duration]() {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		list := reflect.MakeSlice(field.Type(), 0, 0)
+		if strings.TrimSpace(value) != "" {
+			items := strings.Split(value, ",")
+			list = reflect.MakeSlice(field.Type(), len(items), len(items))
+			for i, item := range items {
+				if err := decodeConfig(item, list.Index(i)); err != nil {
+					return err
+				}
+			}
+		}
+		field.Set(list)
+	case reflect.Map:
+		m := reflect.MakeMap(field.Type())
+		if strings.TrimSpace(value) != "" {
+			for pair := range strings.SplitSeq(value, ",") {
+				k, v, ok := strings.Cut(pair, ":")
+				if !ok {
+					return fmt.Errorf("invalid map item: %q", pair)
+				}
+				key := reflect.New(field.Type().Key()).Elem()
+				if err := decodeConfig(k, key); err != nil {
+					return err
+				}
+				elem := reflect.New(field.Type().Elem()).Elem()
+				if err := decodeConfig(v, elem); err != nil {
+					return err
+				}
+				m.SetMapIndex(key, elem)
+			}
+		}
+		field.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package ynabber
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type envTestConfig struct {
+	Token  string `envconfig:"ENVTEST_TOKEN" secret:"true"`
+	BankID string `envconfig:"ENVTEST_BANKID"`
+}
+
+func TestProcessEnv(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "token")
+	if err := os.WriteFile(secret, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		prefix  string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "plain",
+			env:  map[string]string{"ENVTEST_TOKEN": "from-env"},
+			want: "from-env",
+		},
+		{
+			name: "file",
+			env:  map[string]string{"ENVTEST_TOKEN_FILE": secret},
+			want: "from-file",
+		},
+		{
+			name:    "both set",
+			env:     map[string]string{"ENVTEST_TOKEN": "from-env", "ENVTEST_TOKEN_FILE": secret},
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			env:     map[string]string{"ENVTEST_TOKEN_FILE": filepath.Join(dir, "missing")},
+			wantErr: true,
+		},
+		{
+			name:   "prefixed file wins over plain",
+			prefix: "dnb",
+			env:    map[string]string{"DNB_ENVTEST_TOKEN_FILE": secret, "ENVTEST_TOKEN": "from-env"},
+			want:   "from-file",
+		},
+		{
+			name:   "prefixed plain wins over file",
+			prefix: "dnb",
+			env:    map[string]string{"DNB_ENVTEST_TOKEN": "from-prefix", "ENVTEST_TOKEN_FILE": secret},
+			want:   "from-prefix",
+		},
+		{
+			name:   "falls back to unprefixed file",
+			prefix: "dnb",
+			env:    map[string]string{"ENVTEST_TOKEN_FILE": secret},
+			want:   "from-file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetenv(t,
+				"ENVTEST_TOKEN", "ENVTEST_TOKEN_FILE",
+				"DNB_ENVTEST_TOKEN", "DNB_ENVTEST_TOKEN_FILE",
+			)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			var cfg envTestConfig
+			err := ProcessEnv(tt.prefix, &cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if cfg.Token != tt.want {
+				t.Errorf("Token = %q, want %q", cfg.Token, tt.want)
+			}
+			if _, ok := os.LookupEnv("ENVTEST_TOKEN"); ok && tt.env["ENVTEST_TOKEN"] == "" {
+				t.Error("secret read from file was exported to the environment")
+			}
+		})
+	}
+}
+
+type envDecodeConfig struct {
+	Name     string            `envconfig:"ENVTEST_NAME" default:"ynabber"`
+	Count    int               `envconfig:"ENVTEST_COUNT"`
+	Interval time.Duration     `envconfig:"ENVTEST_INTERVAL" default:"6h"`
+	Ratio    float64           `envconfig:"ENVTEST_RATIO"`
+	Enabled  *bool             `envconfig:"ENVTEST_ENABLED"`
+	Readers  []string          `envconfig:"ENVTEST_READERS"`
+	Map      map[string]string `envconfig:"ENVTEST_MAP"`
+	Policy   UpdatePolicy      `envconfig:"ENVTEST_POLICY"`
+	Required string            `envconfig:"ENVTEST_REQUIRED" required:"true"`
+}
+
+func TestProcessEnvDecodes(t *testing.T) {
+	unsetenv(t,
+		"ENVTEST_NAME", "ENVTEST_COUNT", "ENVTEST_INTERVAL", "ENVTEST_RATIO", "ENVTEST_ENABLED",
+		"ENVTEST_READERS", "ENVTEST_MAP", "ENVTEST_POLICY", "ENVTEST_REQUIRED",
+	)
+	var cfg envDecodeConfig
+	if err := ProcessEnv("", &cfg); err == nil {
+		t.Fatal("ProcessEnv() error = nil, want error for the missing required key")
+	}
+
+	t.Setenv("ENVTEST_COUNT", "3")
+	t.Setenv("ENVTEST_RATIO", "0.5")
+	t.Setenv("ENVTEST_ENABLED", "false")
+	t.Setenv("ENVTEST_READERS", "a,b")
+	t.Setenv("ENVTEST_MAP", "x:1,y:2")
+	t.Setenv("ENVTEST_POLICY", "Always")
+	t.Setenv("ENVTEST_REQUIRED", "set")
+	if err := ProcessEnv("", &cfg); err != nil {
+		t.Fatalf("ProcessEnv() failed: %v", err)
+	}
+	want := envDecodeConfig{
+		Name:     "ynabber",
+		Count:    3,
+		Interval: 6 * time.Hour,
+		Ratio:    0.5,
+		Enabled:  new(false),
+		Readers:  []string{"a", "b"},
+		Map:      map[string]string{"x": "1", "y": "2"},
+		Policy:   UpdateAlways,
+		Required: "set",
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("ProcessEnv() = %+v, want %+v", cfg, want)
+	}
+
+	t.Setenv("ENVTEST_COUNT", "three")
+	if err := ProcessEnv("", &cfg); err == nil {
+		t.Error("ProcessEnv() error = nil, want error for an invalid count")
+	}
+}
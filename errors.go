@@ -5,3 +5,7 @@ import (
 )
 
 var ErrNotFound = errors.New("not found")
+
+// ErrShutdownTimeout is returned by RunContext when writers did not finish
+// within the shutdown timeout.
+var ErrShutdownTimeout = errors.New("shutdown timeout exceeded before writers finished")
@@ -11,4 +11,5 @@ require (
 	github.com/kelseyhightower/envconfig v1.4.0
 	golang.org/x/sync v0.22.0
 	golang.org/x/text v0.41.0
+	gopkg.in/yaml.v3 v3.0.1
 )
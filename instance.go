@@ -0,0 +1,42 @@
+package ynabber
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var instanceName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// ParseInstance splits a reader or writer name like "enablebanking:dnb" into
+// its kind and instance name. The instance name is empty for plain names like
+// "enablebanking". Instance names may only contain letters, digits and
+// underscores since they become part of environment variable names.
+func ParseInstance(name string) (kind, instance string, err error) {
+	kind, instance, named := strings.Cut(name, ":")
+	if !named {
+		return kind, "", nil
+	}
+	if !instanceName.MatchString(instance) {
+		return "", "", fmt.Errorf("invalid instance name %q in %q: use only letters, digits and underscores", instance, name)
+	}
+	return kind, instance, nil
+}
+
+// EnvPrefix returns the prefix of the environment variables configuring a
+// named instance, e.g. "DNB" for instance "dnb" so ENABLEBANKING_ASPSP is read
+// from DNB_ENABLEBANKING_ASPSP. Variables without the prefix are used as a
+// fallback, letting instances share settings like credentials. The prefix is
+// empty for unnamed instances.
+func EnvPrefix(instance string) string {
+	return strings.ToUpper(instance)
+}
+
+// InstanceName joins kind and instance back into the name used in logs and
+// routes, e.g. "enablebanking:dnb".
+func InstanceName(kind, instance string) string {
+	if instance == "" {
+		return kind
+	}
+	return kind + ":" + instance
+}
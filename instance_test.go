@@ -0,0 +1,33 @@
+package ynabber
+
+import "testing"
+
+func TestParseInstance(t *testing.T) {
+	tests := []struct {
+		name         string
+		wantKind     string
+		wantInstance string
+		wantErr      bool
+	}{
+		{"enablebanking", "enablebanking", "", false},
+		{"enablebanking:dnb", "enablebanking", "dnb", false},
+		{"nordigen:sparebanken_vest", "nordigen", "sparebanken_vest", false},
+		{"enablebanking:", "", "", true},
+		{"enablebanking:sparebanken-vest", "", "", true},
+		{"enablebanking:a:b", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, instance, err := ParseInstance(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseInstance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if kind != tt.wantKind || instance != tt.wantInstance {
+				t.Errorf("ParseInstance() = %q, %q, want %q, %q", kind, instance, tt.wantKind, tt.wantInstance)
+			}
+			if !tt.wantErr && InstanceName(kind, instance) != tt.name {
+				t.Errorf("InstanceName() = %q, want %q", InstanceName(kind, instance), tt.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+// Package metrics keeps counters, gauges and histograms and serves them in the
+// Prometheus text exposition format. It implements only what Ynabber needs so
+// it does not pull in the Prometheus client library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds a set of metrics.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Default is the registry the metrics of this package are registered in.
+var Default = &Registry{}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.metrics {
+		if existing.name() == m.name() {
+			panic(fmt.Sprintf("metrics: %s registered twice", m.name()))
+		}
+	}
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes all metrics in r in the Prometheus text format, sorted by
+// name.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+
+	slices.SortFunc(metrics, func(a, b metric) int {
+		return strings.Compare(a.name(), b.name())
+	})
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// ServeHTTP serves the metrics in r.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.Write(w)
+}
+
+// vec holds one value per combination of label values.
+type vec[V any] struct {
+	desc   desc
+	mu     sync.Mutex
+	values map[string]*V
+	labels map[string][]string
+}
+
+type desc struct {
+	name, help, kind string
+	labels           []string
+}
+
+func (d desc) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, d.kind)
+}
+
+func newVec[V any](name, help, kind string, labels []string) *vec[V] {
+	return &vec[V]{
+		desc:   desc{name: name, help: help, kind: kind, labels: labels},
+		values: make(map[string]*V),
+		labels: make(map[string][]string),
+	}
+}
+
+func (v *vec[V]) name() string { return v.desc.name }
+
+// with returns the value for labelValues, creating it with init when missing.
+// It must be called with v.mu held.
+func (v *vec[V]) with(labelValues []string, init func() *V) *V {
+	if len(labelValues) != len(v.desc.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", v.desc.name, len(v.desc.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	value, ok := v.values[key]
+	if !ok {
+		value = init()
+		v.values[key] = value
+		v.labels[key] = slices.Clone(labelValues)
+	}
+	return value
+}
+
+// each calls fn for every value sorted by label values. It must be called
+// with v.mu held.
+func (v *vec[V]) each(fn func(labelValues []string, value *V)) {
+	keys := make([]string, 0, len(v.values))
+	for key := range v.values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fn(v.labels[key], v.values[key])
+	}
+}
+
+// CounterVec is a set of counters partitioned by label values.
+type CounterVec struct {
+	*vec[float64]
+}
+
+// NewCounterVec creates a counter with the given label names and registers it
+// in Default.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newVec[float64](name, help, "counter", labels)}
+	Default.register(c)
+	return c
+}
+
+// Add adds delta, which must not be negative, to the counter for labelValues.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.with(labelValues, new0) += delta
+}
+
+// Inc increments the counter for labelValues by one.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Value returns the counter for labelValues.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *c.with(labelValues, new0)
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.desc.header(w)
+	c.each(func(labelValues []string, value *float64) {
+		fmt.Fprintf(w, "%s%s %s\n", c.desc.name, formatLabels(c.desc.labels, labelValues), formatFloat(*value))
+	})
+}
+
+// GaugeVec is a set of gauges partitioned by label values.
+type GaugeVec struct {
+	*vec[float64]
+}
+
+// NewGaugeVec creates a gauge with the given label names and registers it in
+// Default.
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{newVec[float64](name, help, "gauge", labels)}
+	Default.register(g)
+	return g
+}
+
+// Set sets the gauge for labelValues to value.
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.with(labelValues, new0) = value
+}
+
+// Value returns the gauge for labelValues.
+func (g *GaugeVec) Value(labelValues ...string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return *g.with(labelValues, new0)
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.desc.header(w)
+	g.each(func(labelValues []string, value *float64) {
+		fmt.Fprintf(w, "%s%s %s\n", g.desc.name, formatLabels(g.desc.labels, labelValues), formatFloat(*value))
+	})
+}
+
+// HistogramVec is a set of histograms partitioned by label values.
+type HistogramVec struct {
+	*vec[histogram]
+	buckets []float64
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec creates a histogram with the given upper bucket bounds,
+// sorted in increasing order, and label names and registers it in Default.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{newVec[histogram](name, help, "histogram", labels), buckets}
+	Default.register(h)
+	return h
+}
+
+// Observe adds value to the histogram for labelValues.
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hist := h.with(labelValues, func() *histogram {
+		return &histogram{counts: make([]uint64, len(h.buckets))}
+	})
+	for i, bound := range h.buckets {
+		if value <= bound {
+			hist.counts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += value
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.desc.header(w)
+	bucketLabels := append(slices.Clone(h.desc.labels), "le")
+	h.each(func(labelValues []string, hist *histogram) {
+		bucket := func(le string, count uint64) {
+			values := append(slices.Clone(labelValues), le)
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.desc.name, formatLabels(bucketLabels, values), count)
+		}
+		for i, bound := range h.buckets {
+			bucket(formatFloat(bound), hist.counts[i])
+		}
+		bucket("+Inf", hist.count)
+
+		labels := formatLabels(h.desc.labels, labelValues)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.desc.name, labels, formatFloat(hist.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.desc.name, labels, hist.count)
+	})
+}
+
+func new0() *float64 { return new(float64) }
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, name, labelEscaper.Replace(values[i]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	counter := NewCounterVec("test_counter_total", "A counter.", "name")
+	gauge := NewGaugeVec("test_gauge", "A gauge.")
+	histogram := NewHistogramVec("test_histogram_seconds", "A histogram.", []float64{0.5, 1}, "name")
+
+	counter.Inc(`quote"d`)
+	counter.Add(2, `quote"d`)
+	counter.Inc("plain")
+	gauge.Set(1.5)
+	histogram.Observe(0.25, "a")
+	histogram.Observe(0.75, "a")
+	histogram.Observe(2, "a")
+
+	var b strings.Builder
+	Default.Write(&b)
+	got := b.String()
+
+	for _, want := range []string{
+		"# HELP test_counter_total A counter.\n# TYPE test_counter_total counter\n" +
+			"test_counter_total{name=\"plain\"} 1\n" +
+			"test_counter_total{name=\"quote\\\"d\"} 3\n",
+		"# TYPE test_gauge gauge\ntest_gauge 1.5\n",
+		"test_histogram_seconds_bucket{name=\"a\",le=\"0.5\"} 1\n" +
+			"test_histogram_seconds_bucket{name=\"a\",le=\"1\"} 2\n" +
+			"test_histogram_seconds_bucket{name=\"a\",le=\"+Inf\"} 3\n" +
+			"test_histogram_seconds_sum{name=\"a\"} 3\n" +
+			"test_histogram_seconds_count{name=\"a\"} 3\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output is missing\n%s\ngot:\n%s", want, got)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: Transport("test-client", nil)}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	failing := &http.Client{Transport: Transport("test-client", roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}))}
+	if _, err := failing.Get(server.URL); err == nil {
+		t.Fatal("expected error from failing transport")
+	}
+
+	if got := APIRequests.Value("test-client", "418"); got != 1 {
+		t.Errorf("requests with code 418 = %v, want 1", got)
+	}
+	if got := APIRequests.Value("test-client", "0"); got != 1 {
+		t.Errorf("requests without response = %v, want 1", got)
+	}
+}
+
+func TestSucceeded(t *testing.T) {
+	before := time.Now().Unix()
+	Succeeded("test-component")
+	if got := LastSuccess.Value("test-component"); got < float64(before) {
+		t.Errorf("last success = %v, want at least %v", got, before)
+	}
+}
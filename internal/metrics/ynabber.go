@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics exposed by Ynabber. Readers and writers are labelled by the name
+// returned from their String method.
+var (
+	TransactionsRead = NewCounterVec("ynabber_transactions_read_total",
+		"Transactions read from the bank.", "reader")
+	TransactionsSkipped = NewCounterVec("ynabber_transactions_skipped_total",
+		"Transactions skipped by a reader, e.g. because they are not booked yet.", "reader")
+	TransactionsWritten = NewCounterVec("ynabber_transactions_written_total",
+		"Transactions delivered to a writer.", "writer")
+	TransactionsFailed = NewCounterVec("ynabber_transactions_failed_total",
+		"Transactions dropped after a writer failed to deliver them.", "writer")
+	BatchesWritten = NewCounterVec("ynabber_batches_written_total",
+		"Batches a writer delivered.", "writer")
+	BatchesFailed = NewCounterVec("ynabber_batches_failed_total",
+		"Batches dropped after a writer failed to deliver them.", "writer")
+
+	LastSuccess = NewGaugeVec("ynabber_last_success_timestamp_seconds",
+		"Unix time of the last successful run of a reader or writer.", "component")
+
+	SessionValidUntil = NewGaugeVec("ynabber_enablebanking_session_valid_until_timestamp_seconds",
+		"Unix time the EnableBanking session of a reader expires.", "reader")
+
+	APIRequests = NewCounterVec("ynabber_api_requests_total",
+		"Requests made to external APIs, by status code. Code 0 means no response was received.", "client", "code")
+	APIRequestDuration = NewHistogramVec("ynabber_api_request_duration_seconds",
+		"Latency of requests made to external APIs.",
+		[]float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}, "client")
+)
+
+// Succeeded records that component, a reader or writer, completed a run now.
+func Succeeded(component string) {
+	LastSuccess.Set(float64(time.Now().Unix()), component)
+}
+
+// ObserveRequest records a request made by client that completed with status
+// code after duration. Use code 0 when no response was received.
+func ObserveRequest(client string, code int, duration time.Duration) {
+	APIRequests.Inc(client, strconv.Itoa(code))
+	APIRequestDuration.Observe(duration.Seconds(), client)
+}
+
+// Transport returns a RoundTripper recording every request sent through base
+// as made by client. A nil base uses http.DefaultTransport.
+func Transport(client string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return transport{client: client, base: base}
+}
+
+type transport struct {
+	client string
+	base   http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	code := 0
+	if resp != nil {
+		code = resp.StatusCode
+	}
+	ObserveRequest(t.client, code, time.Since(start))
+	return resp, err
+}
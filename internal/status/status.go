@@ -0,0 +1,191 @@
+// Package status tracks what each reader and writer is doing and serves it on
+// health and readiness endpoints.
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// State is what a component is currently doing.
+type State string
+
+const (
+	// Idle components wait for their next run or for work.
+	Idle State = "idle"
+	// Fetching readers are reading transactions from the bank.
+	Fetching State = "fetching"
+	// Writing writers are delivering a batch.
+	Writing State = "writing"
+	// WaitingForAuth readers wait for the user to authorize bank access.
+	WaitingForAuth State = "waiting-for-auth"
+	// BackingOff components wait before retrying after an error.
+	BackingOff State = "backing-off"
+	// Failed components stopped or dropped work because of an error.
+	Failed State = "failed"
+)
+
+// Report is the state of a component at a point in time.
+type Report struct {
+	Kind  string    `json:"kind"`
+	Name  string    `json:"name"`
+	State State     `json:"state"`
+	Since time.Time `json:"since"`
+	// NextRun is when the component runs again, if it is scheduled to.
+	NextRun time.Time `json:"next_run,omitzero"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Component reports the state of a single reader or writer. Its methods do
+// nothing on a nil Component, so code can report without checking whether it
+// was set up with one.
+type Component struct {
+	mu     sync.Mutex
+	report Report
+	now    func() time.Time
+}
+
+// Registry holds the components of a process.
+type Registry struct {
+	mu         sync.Mutex
+	components []*Component
+	now        func() time.Time
+}
+
+// Default is the registry used by Register and served by Handler.
+var Default = &Registry{}
+
+// Register returns the component of the given kind ("reader" or "writer") and
+// name, adding it as idle if it is not known yet.
+func Register(kind, name string) *Component {
+	return Default.Register(kind, name)
+}
+
+// Register returns the component of the given kind and name, adding it as
+// idle if it is not known yet.
+func (r *Registry) Register(kind, name string) *Component {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.components {
+		if c.report.Kind == kind && c.report.Name == name {
+			return c
+		}
+	}
+
+	now := r.now
+	if now == nil {
+		now = time.Now
+	}
+	c := &Component{
+		report: Report{Kind: kind, Name: name, State: Idle, Since: now()},
+		now:    now,
+	}
+	r.components = append(r.components, c)
+	return c
+}
+
+// Set changes the state, clearing any next run and error.
+func (c *Component) Set(state State) {
+	c.update(state, time.Time{}, nil)
+}
+
+// Wait marks the component idle until its next run at next.
+func (c *Component) Wait(next time.Time) {
+	c.update(Idle, next, nil)
+}
+
+// BackOff marks the component as backing off after err until next.
+func (c *Component) BackOff(next time.Time, err error) {
+	c.update(BackingOff, next, err)
+}
+
+// Fail marks the component as failed with err.
+func (c *Component) Fail(err error) {
+	c.update(Failed, time.Time{}, err)
+}
+
+func (c *Component) update(state State, next time.Time, err error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.report.State != state {
+		c.report.Since = c.now()
+	}
+	c.report.State = state
+	c.report.NextRun = next
+	c.report.Error = ""
+	if err != nil {
+		c.report.Error = err.Error()
+	}
+}
+
+// Report returns the current state of the component.
+func (c *Component) Report() Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.report
+}
+
+// Reports returns the state of every component sorted by kind and name.
+func (r *Registry) Reports() []Report {
+	r.mu.Lock()
+	components := slices.Clone(r.components)
+	r.mu.Unlock()
+
+	reports := make([]Report, len(components))
+	for i, c := range components {
+		reports[i] = c.Report()
+	}
+	slices.SortFunc(reports, func(a, b Report) int {
+		if n := strings.Compare(a.Kind, b.Kind); n != 0 {
+			return n
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return reports
+}
+
+// response is the body served by the health and readiness endpoints.
+type response struct {
+	Status     string   `json:"status"`
+	Components []Report `json:"components"`
+}
+
+// HealthHandler serves the state of all components in r. It responds with 503
+// Service Unavailable when any component has failed.
+func (r *Registry) HealthHandler() http.Handler {
+	return r.handler(Failed)
+}
+
+// ReadyHandler serves the state of all components in r. It responds with 503
+// Service Unavailable when any component has failed or waits for the user to
+// authorize bank access.
+func (r *Registry) ReadyHandler() http.Handler {
+	return r.handler(Failed, WaitingForAuth)
+}
+
+// handler serves the reports in r, failing when any component is in one of
+// the unhealthy states.
+func (r *Registry) handler(unhealthy ...State) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		res := response{Status: "ok", Components: r.Reports()}
+		code := http.StatusOK
+		for _, report := range res.Components {
+			if slices.Contains(unhealthy, report.State) {
+				res.Status = "unavailable"
+				code = http.StatusServiceUnavailable
+				break
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(res)
+	})
+}
@@ -0,0 +1,80 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestComponentStates(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := &Registry{now: func() time.Time { return now }}
+
+	c := r.Register("reader", "nordigen")
+	if got := r.Register("reader", "nordigen"); got != c {
+		t.Fatal("registering the same component twice returned a new one")
+	}
+
+	next := now.Add(time.Hour)
+	c.BackOff(next, errors.New("rate limited"))
+	want := Report{Kind: "reader", Name: "nordigen", State: BackingOff, Since: now, NextRun: next, Error: "rate limited"}
+	if got := c.Report(); got != want {
+		t.Errorf("Report() = %+v, want %+v", got, want)
+	}
+
+	c.Set(Fetching)
+	want = Report{Kind: "reader", Name: "nordigen", State: Fetching, Since: now}
+	if got := c.Report(); got != want {
+		t.Errorf("Report() = %+v, want %+v", got, want)
+	}
+
+	var nilComponent *Component
+	nilComponent.Set(Fetching) // Must not panic
+}
+
+func TestHandlers(t *testing.T) {
+	tests := []struct {
+		name       string
+		state      State
+		wantHealth int
+		wantReady  int
+	}{
+		{"idle", Idle, http.StatusOK, http.StatusOK},
+		{"fetching", Fetching, http.StatusOK, http.StatusOK},
+		{"backing off", BackingOff, http.StatusOK, http.StatusOK},
+		{"waiting for auth", WaitingForAuth, http.StatusOK, http.StatusServiceUnavailable},
+		{"failed", Failed, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Registry{}
+			r.Register("writer", "ynab")
+			r.Register("reader", "enablebanking").Set(tt.state)
+
+			for _, h := range []struct {
+				handler http.Handler
+				want    int
+			}{
+				{r.HealthHandler(), tt.wantHealth},
+				{r.ReadyHandler(), tt.wantReady},
+			} {
+				rec := httptest.NewRecorder()
+				h.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+				if rec.Code != h.want {
+					t.Errorf("status code = %d, want %d", rec.Code, h.want)
+				}
+
+				var res response
+				if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+					t.Fatalf("decoding response: %v", err)
+				}
+				if len(res.Components) != 2 || res.Components[0].Name != "enablebanking" || res.Components[0].State != tt.state {
+					t.Errorf("components = %+v, want readers before writers", res.Components)
+				}
+			}
+		})
+	}
+}
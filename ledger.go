@@ -0,0 +1,217 @@
+package ynabber
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/martinohansen/ynabber/internal/atomicfile"
+)
+
+// ledgerVersion is bumped whenever the on-disk format changes incompatibly.
+const ledgerVersion = 1
+
+// LedgerEntry records a single transaction delivered to a writer.
+type LedgerEntry struct {
+	// Hash fingerprints the fields writers identify a transaction by and
+	// update in place, see fingerprint. A transaction whose hash differs from
+	// the recorded one is updated or delivered again.
+	Hash string `json:"hash"`
+
+	// WrittenAt is when the writer last accepted the transaction.
+	WrittenAt time.Time `json:"written_at"`
+
+	// Transaction is the transaction as it was delivered, kept for auditing
+	// and to update it in place when the bank enriches it.
+	Transaction Transaction `json:"transaction"`
+}
+
+type ledgerFile struct {
+	Version int                               `json:"version"`
+	Writers map[string]map[string]LedgerEntry `json:"writers"`
+}
+
+// Ledger is a durable record of which transactions have been delivered to
+// each writer. Ynabber consults it before every delivery so writers only
+// receive transactions that are new or have changed since they were last
+// written.
+type Ledger struct {
+	path    string
+	mu      sync.Mutex
+	writers map[string]map[string]LedgerEntry
+	now     func() time.Time
+}
+
+// OpenLedger loads the ledger stored at path. A missing file yields an empty
+// ledger which is created on the first delivery.
+func OpenLedger(path string) (*Ledger, error) {
+	l := &Ledger{
+		path:    path,
+		writers: make(map[string]map[string]LedgerEntry),
+		now:     time.Now,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading ledger: %w", err)
+	}
+
+	var file ledgerFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing ledger %s: %w", path, err)
+	}
+	if file.Version != ledgerVersion {
+		return nil, fmt.Errorf("unsupported ledger version %d in %s", file.Version, path)
+	}
+	for writer, entries := range file.Writers {
+		if entries != nil {
+			l.writers[writer] = entries
+		}
+	}
+	return l, nil
+}
+
+// Pending returns the transactions in batch that have not been delivered to
+// writer, or that have changed since they were.
+func (l *Ledger) Pending(writer string, batch []Transaction) []Transaction {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pending := make([]Transaction, 0, len(batch))
+	for _, t := range batch {
+		if !l.delivered(writer, t) {
+			pending = append(pending, t)
+		}
+	}
+	return pending
+}
+
+// Prune forgets the entries of writer dated before the oldest transaction of
+// their account in read, the whole batch returned by a reader. The reader no
+// longer returns them, as they fell out of its read window, so the ledger
+// would only grow with them. read must not be narrowed by routing or by
+// promoted pending transactions, which would make the window look shorter
+// than it is and forget entries the reader still returns. The next Record
+// persists their removal.
+func (l *Ledger) Prune(writer string, read []Transaction) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	oldest := make(map[string]time.Time)
+	for _, t := range read {
+		account := accountKey(t)
+		if date, ok := oldest[account]; !ok || t.Date.Before(date) {
+			oldest[account] = t.Date
+		}
+	}
+	for key, entry := range l.writers[writer] {
+		date, ok := oldest[accountKey(entry.Transaction)]
+		if ok && entry.Transaction.Date.Before(date) {
+			delete(l.writers[writer], key)
+		}
+	}
+}
+
+// Delivered reports whether t has been delivered to writer and is unchanged
+// since.
+func (l *Ledger) Delivered(writer string, t Transaction) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.delivered(writer, t)
+}
+
+// delivered is Delivered for callers holding l.mu.
+func (l *Ledger) delivered(writer string, t Transaction) bool {
+	entry, ok := l.writers[writer][ledgerKey(t)]
+	return ok && entry.Hash == fingerprint(t)
+}
+
+// Previous returns the version of t last delivered to writer, if t has
+// changed since.
+func (l *Ledger) Previous(writer string, t Transaction) (Transaction, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.writers[writer][ledgerKey(t)]
+	if !ok || entry.Hash == fingerprint(t) {
+		return Transaction{}, false
+	}
+	return entry.Transaction, true
+}
+
+// Record marks delivered as written by writer and persists the ledger.
+func (l *Ledger) Record(writer string, delivered []Transaction) error {
+	if len(delivered) == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries, ok := l.writers[writer]
+	if !ok {
+		entries = make(map[string]LedgerEntry)
+		l.writers[writer] = entries
+	}
+	now := l.now().UTC()
+	for _, t := range delivered {
+		entries[ledgerKey(t)] = LedgerEntry{
+			Hash:        fingerprint(t),
+			WrittenAt:   now,
+			Transaction: t,
+		}
+	}
+	return l.save()
+}
+
+// save writes the ledger to a temporary file and renames it into place so a
+// crash never leaves a half-written ledger behind. Callers must hold l.mu.
+func (l *Ledger) save() error {
+	data, err := json.MarshalIndent(ledgerFile{
+		Version: ledgerVersion,
+		Writers: l.writers,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling ledger: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(l.path, data, 0644); err != nil {
+		return fmt.Errorf("writing ledger: %w", err)
+	}
+	return nil
+}
+
+// ledgerKey identifies a transaction across runs. IBAN is preferred over
+// account ID for the same reason the writers prefer it in their import IDs:
+// it is stable across EnableBanking sessions. Transactions without a source
+// ID are keyed by their content.
+func ledgerKey(t Transaction) string {
+	id := string(t.ID)
+	if id == "" {
+		id = fingerprint(t)
+	}
+	return accountKey(t) + "/" + id
+}
+
+// fingerprint hashes the transaction fields writers identify a transaction by,
+// the date and amount, and the payee and memo, which banks enrich after
+// booking and writers update in place. Category, flag, splits and transfer
+// are left out: they are set by transformers rather than read from the bank,
+// and writers don't update them once written. The hash input uses a NUL
+// separator to prevent field collisions.
+func fingerprint(t Transaction) string {
+	parts := [][]byte{
+		[]byte(t.Date.Format(time.DateOnly)),
+		[]byte(t.Amount.String()),
+		[]byte(t.Payee),
+		[]byte(t.Memo),
+	}
+	hash := sha256.Sum256(bytes.Join(parts, []byte{0}))
+	return fmt.Sprintf("%x", hash)
+}
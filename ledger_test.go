@@ -0,0 +1,152 @@
+package ynabber
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLedgerPendingAndRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	ledger, err := OpenLedger(path)
+	if err != nil {
+		t.Fatalf("OpenLedger() error = %v", err)
+	}
+
+	first := Transaction{
+		Account: Account{IBAN: "NO1234567890"},
+		ID:      "tx-1",
+		Date:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Payee:   "Grocer",
+		Amount:  -1000,
+	}
+	second := first
+	second.ID = "tx-2"
+
+	if got := ledger.Pending("ynab", []Transaction{first, second}); len(got) != 2 {
+		t.Fatalf("Pending() on empty ledger = %d transactions, want 2", len(got))
+	}
+
+	if err := ledger.Record("ynab", []Transaction{first}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	got := ledger.Pending("ynab", []Transaction{first, second})
+	if len(got) != 1 || got[0].ID != "tx-2" {
+		t.Fatalf("Pending() = %+v, want only tx-2", got)
+	}
+
+	// Deliveries are tracked per writer.
+	if got := ledger.Pending("actual", []Transaction{first}); len(got) != 1 {
+		t.Fatalf("Pending() for other writer = %d transactions, want 1", len(got))
+	}
+
+	// A changed transaction is delivered again.
+	enriched := first
+	enriched.Memo = "Card purchase 1234"
+	if got := ledger.Pending("ynab", []Transaction{enriched}); len(got) != 1 {
+		t.Fatalf("Pending() for changed transaction = %d transactions, want 1", len(got))
+	}
+}
+
+func TestLedgerPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	ledger, err := OpenLedger(path)
+	if err != nil {
+		t.Fatalf("OpenLedger() error = %v", err)
+	}
+	writtenAt := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	ledger.now = func() time.Time { return writtenAt }
+
+	tx := Transaction{
+		Account: Account{ID: "account-uid"},
+		ID:      "tx-1",
+		Date:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Amount:  2500,
+	}
+	if err := ledger.Record("ynab", []Transaction{tx}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	reopened, err := OpenLedger(path)
+	if err != nil {
+		t.Fatalf("OpenLedger() after Record error = %v", err)
+	}
+	if got := reopened.Pending("ynab", []Transaction{tx}); len(got) != 0 {
+		t.Fatalf("Pending() after reopen = %d transactions, want 0", len(got))
+	}
+
+	entry := reopened.writers["ynab"][ledgerKey(tx)]
+	if !entry.WrittenAt.Equal(writtenAt) {
+		t.Errorf("WrittenAt = %v, want %v", entry.WrittenAt, writtenAt)
+	}
+	if entry.Transaction.ID != tx.ID {
+		t.Errorf("recorded transaction ID = %q, want %q", entry.Transaction.ID, tx.ID)
+	}
+}
+
+func TestOpenLedgerRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenLedger(path); err == nil {
+		t.Fatal("OpenLedger() error = nil, want parse error")
+	}
+}
+
+func TestLedgerKeyWithoutID(t *testing.T) {
+	tx := Transaction{
+		Account: Account{IBAN: "NO1234567890"},
+		Date:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Payee:   "Grocer",
+		Amount:  -1000,
+	}
+	other := tx
+	other.Payee = "Bakery"
+
+	if ledgerKey(tx) == ledgerKey(other) {
+		t.Error("ledgerKey() should fall back to content when ID is empty")
+	}
+}
+
+func TestLedgerPrunesOutsideReadWindow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	ledger, err := OpenLedger(path)
+	if err != nil {
+		t.Fatalf("OpenLedger() error = %v", err)
+	}
+	tx := func(id, iban string, day int) Transaction {
+		return Transaction{
+			Account: Account{IBAN: iban},
+			ID:      ID(id),
+			Date:    time.Date(2024, 5, day, 0, 0, 0, 0, time.UTC),
+			Amount:  -1000,
+		}
+	}
+	old, kept, other := tx("old", "NO1", 1), tx("kept", "NO1", 10), tx("other", "NO2", 1)
+	if err := ledger.Record("ynab", []Transaction{old, kept, other}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	// The reader now returns NO1 from the 10th, while NO2 wasn't read
+	current := tx("new", "NO1", 12)
+	ledger.Prune("ynab", []Transaction{kept, current})
+	if err := ledger.Record("ynab", []Transaction{current}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	reopened, err := OpenLedger(path)
+	if err != nil {
+		t.Fatalf("OpenLedger() error = %v", err)
+	}
+	for _, tt := range []struct {
+		t    Transaction
+		want bool
+	}{{old, false}, {kept, true}, {other, true}, {current, true}} {
+		if got := reopened.Delivered("ynab", tt.t); got != tt.want {
+			t.Errorf("Delivered(%s) = %v, want %v", tt.t.ID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package ynabber
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/martinohansen/ynabber/internal/atomicfile"
+)
+
+// pendingVersion is bumped whenever the on-disk format changes incompatibly.
+const pendingVersion = 1
+
+const (
+	// pendingMatchDays is how many days after a pending transaction its
+	// booked counterpart may be dated. Card purchases usually book within a
+	// few days.
+	pendingMatchDays = 7
+
+	// promotedRetention is how long a promotion is remembered. Until then the
+	// booked transaction is recognized and not written again when a reader
+	// returns it.
+	promotedRetention = 365 * 24 * time.Hour
+)
+
+// Promoter is implemented by writers that can import pending transactions
+// and later replace them with their booked counterpart. Writers that don't
+// implement it never receive pending transactions.
+type Promoter interface {
+	// Promote updates the transaction written for pending with the details
+	// of booked, keeping any changes made to it in the budget.
+	Promote(ctx context.Context, pending, booked Transaction) error
+
+	// Remove deletes the transaction written for pending, which the bank
+	// dropped without booking it. A transaction that is already gone is not
+	// an error.
+	Remove(ctx context.Context, pending Transaction) error
+}
+
+// PendingReader is implemented by readers that can return pending
+// transactions. Ynabber only tracks pending transactions when a reader
+// reports that it returns them.
+type PendingReader interface {
+	ReadsPending() bool
+}
+
+// PendingEntry records a pending transaction written to a writer.
+type PendingEntry struct {
+	// Transaction is the pending transaction as it was written.
+	Transaction Transaction `json:"transaction"`
+
+	// WrittenAt is when the writer accepted the transaction.
+	WrittenAt time.Time `json:"written_at"`
+
+	// PromotedTo is the booked transaction that replaced it, if any.
+	PromotedTo *Transaction `json:"promoted_to,omitempty"`
+
+	// PromotedAt is when it was replaced.
+	PromotedAt time.Time `json:"promoted_at,omitzero"`
+}
+
+type pendingFile struct {
+	Version int                                `json:"version"`
+	Writers map[string]map[string]PendingEntry `json:"writers"`
+}
+
+// PendingTracker is a durable record of the pending transactions written to
+// each writer. Ynabber uses it to promote them once their booked counterpart
+// arrives instead of writing a duplicate, and to remove the ones the bank
+// drops.
+type PendingTracker struct {
+	path    string
+	mu      sync.Mutex
+	writers map[string]map[string]PendingEntry
+	// promoted maps the key of every booked transaction that replaced a
+	// pending one to the key of the pending one, per writer.
+	promoted map[string]map[string]string
+	now      func() time.Time
+}
+
+// OpenPendingTracker loads the pending transactions stored at path. A missing
+// file yields an empty tracker which is created on the first pending
+// transaction.
+func OpenPendingTracker(path string) (*PendingTracker, error) {
+	p := &PendingTracker{
+		path:     path,
+		writers:  make(map[string]map[string]PendingEntry),
+		promoted: make(map[string]map[string]string),
+		now:      time.Now,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading pending transactions: %w", err)
+	}
+
+	var file pendingFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing pending transactions %s: %w", path, err)
+	}
+	if file.Version != pendingVersion {
+		return nil, fmt.Errorf("unsupported pending transactions version %d in %s", file.Version, path)
+	}
+	for writer, entries := range file.Writers {
+		if entries == nil {
+			continue
+		}
+		p.writers[writer] = entries
+		for key, entry := range entries {
+			if entry.PromotedTo != nil {
+				p.indexPromoted(writer, ledgerKey(*entry.PromotedTo), key)
+			}
+		}
+	}
+	return p, nil
+}
+
+// Track records the pending transactions in written as written to writer.
+// Booked transactions are ignored.
+func (p *PendingTracker) Track(writer string, written []Transaction) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	now := p.now().UTC()
+	for _, t := range written {
+		if t.Status != StatusPending {
+			continue
+		}
+		entries, ok := p.writers[writer]
+		if !ok {
+			entries = make(map[string]PendingEntry)
+			p.writers[writer] = entries
+		}
+		entries[ledgerKey(t)] = PendingEntry{Transaction: t, WrittenAt: now}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return p.save()
+}
+
+// Tracked reports for every transaction in batch whether it is a pending
+// transaction that has been written to writer, see pairPending.
+func (p *PendingTracker) Tracked(writer string, batch []Transaction) []bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	tracked := make([]bool, len(batch))
+	for i := range p.pairPending(writer, batch) {
+		tracked[i] = true
+	}
+	return tracked
+}
+
+// pairPending pairs the pending transactions in batch with the ones written
+// to writer and returns the key of the written one by index in batch. A
+// pending transaction pairs with the written one of the same ID or, since
+// some banks give pending transactions a new ID on every fetch, with one of
+// the same account and amount it could be a later version of, see
+// counterpartOf. The closest in date wins and every written transaction pairs
+// at most once. Callers must hold p.mu.
+func (p *PendingTracker) pairPending(writer string, batch []Transaction) map[int]string {
+	entries := p.writers[writer]
+	pairs := make(map[int]string)
+	paired := make(map[string]bool)
+	for i, t := range batch {
+		if t.Status != StatusPending {
+			continue
+		}
+		if key := ledgerKey(t); !paired[key] {
+			if _, ok := entries[key]; ok {
+				pairs[i], paired[key] = key, true
+			}
+		}
+	}
+	type candidate struct {
+		index int
+		key   string
+		apart time.Duration
+	}
+	var candidates []candidate
+	for i, t := range batch {
+		if _, ok := pairs[i]; ok || t.Status != StatusPending {
+			continue
+		}
+		for key, entry := range entries {
+			if !paired[key] && entry.PromotedTo == nil && counterpartOf(entry.Transaction, t) {
+				candidates = append(candidates, candidate{i, key, t.Date.Sub(entry.Transaction.Date).Abs()})
+			}
+		}
+	}
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		return cmp.Or(cmp.Compare(a.apart, b.apart), cmp.Compare(a.index, b.index), strings.Compare(a.key, b.key))
+	})
+	for _, c := range candidates {
+		if _, ok := pairs[c.index]; !ok && !paired[c.key] {
+			pairs[c.index], paired[c.key] = c.key, true
+		}
+	}
+	return pairs
+}
+
+// Promoted reports whether the booked transaction t already replaced a
+// pending transaction written to writer.
+func (p *PendingTracker) Promoted(writer string, t Transaction) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.promoted[writer][ledgerKey(t)]
+	return ok
+}
+
+// Match returns the pending transaction written to writer that the booked
+// transaction t is the counterpart of. It must be of the same account and
+// amount, dated at most pendingMatchDays before t, and have the same
+// counterparty if both know it. The closest in date wins.
+func (p *PendingTracker) Match(writer string, t Transaction) (Transaction, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var (
+		best  Transaction
+		found bool
+	)
+	for _, entry := range p.writers[writer] {
+		pending := entry.Transaction
+		if entry.PromotedTo != nil || !counterpartOf(pending, t) {
+			continue
+		}
+		if !found || t.Date.Sub(pending.Date).Abs() < t.Date.Sub(best.Date).Abs() {
+			best, found = pending, true
+		}
+	}
+	return best, found
+}
+
+// counterpartOf reports whether booked can be the booked counterpart of
+// pending, or a later version of pending that the bank gave a new ID.
+func counterpartOf(pending, booked Transaction) bool {
+	if accountKey(pending) != accountKey(booked) || pending.Amount != booked.Amount {
+		return false
+	}
+	from, to := dateOf(pending.Date).AddDate(0, 0, -1), dateOf(pending.Date).AddDate(0, 0, pendingMatchDays)
+	if date := dateOf(booked.Date); date.Before(from) || date.After(to) {
+		return false
+	}
+	a, b := pending.Counterparty.Name, booked.Counterparty.Name
+	return a == "" || b == "" || strings.EqualFold(a, b)
+}
+
+// Promote records that booked replaced pending at writer.
+func (p *PendingTracker) Promote(writer string, pending, booked Transaction) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := ledgerKey(pending)
+	entry, ok := p.writers[writer][key]
+	if !ok {
+		return fmt.Errorf("pending transaction %s is not tracked", key)
+	}
+	entry.PromotedTo = &booked
+	entry.PromotedAt = p.now().UTC()
+	p.writers[writer][key] = entry
+	p.indexPromoted(writer, ledgerKey(booked), key)
+	return p.save()
+}
+
+// Vanished returns the pending transactions written to writer that are
+// missing from batch, which means the bank dropped them. A pending
+// transaction reported under a new ID is not missing, see pairPending. Only
+// accounts that batch has transactions of, dated on or after the pending
+// transaction, are considered, so batches covering another account or an
+// older date range don't remove anything.
+func (p *PendingTracker) Vanished(writer string, batch []Transaction) []Transaction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	present := make(map[string]bool, len(batch))
+	for _, key := range p.pairPending(writer, batch) {
+		present[key] = true
+	}
+	latest := make(map[string]time.Time)
+	for _, t := range batch {
+		present[ledgerKey(t)] = true
+		if account := accountKey(t); t.Date.After(latest[account]) {
+			latest[account] = t.Date
+		}
+	}
+
+	var vanished []Transaction
+	for key, entry := range p.writers[writer] {
+		pending := entry.Transaction
+		if entry.PromotedTo != nil || present[key] {
+			continue
+		}
+		if latest, ok := latest[accountKey(pending)]; ok && !latest.Before(pending.Date) {
+			vanished = append(vanished, pending)
+		}
+	}
+	return vanished
+}
+
+// Forget stops tracking the pending transaction t written to writer.
+func (p *PendingTracker) Forget(writer string, t Transaction) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.writers[writer], ledgerKey(t))
+	return p.save()
+}
+
+func (p *PendingTracker) indexPromoted(writer, bookedKey, pendingKey string) {
+	index, ok := p.promoted[writer]
+	if !ok {
+		index = make(map[string]string)
+		p.promoted[writer] = index
+	}
+	index[bookedKey] = pendingKey
+}
+
+// save drops promotions older than promotedRetention and writes the tracker
+// to disk atomically. Callers must hold p.mu.
+func (p *PendingTracker) save() error {
+	cutoff := p.now().Add(-promotedRetention)
+	for writer, entries := range p.writers {
+		for key, entry := range entries {
+			if entry.PromotedTo != nil && entry.PromotedAt.Before(cutoff) {
+				delete(entries, key)
+				delete(p.promoted[writer], ledgerKey(*entry.PromotedTo))
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(pendingFile{
+		Version: pendingVersion,
+		Writers: p.writers,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling pending transactions: %w", err)
+	}
+	if err := atomicfile.WriteFile(p.path, data, 0644); err != nil {
+		return fmt.Errorf("writing pending transactions: %w", err)
+	}
+	return nil
+}
+
+// accountKey identifies the account of t. IBAN is preferred over account ID
+// for the same reason as in ledgerKey.
+func accountKey(t Transaction) string {
+	if t.Account.IBAN != "" {
+		return t.Account.IBAN
+	}
+	return string(t.Account.ID)
+}
+
+// withoutPending returns the booked transactions in batch.
+func withoutPending(batch []Transaction) []Transaction {
+	booked := make([]Transaction, 0, len(batch))
+	for _, t := range batch {
+		if t.Status != StatusPending {
+			booked = append(booked, t)
+		}
+	}
+	return booked
+}
@@ -0,0 +1,288 @@
+package ynabber
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPendingTrackerMatch(t *testing.T) {
+	tracker, err := OpenPendingTracker(filepath.Join(t.TempDir(), "pending.json"))
+	if err != nil {
+		t.Fatalf("OpenPendingTracker() error = %v", err)
+	}
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	pending := Transaction{
+		Account:      Account{IBAN: "NO1"},
+		ID:           "pdng-1",
+		Status:       StatusPending,
+		Date:         day,
+		Amount:       -1000,
+		Counterparty: Counterparty{Name: "Grocer"},
+	}
+	if err := tracker.Track("ynab", []Transaction{pending, {ID: "booked", Date: day}}); err != nil {
+		t.Fatalf("Track() error = %v", err)
+	}
+	if !tracker.Tracked("ynab", []Transaction{pending})[0] {
+		t.Fatal("Tracked() = false for tracked pending transaction")
+	}
+
+	booked := pending
+	booked.ID, booked.Status, booked.Date = "book-1", StatusBooked, day.AddDate(0, 0, 2)
+	booked.Counterparty.Name = "GROCER"
+
+	tests := []struct {
+		name   string
+		writer string
+		change func(*Transaction)
+		want   bool
+	}{
+		{name: "counterpart", writer: "ynab", change: func(*Transaction) {}, want: true},
+		{name: "other writer", writer: "actual", change: func(*Transaction) {}},
+		{name: "other amount", writer: "ynab", change: func(t *Transaction) { t.Amount = -2000 }},
+		{name: "other account", writer: "ynab", change: func(t *Transaction) { t.Account.IBAN = "NO2" }},
+		{name: "booked too late", writer: "ynab", change: func(t *Transaction) { t.Date = day.AddDate(0, 0, pendingMatchDays+1) }},
+		{name: "other counterparty", writer: "ynab", change: func(t *Transaction) { t.Counterparty.Name = "Bakery" }},
+		{name: "unknown counterparty", writer: "ynab", change: func(t *Transaction) { t.Counterparty.Name = "" }, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidate := booked
+			tt.change(&candidate)
+			got, ok := tracker.Match(tt.writer, candidate)
+			if ok != tt.want {
+				t.Fatalf("Match() ok = %v, want %v", ok, tt.want)
+			}
+			if ok && got.ID != pending.ID {
+				t.Errorf("Match() = %s, want %s", got.ID, pending.ID)
+			}
+		})
+	}
+}
+
+func TestPendingTrackerPersistsPromotions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pending.json")
+	tracker, err := OpenPendingTracker(path)
+	if err != nil {
+		t.Fatalf("OpenPendingTracker() error = %v", err)
+	}
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	pending := Transaction{Account: Account{IBAN: "NO1"}, ID: "pdng-1", Status: StatusPending, Date: day, Amount: -1000}
+	booked := Transaction{Account: Account{IBAN: "NO1"}, ID: "book-1", Status: StatusBooked, Date: day, Amount: -1000}
+	if err := tracker.Track("ynab", []Transaction{pending}); err != nil {
+		t.Fatalf("Track() error = %v", err)
+	}
+	if err := tracker.Promote("ynab", pending, booked); err != nil {
+		t.Fatalf("Promote() error = %v", err)
+	}
+
+	reopened, err := OpenPendingTracker(path)
+	if err != nil {
+		t.Fatalf("OpenPendingTracker() error = %v", err)
+	}
+	if !reopened.Promoted("ynab", booked) {
+		t.Error("Promoted() = false after reopening")
+	}
+	if _, ok := reopened.Match("ynab", booked); ok {
+		t.Error("Match() found a pending transaction that was already promoted")
+	}
+}
+
+func TestPendingTrackerVanished(t *testing.T) {
+	tracker, err := OpenPendingTracker(filepath.Join(t.TempDir(), "pending.json"))
+	if err != nil {
+		t.Fatalf("OpenPendingTracker() error = %v", err)
+	}
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	pending := Transaction{Account: Account{IBAN: "NO1"}, ID: "pdng-1", Status: StatusPending, Date: day, Amount: -1000}
+	if err := tracker.Track("ynab", []Transaction{pending}); err != nil {
+		t.Fatalf("Track() error = %v", err)
+	}
+
+	later := Transaction{Account: Account{IBAN: "NO1"}, ID: "later", Date: day.AddDate(0, 0, 1)}
+	renamed := pending
+	renamed.ID = "pdng-9"
+	tests := []struct {
+		name  string
+		batch []Transaction
+		want  int
+	}{
+		{name: "still reported", batch: []Transaction{pending, later}},
+		{name: "dropped", batch: []Transaction{later}, want: 1},
+		{name: "new ID", batch: []Transaction{renamed, later}},
+		{name: "other account", batch: []Transaction{{Account: Account{IBAN: "NO2"}, Date: later.Date}}},
+		{name: "older date range", batch: []Transaction{{Account: Account{IBAN: "NO1"}, Date: day.AddDate(0, 0, -1)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tracker.Vanished("ynab", tt.batch); len(got) != tt.want {
+				t.Errorf("Vanished() = %+v, want %d transaction(s)", got, tt.want)
+			}
+		})
+	}
+}
+
+// Mock writer that delivers every transaction and records promotions and
+// removals
+type mockPromoter struct {
+	mockDeliverer
+	promoted [][2]Transaction
+	removed  []Transaction
+	mu       sync.Mutex
+}
+
+func (w *mockPromoter) String() string { return "mock-promoter" }
+
+func (w *mockPromoter) Promote(ctx context.Context, pending, booked Transaction) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.promoted = append(w.promoted, [2]Transaction{pending, booked})
+	return nil
+}
+
+func (w *mockPromoter) Remove(ctx context.Context, pending Transaction) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.removed = append(w.removed, pending)
+	return nil
+}
+
+func TestPendingTransactionsArePromoted(t *testing.T) {
+	tracker, err := OpenPendingTracker(filepath.Join(t.TempDir(), "pending.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	pending := Transaction{Account: Account{IBAN: "NO1"}, ID: "pdng-1", Status: StatusPending, Date: day, Amount: -1000}
+	dropped := Transaction{Account: Account{IBAN: "NO1"}, ID: "pdng-2", Status: StatusPending, Date: day, Amount: -500}
+	booked := Transaction{Account: Account{IBAN: "NO1"}, ID: "book-1", Status: StatusBooked, Date: day.AddDate(0, 0, 1), Amount: -1000}
+
+	promoter := &mockPromoter{}
+	plain := &mockDeliverer{}
+	y := &Ynabber{
+		Readers: []Reader{&mockMultiBatchReader{batches: [][]Transaction{
+			{pending, dropped},
+			{pending, dropped},
+			{booked},
+			{booked},
+		}}},
+		Writers: []Writer{promoter, plain},
+		Pending: tracker,
+		logger:  *slog.Default(),
+	}
+	if err := y.Run(); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	batches := promoter.getBatches()
+	if len(batches) != 1 || len(batches[0]) != 2 {
+		t.Fatalf("promoter received %+v, want the two pending transactions once", batches)
+	}
+	if len(promoter.promoted) != 1 || promoter.promoted[0][0].ID != "pdng-1" || promoter.promoted[0][1].ID != "book-1" {
+		t.Errorf("promoted %+v, want pdng-1 promoted to book-1", promoter.promoted)
+	}
+	if len(promoter.removed) != 1 || promoter.removed[0].ID != "pdng-2" {
+		t.Errorf("removed %+v, want pdng-2", promoter.removed)
+	}
+
+	for _, batch := range plain.getBatches() {
+		for _, tx := range batch {
+			if tx.Status == StatusPending {
+				t.Errorf("writer without Promoter received pending transaction %s", tx.ID)
+			}
+		}
+	}
+}
+
+func TestPendingIDChangesBetweenRuns(t *testing.T) {
+	tracker, err := OpenPendingTracker(filepath.Join(t.TempDir(), "pending.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	pending := Transaction{Account: Account{IBAN: "NO1"}, ID: "pdng-1", Status: StatusPending, Date: day, Amount: -1000}
+	// Two purchases of the same amount the next day, one of which is new
+	other := Transaction{Account: Account{IBAN: "NO1"}, ID: "pdng-2", Status: StatusPending, Date: day.AddDate(0, 0, 1), Amount: -1000}
+	renamed, otherRenamed, added := pending, other, other
+	renamed.ID, otherRenamed.ID, added.ID = "pdng-7", "pdng-8", "pdng-9"
+	booked := Transaction{Account: Account{IBAN: "NO1"}, ID: "book-1", Status: StatusBooked, Date: day, Amount: -1000}
+
+	promoter := &mockPromoter{}
+	y := &Ynabber{
+		Readers: []Reader{&mockMultiBatchReader{batches: [][]Transaction{
+			{pending, other},
+			{renamed, otherRenamed},
+			{added, renamed, otherRenamed},
+			{booked, added, otherRenamed},
+		}}},
+		Writers: []Writer{promoter},
+		Pending: tracker,
+		logger:  *slog.Default(),
+	}
+	if err := y.Run(); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	batches := promoter.getBatches()
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 || batches[1][0].Date != other.Date {
+		t.Fatalf("promoter received %+v, want the first two pending transactions and then only the added one", batches)
+	}
+	if len(promoter.removed) != 0 {
+		t.Errorf("removed %+v, want nothing removed", promoter.removed)
+	}
+	if len(promoter.promoted) != 1 || promoter.promoted[0][0].ID != "pdng-1" {
+		t.Errorf("promoted %+v, want pdng-1 promoted under the ID it was written with", promoter.promoted)
+	}
+}
+
+func TestLedgerKeepsWindowOfPromotedTransactions(t *testing.T) {
+	dir := t.TempDir()
+	tracker, err := OpenPendingTracker(filepath.Join(dir, "pending.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger, err := OpenLedger(filepath.Join(dir, "ledger.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	pending := Transaction{Account: Account{IBAN: "NO1"}, ID: "pdng-1", Status: StatusPending, Date: day, Amount: -1000}
+	still := Transaction{Account: Account{IBAN: "NO1"}, ID: "pdng-2", Status: StatusPending, Date: day.AddDate(0, 0, 1), Amount: -500}
+	later := Transaction{Account: Account{IBAN: "NO1"}, ID: "book-2", Status: StatusBooked, Date: day.AddDate(0, 0, 2), Amount: -200}
+	booked := Transaction{Account: Account{IBAN: "NO1"}, ID: "book-1", Status: StatusBooked, Date: day, Amount: -1000}
+
+	// The oldest transaction is promoted on the second run, which leaves only
+	// later in the batch delivered to the writer
+	promoter := &mockPromoter{}
+	y := &Ynabber{
+		Readers: []Reader{&mockMultiBatchReader{batches: [][]Transaction{
+			{pending, still, later},
+			{booked, still, later},
+		}}},
+		Writers: []Writer{promoter},
+		Pending: tracker,
+		Ledger:  ledger,
+		logger:  *slog.Default(),
+	}
+	if err := y.Run(); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	if len(promoter.promoted) != 1 || promoter.promoted[0][1].ID != booked.ID {
+		t.Fatalf("promoted %+v, want %s promoted to %s", promoter.promoted, pending.ID, booked.ID)
+	}
+	for _, tx := range []Transaction{still, later} {
+		if !ledger.Delivered(promoter.String(), tx) {
+			t.Errorf("ledger forgot %s, which is still in the read window", tx.ID)
+		}
+	}
+}
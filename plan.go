@@ -0,0 +1,220 @@
+package ynabber
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Action is what a writer would do with a transaction.
+type Action string
+
+const (
+	// ActionCreate means the transaction would be written.
+	ActionCreate Action = "create"
+	// ActionDuplicate means the budget already has a transaction with the
+	// same import ID, so writing it would have no effect.
+	ActionDuplicate Action = "duplicate"
+	// ActionSkip means the writer would not send the transaction at all, for
+	// example because of its date filters or an unmapped account.
+	ActionSkip Action = "skip"
+	// ActionPromote means the booked transaction would update the pending
+	// transaction written for it before.
+	ActionPromote Action = "promote"
+	// ActionRemove means a pending transaction the bank dropped would be
+	// removed.
+	ActionRemove Action = "remove"
+	// ActionUpdate means the transaction was delivered before and its payee
+	// or memo changed since, so it would be updated in place.
+	ActionUpdate Action = "update"
+	// ActionMatch means the budget has the transaction entered by hand, so
+	// that one would be marked as imported instead of a new one created.
+	ActionMatch Action = "match"
+)
+
+// Change is the planned outcome of writing a single transaction.
+type Change struct {
+	Transaction Transaction `json:"transaction"`
+	Action      Action      `json:"action"`
+	// Account is the writer account the transaction would be written to.
+	Account string `json:"account,omitempty"`
+	// ImportID is the import ID the writer would send, used to find
+	// duplicates.
+	ImportID string `json:"import_id,omitempty"`
+	// Reason explains why a transaction is skipped or a duplicate.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Planner is implemented by writers that can work out what writing a batch
+// would change, without writing anything. Plan may read from the budget to
+// find duplicates, and must return a Change for every transaction in batch.
+type Planner interface {
+	Plan(ctx context.Context, batch []Transaction) ([]Change, error)
+}
+
+// WriterPlan is the plan of a single writer.
+type WriterPlan struct {
+	Writer  string
+	Changes []Change
+	// Err is set if the writer can't plan or planning failed.
+	Err error
+}
+
+// Plan reads from every reader once, passes the transactions through the
+// transformers and routes like Run does, and asks every writer implementing
+// Planner what it would change. Nothing is written, and the ledger is only
+// read. Readers must be created with Options.Once so they return after their
+// first fetch.
+func (y *Ynabber) Plan(ctx context.Context) ([]WriterPlan, error) {
+	batches, err := y.readOnce(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	routed := make([][]Transaction, len(y.Writers))
+	for _, read := range batches {
+		batch, err := y.transform(ctx, read.transactions)
+		if err != nil {
+			return nil, err
+		}
+		for w, writer := range y.Writers {
+			routed[w] = append(routed[w], y.Routes.Filter(writer.String(), read.reader, batch)...)
+		}
+	}
+
+	plans := make([]WriterPlan, len(y.Writers))
+	for w, writer := range y.Writers {
+		plans[w] = y.planWriter(ctx, writer, routed[w])
+	}
+	return plans, nil
+}
+
+// planWriter plans batch for writer. Transactions the ledger has already
+// delivered are skipped before the writer sees them, like during a run.
+func (y *Ynabber) planWriter(ctx context.Context, writer Writer, batch []Transaction) WriterPlan {
+	plan := WriterPlan{Writer: writer.String()}
+	planner, ok := writer.(Planner)
+	if !ok {
+		plan.Err = fmt.Errorf("writer %s can't plan its changes", writer)
+		return plan
+	}
+
+	if !y.promotes(writer) {
+		booked := make([]Transaction, 0, len(batch))
+		for _, t := range batch {
+			if t.Status != StatusPending {
+				booked = append(booked, t)
+				continue
+			}
+			plan.Changes = append(plan.Changes, Change{
+				Transaction: t,
+				Action:      ActionSkip,
+				Reason:      "pending, the writer can't promote pending transactions",
+			})
+		}
+		batch = booked
+	} else {
+		var changes []Change
+		batch, changes = y.planPending(writer.String(), batch)
+		plan.Changes = append(plan.Changes, changes...)
+	}
+
+	if y.Ledger != nil {
+		_, updates := writer.(Updater)
+		pending := make([]Transaction, 0, len(batch))
+		for _, t := range batch {
+			if previous, ok := y.Ledger.Previous(writer.String(), t); ok && updates && enriched(previous, t) {
+				plan.Changes = append(plan.Changes, Change{
+					Transaction: t,
+					Action:      ActionUpdate,
+					Reason:      "payee or memo changed since it was delivered",
+				})
+				continue
+			}
+			if !y.Ledger.Delivered(writer.String(), t) {
+				pending = append(pending, t)
+				continue
+			}
+			plan.Changes = append(plan.Changes, Change{
+				Transaction: t,
+				Action:      ActionSkip,
+				Reason:      "already delivered according to the ledger",
+			})
+		}
+		batch = pending
+	}
+	if len(batch) == 0 {
+		return plan
+	}
+
+	changes, err := planner.Plan(ctx, batch)
+	if err != nil {
+		plan.Err = fmt.Errorf("planning: %w", err)
+		return plan
+	}
+	plan.Changes = append(plan.Changes, changes...)
+	return plan
+}
+
+// planPending works out which transactions in batch would promote or remove
+// pending transactions written to writer before, like promotePending, and
+// returns the rest.
+func (y *Ynabber) planPending(writer string, batch []Transaction) ([]Transaction, []Change) {
+	var changes []Change
+	remaining := make([]Transaction, 0, len(batch))
+	promoted := make(map[string]bool)
+	tracked := y.Pending.Tracked(writer, batch)
+	for i, t := range batch {
+		switch {
+		case t.Status == StatusPending && tracked[i]:
+			changes = append(changes, Change{Transaction: t, Action: ActionSkip, Reason: "pending, already written"})
+		case t.Status == StatusPending:
+			remaining = append(remaining, t)
+		case y.Pending.Promoted(writer, t):
+			changes = append(changes, Change{Transaction: t, Action: ActionSkip, Reason: "already promoted from a pending transaction"})
+		default:
+			pending, ok := y.Pending.Match(writer, t)
+			if !ok {
+				remaining = append(remaining, t)
+				continue
+			}
+			promoted[ledgerKey(pending)] = true
+			changes = append(changes, Change{Transaction: t, Action: ActionPromote, Reason: fmt.Sprintf("replaces pending transaction %s", pending.ID)})
+		}
+	}
+	for _, pending := range y.Pending.Vanished(writer, batch) {
+		if !promoted[ledgerKey(pending)] {
+			changes = append(changes, Change{Transaction: pending, Action: ActionRemove, Reason: "pending transaction no longer reported by the bank"})
+		}
+	}
+	return remaining, changes
+}
+
+// readOnce runs every reader until it returns and collects their batches in
+// the order of the readers.
+func (y *Ynabber) readOnce(ctx context.Context) ([]readerBatch, error) {
+	read := make([][]readerBatch, len(y.Readers))
+	g, ctx := errgroup.WithContext(ctx)
+	for r, reader := range y.Readers {
+		out := make(chan []Transaction)
+		g.Go(func() error {
+			defer close(out)
+			if err := reader.Runner(ctx, out); err != nil {
+				return fmt.Errorf("reading with %s: %w", reader, err)
+			}
+			return nil
+		})
+		g.Go(func() error {
+			for batch := range out {
+				read[r] = append(read[r], readerBatch{reader: reader.String(), transactions: batch})
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return slices.Concat(read...), nil
+}
@@ -0,0 +1,82 @@
+package ynabber
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// Mock planner that would create every transaction it is asked about
+type mockPlanner struct {
+	mockDeliverer
+}
+
+func (w *mockPlanner) String() string { return "mock-planner" }
+
+func (w *mockPlanner) Plan(ctx context.Context, batch []Transaction) ([]Change, error) {
+	changes := make([]Change, len(batch))
+	for i, t := range batch {
+		changes[i] = Change{Transaction: t, Action: ActionCreate}
+	}
+	return changes, nil
+}
+
+func TestPlan(t *testing.T) {
+	ledger, err := OpenLedger(t.TempDir() + "/ledger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	delivered := Transaction{ID: "delivered", Account: Account{IBAN: "NO1"}, Amount: -100, Date: date}
+	fresh := Transaction{ID: "fresh", Account: Account{IBAN: "NO1"}, Amount: -200, Date: date}
+	other := Transaction{ID: "other", Account: Account{IBAN: "NO2"}, Amount: -300, Date: date}
+
+	planner := &mockPlanner{}
+	written := delivered
+	written.Payee = "+planned"
+	if err := ledger.Record(planner.String(), []Transaction{written}); err != nil {
+		t.Fatal(err)
+	}
+	y := &Ynabber{
+		Readers:      []Reader{&mockMultiBatchReader{batches: [][]Transaction{{delivered, fresh}, {other}}}},
+		Transformers: []Transformer{mockTransformer{name: "planned"}},
+		Writers:      []Writer{planner, &mockWriter{}},
+		Routes:       Routes{"mock-planner": {{Account: "NO1"}}},
+		Ledger:       ledger,
+		logger:       *slog.Default(),
+	}
+
+	plans, err := y.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plans) != 2 {
+		t.Fatalf("Plan() returned %d plans, want 2", len(plans))
+	}
+
+	got := plans[0]
+	if got.Err != nil {
+		t.Fatalf("mock-planner failed: %v", got.Err)
+	}
+	want := map[ID]Action{"delivered": ActionSkip, "fresh": ActionCreate}
+	if len(got.Changes) != len(want) {
+		t.Fatalf("mock-planner planned %+v, want %d changes", got.Changes, len(want))
+	}
+	for _, c := range got.Changes {
+		if c.Action != want[c.Transaction.ID] {
+			t.Errorf("%s: action = %s, want %s", c.Transaction.ID, c.Action, want[c.Transaction.ID])
+		}
+		if c.Transaction.Payee != "+planned" {
+			t.Errorf("%s: payee = %q, want the transformed payee", c.Transaction.ID, c.Transaction.Payee)
+		}
+	}
+	if batches := planner.getBatches(); len(batches) != 0 {
+		t.Errorf("mock-planner was written %+v, want nothing", batches)
+	}
+
+	if plans[1].Err == nil {
+		t.Error("mock-writer can't plan, want an error")
+	}
+}
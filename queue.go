@@ -0,0 +1,96 @@
+package ynabber
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// maxQueuedBatches caps how many batches wait for a single writer. Readers
+// resend their whole history window on every run, so dropping the oldest
+// batch when a writer falls far behind loses nothing a later batch won't
+// carry again.
+const maxQueuedBatches = 100
+
+// queuedBatch is a batch routed to a writer along with the batch it was
+// routed from, which the ledger is pruned by, see Ledger.Prune.
+type queuedBatch struct {
+	transactions []Transaction
+	read         []Transaction
+}
+
+// batchQueue buffers batches for a single writer so a slow or failing writer
+// never blocks the fan-out to the others.
+type batchQueue struct {
+	mu      sync.Mutex
+	batches []queuedBatch
+	closed  bool
+	ready   chan struct{}
+	logger  *slog.Logger
+}
+
+func newBatchQueue(logger *slog.Logger) *batchQueue {
+	return &batchQueue{
+		ready:  make(chan struct{}, 1),
+		logger: logger,
+	}
+}
+
+// push appends batch to the queue without blocking.
+func (q *batchQueue) push(batch queuedBatch) {
+	q.mu.Lock()
+	if len(q.batches) >= maxQueuedBatches {
+		q.logger.Warn("writer is falling behind, dropping oldest queued batch",
+			"queued", len(q.batches),
+			"dropped_transactions", len(q.batches[0].transactions),
+		)
+		q.batches = q.batches[1:]
+	}
+	q.batches = append(q.batches, batch)
+	q.mu.Unlock()
+	q.signal()
+}
+
+// close marks the queue as complete. Queued batches can still be read.
+func (q *batchQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.signal()
+}
+
+func (q *batchQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// next blocks until a batch is available and returns it. It returns false
+// once the queue is closed and drained, or when ctx is done, even if batches
+// are still queued.
+func (q *batchQueue) next(ctx context.Context) (queuedBatch, bool) {
+	for {
+		if ctx.Err() != nil {
+			return queuedBatch{}, false
+		}
+		q.mu.Lock()
+		if len(q.batches) > 0 {
+			batch := q.batches[0]
+			q.batches = q.batches[1:]
+			q.mu.Unlock()
+			return batch, true
+		}
+		closed := q.closed
+		q.mu.Unlock()
+		if closed {
+			return queuedBatch{}, false
+		}
+
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return queuedBatch{}, false
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package ynabber
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestBatchQueueDropsOldestWhenFull(t *testing.T) {
+	q := newBatchQueue(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	q.push(queuedBatch{transactions: []Transaction{{ID: "oldest"}}})
+	for range maxQueuedBatches {
+		q.push(queuedBatch{transactions: []Transaction{{ID: "newer"}}})
+	}
+	q.close()
+
+	count := 0
+	for {
+		batch, ok := q.next(context.Background())
+		if !ok {
+			break
+		}
+		if batch.transactions[0].ID == "oldest" {
+			t.Fatal("oldest batch was not dropped")
+		}
+		count++
+	}
+	if count != maxQueuedBatches {
+		t.Errorf("read %d batches, want %d", count, maxQueuedBatches)
+	}
+}
+
+func TestBatchQueueNextStopsOnCancel(t *testing.T) {
+	q := newBatchQueue(slog.Default())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, ok := q.next(ctx); ok {
+		t.Fatal("next() = true on cancelled context, want false")
+	}
+}
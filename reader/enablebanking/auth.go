@@ -20,6 +20,9 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/martinohansen/ynabber/internal/atomicfile"
+	"github.com/martinohansen/ynabber/internal/metrics"
+	"github.com/martinohansen/ynabber/internal/status"
 )
 
 const (
@@ -166,6 +169,8 @@ type Auth struct {
 	httpClient    *http.Client
 	redirectInput io.Reader
 	logger        *slog.Logger
+	// status reports when the user needs to authorize access.
+	status *status.Component
 }
 
 // NewAuth creates a new Auth handler
@@ -174,7 +179,8 @@ func NewAuth(cfg Config, logger *slog.Logger) Auth {
 		Config:  cfg,
 		baseURL: enableBankingAPIBase,
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: metrics.Transport("enablebanking", nil),
 		},
 		redirectInput: os.Stdin,
 		logger:        logger,
@@ -275,6 +281,29 @@ func (a Auth) acquireSession(ctx context.Context) (Session, bool, error) {
 	return session, false, nil
 }
 
+// savedSession returns the session saved on disk without starting a new
+// authorization. It fails if there is no usable session.
+func (a Auth) savedSession() (Session, error) {
+	sessionFile, err := os.ReadFile(a.Config.SessionFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return Session{}, fmt.Errorf("no session in %s, run ynabber to authorize access first", a.Config.SessionFile)
+	} else if err != nil {
+		return Session{}, fmt.Errorf("reading session file: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(sessionFile, &session); err != nil {
+		return Session{}, fmt.Errorf("parsing session file: %w", err)
+	}
+	if session.CreatedAt == "" {
+		return Session{}, fmt.Errorf("session in %s was never authorized, run ynabber to authorize access first", a.Config.SessionFile)
+	}
+	if session.IsExpired() {
+		return Session{}, fmt.Errorf("session in %s expired at %s, run ynabber to authorize access again", a.Config.SessionFile, session.ValidUntil)
+	}
+	return session, nil
+}
+
 // invalidateSession removes a session that the API has rejected. The next
 // call to Session will initiate a new authorization flow. A missing file is
 // already invalidated and is not an error.
@@ -311,7 +340,9 @@ func (a Auth) saveSession(session Session) error {
 		return fmt.Errorf("marshaling session: %w", err)
 	}
 
-	err = os.WriteFile(a.Config.SessionFile, sessionData, 0600)
+	// Write atomically so a shutdown mid-write never leaves a corrupt session
+	// that forces a new authorization on the next start.
+	err = atomicfile.WriteFile(a.Config.SessionFile, sessionData, 0600)
 	if err != nil {
 		return fmt.Errorf("writing session file: %w", err)
 	}
@@ -352,7 +383,9 @@ func (a Auth) createNewSession(ctx context.Context) (Session, error) {
 
 	// Prompt user to paste the full redirect URL; code and state are extracted
 	// and validated inside the function.
+	a.status.Set(status.WaitingForAuth)
 	code, err := a.promptForRedirectURL(ctx, state)
+	a.status.Set(status.Fetching)
 	if err != nil {
 		return Session{}, fmt.Errorf("reading authorization code: %w", err)
 	}
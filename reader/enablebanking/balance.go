@@ -0,0 +1,62 @@
+package enablebanking
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// bookedBalanceTypes are the ISO 20022 balance types holding booked
+// transactions only, most current first: interim booked and closing booked.
+var bookedBalanceTypes = []string{"ITBD", "CLBD"}
+
+// Balances implements ynabber.BalanceReader. It fetches the booked balance of
+// every account of the saved session.
+func (r Reader) Balances(ctx context.Context) ([]ynabber.Balance, error) {
+	session, err := r.Auth.savedSession()
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]ynabber.Balance, 0, len(session.Accounts))
+	for _, account := range session.Accounts {
+		response, err := r.Client.GetAccountBalances(ctx, session.AuthToken, account.UID)
+		if err != nil {
+			return nil, fmt.Errorf("fetching balances for account %q: %w", maskIdentifier(account.StableID()), err)
+		}
+		balance, err := bookedBalance(response.Balances)
+		if err != nil {
+			return nil, fmt.Errorf("account %q: %w", maskIdentifier(account.StableID()), err)
+		}
+		balance.Account = ynabber.Account{
+			ID:   ynabber.ID(account.UID),
+			Name: account.DisplayName,
+			IBAN: account.StableID(),
+		}
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+
+// bookedBalance returns the most current booked balance in balances.
+func bookedBalance(balances []EBBalance) (ynabber.Balance, error) {
+	for _, balanceType := range bookedBalanceTypes {
+		for _, b := range balances {
+			if b.BalanceType != balanceType {
+				continue
+			}
+			amount, err := ynabber.MilliunitsFromString(b.BalanceAmount.Amount)
+			if err != nil {
+				return ynabber.Balance{}, fmt.Errorf("parsing %s balance: %w", balanceType, err)
+			}
+			balance := ynabber.Balance{Amount: amount, Currency: b.BalanceAmount.Currency}
+			if date, err := time.Parse(dateFormat, b.ReferenceDate); err == nil {
+				balance.Date = date
+			}
+			return balance, nil
+		}
+	}
+	return ynabber.Balance{}, fmt.Errorf("no booked balance among %d balance(s)", len(balances))
+}
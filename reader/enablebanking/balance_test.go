@@ -0,0 +1,49 @@
+package enablebanking
+
+import (
+	"testing"
+
+	"github.com/martinohansen/ynabber"
+)
+
+func TestBookedBalance(t *testing.T) {
+	balance := func(balanceType, amount string) EBBalance {
+		b := EBBalance{BalanceType: balanceType, ReferenceDate: "2024-03-01"}
+		b.BalanceAmount.Currency = "EUR"
+		b.BalanceAmount.Amount = amount
+		return b
+	}
+	tests := []struct {
+		name     string
+		balances []EBBalance
+		want     ynabber.Milliunits
+		wantErr  bool
+	}{
+		{
+			name:     "interim booked preferred",
+			balances: []EBBalance{balance("CLBD", "100.00"), balance("ITAV", "90.00"), balance("ITBD", "120.50")},
+			want:     120500,
+		},
+		{
+			name:     "closing booked",
+			balances: []EBBalance{balance("XPCD", "90.00"), balance("CLBD", "-100.00")},
+			want:     -100000,
+		},
+		{
+			name:     "no booked balance",
+			balances: []EBBalance{balance("CLAV", "90.00")},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bookedBalance(tt.balances)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bookedBalance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Amount != tt.want {
+				t.Errorf("bookedBalance() = %d, want %d", got.Amount, tt.want)
+			}
+		})
+	}
+}
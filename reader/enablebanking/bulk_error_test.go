@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -224,3 +225,33 @@ func newBulkTestReader(t *testing.T, server *httptest.Server, accounts []Account
 		logger: logger,
 	}
 }
+
+func TestFetchSessionTransactionsSplitsLongRanges(t *testing.T) {
+	var windows []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		windows = append(windows, r.URL.Query().Get("date_from")+"/"+r.URL.Query().Get("date_to"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"transactions": [], "pending": []}`)
+	}))
+	defer server.Close()
+
+	reader := newBulkTestReader(t, server, []AccountInfo{
+		{UID: "account-1", AccountID: AccountID{IBAN: "NO9812345678901"}},
+	})
+	reader.Config.FromDate = Date(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	reader.Config.ToDate = Date(time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC))
+
+	ctx := context.Background()
+	session, err := reader.Auth.Session(ctx)
+	if err != nil {
+		t.Fatalf("Session() error = %v", err)
+	}
+	if _, err := reader.fetchSessionTransactions(ctx, session); err != nil {
+		t.Fatalf("fetchSessionTransactions() error = %v", err)
+	}
+
+	want := []string{"2024-01-01/2024-03-30", "2024-03-31/2024-06-28", "2024-06-29/2024-06-30"}
+	if !slices.Equal(windows, want) {
+		t.Errorf("requested windows = %v, want %v", windows, want)
+	}
+}
@@ -4,7 +4,11 @@
 package enablebanking
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -54,8 +58,16 @@ type Config struct {
 	// Interval is the time between fetches (0 means run once and exit)
 	Interval time.Duration `envconfig:"ENABLEBANKING_INTERVAL"`
 
+	// Pending also reads pending transactions, like card purchases that are
+	// not booked yet. Writers that support it import them as uncleared and
+	// update them once they are booked. Others skip them.
+	Pending bool `envconfig:"ENABLEBANKING_PENDING" default:"false"`
+
 	// PayeeStrip contains words to remove from payee names.
 	// Example: "foo,bar" removes "foo" and "bar" from all payee names.
+	//
+	// Deprecated: add strip to YNABBER_TRANSFORMERS and set STRIP_PAYEE
+	// instead, which cleans up the payees of every reader.
 	PayeeStrip []string `envconfig:"ENABLEBANKING_PAYEE_STRIP"`
 
 	// PayeeStripRegex is a comma-separated list of regular expressions whose
@@ -64,6 +76,9 @@ type Config struct {
 	// literal comma.
 	// Example: "^Dk-Nota\S+\s+" turns "Dk-Nota61221 Remouladen" into
 	// "Remouladen".
+	//
+	// Deprecated: add strip to YNABBER_TRANSFORMERS and set
+	// STRIP_PAYEE_REGEX instead, which takes the same patterns.
 	PayeeStripRegex PayeeRegex `envconfig:"ENABLEBANKING_PAYEE_STRIP_REGEX"`
 
 	// PSUHeaders controls whether PSU headers are sent to EnableBanking.
@@ -92,6 +107,39 @@ func (c *Config) Validate(dataDir string) error {
 	return nil
 }
 
+// Check implements ynabber.Checker. It parses the private key and checks the
+// dates and addresses without contacting EnableBanking.
+func (c *Config) Check() error {
+	var errs []error
+	if keyData, err := os.ReadFile(c.PEMFile); err != nil {
+		errs = append(errs, fmt.Errorf("ENABLEBANKING_PEM_FILE: %w", err))
+	} else if _, err := (Auth{}).parsePrivateKey(keyData); err != nil {
+		errs = append(errs, fmt.Errorf("ENABLEBANKING_PEM_FILE: %s: %w", c.PEMFile, err))
+	}
+
+	if len(c.Country) != 2 {
+		errs = append(errs, fmt.Errorf("ENABLEBANKING_COUNTRY: %q is not a two-letter country code like NO", c.Country))
+	}
+	if u, err := url.Parse(c.RedirectURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("ENABLEBANKING_REDIRECT_URL: %q is not an absolute URL", c.RedirectURL))
+	}
+	if c.PSUIPAddress != "" && net.ParseIP(c.PSUIPAddress) == nil {
+		errs = append(errs, fmt.Errorf("ENABLEBANKING_PSU_IP_ADDRESS: %q is not an IP address", c.PSUIPAddress))
+	}
+
+	from, to := time.Time(c.FromDate), time.Time(c.ToDate)
+	if from.After(time.Now()) {
+		errs = append(errs, fmt.Errorf("ENABLEBANKING_FROM_DATE: %s is in the future", from.Format(dateFormat)))
+	}
+	if !to.IsZero() && to.Before(from) {
+		errs = append(errs, fmt.Errorf("ENABLEBANKING_TO_DATE: %s is before ENABLEBANKING_FROM_DATE %s", to.Format(dateFormat), from.Format(dateFormat)))
+	}
+	if c.Interval < 0 {
+		errs = append(errs, errors.New("ENABLEBANKING_INTERVAL: must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
 // GetFromDate returns FromDate as a time.Time. It is always valid after Validate.
 func (c Config) GetFromDate() (time.Time, error) {
 	return time.Time(c.FromDate), nil
@@ -112,6 +160,12 @@ func defaultSessionFile(aspsp, country string) string {
 	return fmt.Sprintf("enablebanking_%s_%s_session.json", sanitizeSessionPart(aspsp), sanitizeSessionPart(country))
 }
 
+// namedSessionFile is the default session file of a named instance, keeping
+// instances apart even when they use the same bank.
+func namedSessionFile(instance, aspsp, country string) string {
+	return fmt.Sprintf("enablebanking_%s_%s_%s_session.json", sanitizeSessionPart(instance), sanitizeSessionPart(aspsp), sanitizeSessionPart(country))
+}
+
 func sanitizeSessionPart(value string) string {
 	trimmed := strings.ToLower(strings.TrimSpace(value))
 	trimmed = strings.ReplaceAll(trimmed, " ", "_")
@@ -2,6 +2,7 @@ package enablebanking
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -270,6 +271,35 @@ func TestConfigWithEnvironmentVariables(t *testing.T) {
 	_ = testEnvVars // silence unused variable warning
 }
 
+func TestLoadEnvConfigNamedInstance(t *testing.T) {
+	// Shared settings without prefix, per-bank settings with prefix
+	t.Setenv("ENABLEBANKING_APP_ID", "shared-app")
+	t.Setenv("ENABLEBANKING_PEM_FILE", "./shared.pem")
+	t.Setenv("ENABLEBANKING_COUNTRY", "NO")
+	t.Setenv("ENABLEBANKING_ASPSP", "Nordea")
+	t.Setenv("ENABLEBANKING_FROM_DATE", "2024-01-01")
+	t.Setenv("DNB_ENABLEBANKING_ASPSP", "DNB")
+	t.Setenv("DNB_ENABLEBANKING_INTERVAL", "1h")
+
+	var cfg Config
+	if err := loadEnvConfig("DNB", &cfg); err != nil {
+		t.Fatalf("loadEnvConfig() failed: %v", err)
+	}
+	if cfg.ASPSP != "DNB" {
+		t.Errorf("ASPSP = %q, want prefixed value DNB", cfg.ASPSP)
+	}
+	if cfg.Interval != time.Hour {
+		t.Errorf("Interval = %v, want prefixed value 1h", cfg.Interval)
+	}
+	if cfg.AppID != "shared-app" || cfg.Country != "NO" {
+		t.Errorf("AppID, Country = %q, %q, want unprefixed fallbacks", cfg.AppID, cfg.Country)
+	}
+
+	if got, want := namedSessionFile("dnb", cfg.ASPSP, cfg.Country), "enablebanking_dnb_dnb_no_session.json"; got != want {
+		t.Errorf("namedSessionFile() = %q, want %q", got, want)
+	}
+}
+
 func TestConfigdateFormatsAccepted(t *testing.T) {
 	validFormats := []string{
 		"2024-01-01",
@@ -501,3 +531,59 @@ func TestDefaultSessionFile(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigCheck(t *testing.T) {
+	dir := t.TempDir()
+	pemFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(pemFile, generateTestKeyPair(t), 0600); err != nil {
+		t.Fatal(err)
+	}
+	invalidPEM := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalidPEM, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := func() Config {
+		return Config{
+			AppID:       "test-app",
+			Country:     "NO",
+			ASPSP:       "DNB",
+			RedirectURL: "https://example.com/ok.html",
+			PEMFile:     pemFile,
+			FromDate:    Date(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{name: "valid", modify: func(*Config) {}},
+		{name: "missing PEM file", modify: func(c *Config) { c.PEMFile = filepath.Join(dir, "missing.pem") }, want: "ENABLEBANKING_PEM_FILE"},
+		{name: "invalid PEM file", modify: func(c *Config) { c.PEMFile = invalidPEM }, want: "ENABLEBANKING_PEM_FILE"},
+		{name: "country", modify: func(c *Config) { c.Country = "Norway" }, want: "ENABLEBANKING_COUNTRY"},
+		{name: "redirect URL", modify: func(c *Config) { c.RedirectURL = "ok.html" }, want: "ENABLEBANKING_REDIRECT_URL"},
+		{name: "PSU IP address", modify: func(c *Config) { c.PSUIPAddress = "localhost" }, want: "ENABLEBANKING_PSU_IP_ADDRESS"},
+		{
+			name:   "to date before from date",
+			modify: func(c *Config) { c.ToDate = Date(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)) },
+			want:   "ENABLEBANKING_TO_DATE",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(&cfg)
+			err := cfg.Check()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Check() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Check() = %v, want error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package enablebanking
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,12 +10,14 @@ import (
 	"log/slog"
 	"net/http"
 	"net/netip"
+	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/kelseyhightower/envconfig"
 	"github.com/martinohansen/ynabber"
 	"github.com/martinohansen/ynabber/internal/log"
+	"github.com/martinohansen/ynabber/internal/metrics"
+	"github.com/martinohansen/ynabber/internal/status"
 )
 
 // ErrRateLimit is returned when the API responds with HTTP 429 Too Many Requests.
@@ -32,6 +35,11 @@ type apiErrorResponse struct {
 }
 
 const (
+	// maxWindowDays is the longest date range fetched in a single request.
+	// Longer ranges, like a backfill of a whole year, are split so banks that
+	// cap or paginate long ranges still return every transaction.
+	maxWindowDays = 90
+
 	// maxResponseBodyBytes caps how much of an EnableBanking response body we
 	// buffer. 10 MB is far above any realistic API payload.
 	maxResponseBodyBytes = 10 * 1024 * 1024
@@ -62,7 +70,8 @@ func NewClient(cfg Config, logger *slog.Logger) *Client {
 	return &Client{
 		BaseURL: enableBankingAPIBase,
 		HTTPClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: metrics.Transport("enablebanking", nil),
 		},
 		logger: logger,
 		config: cfg,
@@ -111,6 +120,56 @@ func (c *Client) GetAccountTransactions(ctx context.Context, jwtToken, accountUI
 	url := fmt.Sprintf("%s/accounts/%s/transactions?date_from=%s&date_to=%s",
 		c.BaseURL, accountUID, fromDate, toDate)
 
+	respBody, err := c.get(ctx, jwtToken, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions TransactionsResponse
+	if err := json.Unmarshal(respBody, &transactions); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	return &transactions, nil
+}
+
+// BalancesResponse represents the balances response from the API
+type BalancesResponse struct {
+	Balances []EBBalance `json:"balances"`
+}
+
+// EBBalance represents a balance from the EnableBanking API
+type EBBalance struct {
+	Name          string `json:"name"`
+	BalanceAmount struct {
+		Currency string `json:"currency"`
+		Amount   string `json:"amount"`
+	} `json:"balance_amount"`
+	BalanceType   string `json:"balance_type"`
+	ReferenceDate string `json:"reference_date"`
+}
+
+// GetAccountBalances fetches the balances of a specific account
+func (c *Client) GetAccountBalances(ctx context.Context, jwtToken, accountUID string) (*BalancesResponse, error) {
+	url := fmt.Sprintf("%s/accounts/%s/balances", c.BaseURL, accountUID)
+
+	respBody, err := c.get(ctx, jwtToken, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var balances BalancesResponse
+	if err := json.Unmarshal(respBody, &balances); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	return &balances, nil
+}
+
+// get sends an authenticated GET request to url and returns the response
+// body. Rate limits and expired sessions are reported as ErrRateLimit and
+// ErrUnauthorized.
+func (c *Client) get(ctx context.Context, jwtToken, url string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
@@ -151,13 +210,7 @@ func (c *Client) GetAccountTransactions(ctx context.Context, jwtToken, accountUI
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
 	}
-
-	var transactions TransactionsResponse
-	if err := json.Unmarshal(respBody, &transactions); err != nil {
-		return nil, fmt.Errorf("parsing response: %w", err)
-	}
-
-	return &transactions, nil
+	return respBody, nil
 }
 
 // Reader represents an EnableBanking reader instance
@@ -165,7 +218,11 @@ type Reader struct {
 	Config Config
 	Auth   Auth
 	Client *Client
-	logger *slog.Logger
+	// instance names the reader when several run side by side, empty for
+	// the default one.
+	instance string
+	logger   *slog.Logger
+	status   *status.Component
 	// bulkFn and afterFn let runner tests exercise the production loop without
 	// making network requests or waiting on wall-clock timers.
 	bulkFn  func(context.Context) ([]ynabber.Transaction, error)
@@ -175,21 +232,52 @@ type Reader struct {
 	retryDelay time.Duration
 }
 
+func init() {
+	ynabber.RegisterReader("enablebanking", func(opts ynabber.Options) (ynabber.Reader, error) {
+		reader, err := NewNamedReader(opts.Logger, opts.DataDir, opts.Instance)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Once {
+			reader.Config.Interval = 0
+		}
+		if !opts.Window.IsZero() {
+			reader.Config.FromDate = Date(opts.Window.From)
+			reader.Config.ToDate = Date(opts.Window.To)
+		}
+		return reader, nil
+	}, &Config{})
+}
+
 // NewReader returns a new EnableBanking reader
 func NewReader(logger *slog.Logger, dataDir string) (Reader, error) {
-	logger = logger.With("reader", "enablebanking")
+	return NewNamedReader(logger, dataDir, "")
+}
+
+// NewNamedReader returns a new EnableBanking reader for the named instance.
+// Its config is read from environment variables prefixed with the upper-case
+// instance name, e.g. DNB_ENABLEBANKING_ASPSP, falling back to the unprefixed
+// variables. Each named instance gets its own session file by default.
+func NewNamedReader(logger *slog.Logger, dataDir, instance string) (Reader, error) {
+	logger = logger.With("reader", ynabber.InstanceName("enablebanking", instance))
 
 	// Load and validate config
 	cfg := Config{}
-	if err := loadEnvConfig(&cfg); err != nil {
+	if err := loadEnvConfig(ynabber.EnvPrefix(instance), &cfg); err != nil {
 		return Reader{}, fmt.Errorf("loading config: %w", err)
 	}
 
+	if instance != "" && cfg.SessionFile == "" {
+		cfg.SessionFile = filepath.Join(dataDir, namedSessionFile(instance, cfg.ASPSP, cfg.Country))
+	}
 	if err := cfg.Validate(dataDir); err != nil {
 		return Reader{}, fmt.Errorf("validating config: %w", err)
 	}
 
 	logger.Debug("config loaded", "aspsp", cfg.ASPSP, "country", cfg.Country)
+	if cfg.PayeeStrip != nil || len(cfg.PayeeStripRegex) > 0 {
+		logger.Warn("ENABLEBANKING_PAYEE_STRIP and ENABLEBANKING_PAYEE_STRIP_REGEX are deprecated, use the strip transformer instead")
+	}
 
 	discoverIPAddress := func(ctx context.Context) (string, error) {
 		client := &http.Client{Timeout: publicIPAddressTimeout}
@@ -199,20 +287,53 @@ func NewReader(logger *slog.Logger, dataDir string) (Reader, error) {
 		return Reader{}, fmt.Errorf("resolving PSU IP address: %w", err)
 	}
 
+	name := ynabber.InstanceName("enablebanking", instance)
 	auth := NewAuth(cfg, logger)
+	auth.status = status.Register("reader", name)
 	client := NewClient(cfg, logger)
 
 	return Reader{
-		Config: cfg,
-		Auth:   auth,
-		Client: client,
-		logger: logger,
+		Config:   cfg,
+		Auth:     auth,
+		Client:   client,
+		instance: instance,
+		logger:   logger,
+		status:   auth.status,
 	}, nil
 }
 
 // String returns the reader name
 func (r Reader) String() string {
-	return "enablebanking"
+	return ynabber.InstanceName("enablebanking", r.instance)
+}
+
+// ReadsPending implements ynabber.PendingReader.
+func (r Reader) ReadsPending() bool {
+	return r.Config.Pending
+}
+
+// Accounts implements ynabber.AccountLister. It lists the accounts of the
+// saved session without contacting EnableBanking.
+func (r Reader) Accounts(context.Context) ([]ynabber.AccountDetails, error) {
+	session, err := r.Auth.savedSession()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]ynabber.AccountDetails, 0, len(session.Accounts))
+	for _, account := range session.Accounts {
+		accounts = append(accounts, ynabber.AccountDetails{
+			Account: ynabber.Account{
+				ID:   ynabber.ID(account.UID),
+				Name: account.DisplayName,
+				IBAN: account.StableID(),
+			},
+			Name:     cmp.Or(account.DisplayName, account.Name),
+			Type:     account.AccountType,
+			Currency: account.Currency,
+		})
+	}
+	return accounts, nil
 }
 
 // Bulk fetches all accounts and their transactions
@@ -265,14 +386,17 @@ func (r Reader) fetchSessionTransactions(ctx context.Context, session Session) (
 	log.Trace(r.logger, "session", "data", session)
 
 	r.logger.Info("loaded session", "accounts", len(session.Accounts))
+	if validUntil, err := time.Parse(time.RFC3339, session.ValidUntil); err == nil {
+		metrics.SessionValidUntil.Set(float64(validUntil.Unix()), r.String())
+	}
 
 	var results []ynabber.Transaction
-	fromDate := time.Time(r.Config.FromDate).Format(dateFormat)
-	toDateTime, err := r.Config.GetToDate()
+	skipped := 0
+	toDate, err := r.Config.GetToDate()
 	if err != nil {
 		return nil, fmt.Errorf("getting to date: %w", err)
 	}
-	toDate := toDateTime.Format(dateFormat)
+	windows := ynabber.Window{From: time.Time(r.Config.FromDate), To: toDate}.Split(maxWindowDays)
 
 	for i, account := range session.Accounts {
 		accountLogger := r.logger.With("account", account.UID, "stable_id_hint", maskIdentifier(account.StableID()))
@@ -286,25 +410,41 @@ func (r Reader) fetchSessionTransactions(ctx context.Context, session Session) (
 				"delete the session file and re-authorize to fix YNAB_ACCOUNTMAP matching")
 		}
 
-		txResp, err := r.Client.GetAccountTransactions(ctx, session.AuthToken, account.UID, fromDate, toDate)
-		if err != nil {
-			return nil, fmt.Errorf("fetching transactions for account %q: %w", maskIdentifier(account.StableID()), err)
-		}
+		for _, window := range windows {
+			fromDate, toDate := window.From.Format(dateFormat), window.To.Format(dateFormat)
+			txResp, err := r.Client.GetAccountTransactions(ctx, session.AuthToken, account.UID, fromDate, toDate)
+			if err != nil {
+				return nil, fmt.Errorf("fetching transactions for account %q: %w", maskIdentifier(account.StableID()), err)
+			}
 
-		log.Trace(accountLogger, "transactions", "data", txResp)
+			log.Trace(accountLogger, "transactions", "data", txResp)
 
-		accountLogger.Info("fetched transactions", "booked", len(txResp.Transactions), "pending", len(txResp.Pending))
+			accountLogger.Info("fetched transactions", "from", fromDate, "to", toDate, "booked", len(txResp.Transactions), "pending", len(txResp.Pending))
 
-		// Process booked transactions
-		for _, ebTx := range txResp.Transactions {
-			tx, err := r.Mapper(account, ebTx)
-			if err != nil {
-				accountLogger.Debug("skipping transaction", "error", err, "id", ebTx.TransactionID)
-				continue
+			// Process booked transactions, and pending ones when enabled.
+			// The mapper drops pending transactions otherwise.
+			transactions := txResp.Transactions
+			if r.Config.Pending {
+				for _, ebTx := range txResp.Pending {
+					if ebTx.Status == "" {
+						ebTx.Status = statusPending
+					}
+					transactions = append(transactions, ebTx)
+				}
 			}
-
-			if tx != nil {
-				results = append(results, *tx)
+			for _, ebTx := range transactions {
+				tx, err := r.Mapper(account, ebTx)
+				if err != nil {
+					accountLogger.Debug("skipping transaction", "error", err, "id", ebTx.TransactionID)
+					skipped++
+					continue
+				}
+
+				if tx != nil {
+					results = append(results, *tx)
+				} else {
+					skipped++
+				}
 			}
 		}
 
@@ -313,7 +453,8 @@ func (r Reader) fetchSessionTransactions(ctx context.Context, session Session) (
 		accountLogger.Debug("processed", "progress_pct", fmt.Sprintf("%.0f%%", rate))
 	}
 
-	r.logger.Info("read transactions", "total", len(results))
+	r.logger.Info("read transactions", "total", len(results), "skipped", skipped)
+	metrics.TransactionsSkipped.Add(float64(skipped), r.String())
 	return results, nil
 }
 
@@ -328,9 +469,11 @@ func maskIdentifier(id string) string {
 	return string(r[:4]) + "..." + string(r[len(r)-4:])
 }
 
-// loadEnvConfig loads config from environment variables using kelseyhightower/envconfig
-func loadEnvConfig(cfg *Config) error {
-	if err := envconfig.Process("", cfg); err != nil {
+// loadEnvConfig loads config from environment variables using
+// ynabber.ProcessEnv. Variables are looked up with prefix first, then without
+// it.
+func loadEnvConfig(prefix string, cfg *Config) error {
+	if err := ynabber.ProcessEnv(prefix, cfg); err != nil {
 		return fmt.Errorf("processing config: %w", err)
 	}
 	return nil
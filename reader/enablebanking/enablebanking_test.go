@@ -945,3 +945,55 @@ func TestBulkMapsStableIDFromSession(t *testing.T) {
 			txs[0].Account.IBAN, accountIBAN)
 	}
 }
+
+func TestReaderAccounts(t *testing.T) {
+	sessionFile := t.TempDir() + "/session.json"
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	reader := Reader{Auth: Auth{Config: Config{SessionFile: sessionFile}, logger: logger}}
+
+	if _, err := reader.Accounts(context.Background()); err == nil {
+		t.Fatal("Accounts() without a session should fail instead of authorizing")
+	}
+
+	session := Session{
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+		ValidUntil: time.Now().UTC().Add(time.Hour).Format(time.RFC3339),
+		Accounts: []AccountInfo{
+			{UID: "uid-1", AccountID: AccountID{IBAN: "NO8330001234567"}, DisplayName: "Brukskonto", AccountType: "CACC", Currency: "NOK"},
+			{UID: "uid-2", AccountID: AccountID{Other: AccountIDOther{Identification: "540111******9999"}}, Name: "Visa", AccountType: "CARD", Currency: "NOK"},
+		},
+	}
+	if err := reader.Auth.saveSession(session); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := reader.Accounts(context.Background())
+	if err != nil {
+		t.Fatalf("Accounts() failed: %v", err)
+	}
+	want := []ynabber.AccountDetails{
+		{
+			Account:  ynabber.Account{ID: "uid-1", Name: "Brukskonto", IBAN: "NO8330001234567"},
+			Name:     "Brukskonto",
+			Type:     "CACC",
+			Currency: "NOK",
+		},
+		{
+			Account:  ynabber.Account{ID: "uid-2", IBAN: "540111******9999"},
+			Name:     "Visa",
+			Type:     "CARD",
+			Currency: "NOK",
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Accounts() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Accounts()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+		if got[i].Key() != want[i].Account.IBAN {
+			t.Errorf("Key() = %q, want %q", got[i].Key(), want[i].Account.IBAN)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package enablebanking
 
 import (
+	"cmp"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -16,14 +17,26 @@ func (r Reader) Mapper(account AccountInfo, tx EBTransaction) (*ynabber.Transact
 	return r.defaultMapper(account, tx)
 }
 
-// statusBooked is the only transaction status imported into YNAB.
-// Transactions with any other non-empty status (e.g. "PDNG") are discarded
+// statusBooked and statusPending are the transaction statuses that are
+// imported. Pending transactions are only imported when Config.Pending is set,
 // because their IDs are unstable and their payee fields are often absent.
-const statusBooked = "BOOK"
+// Transactions with any other status are discarded.
+const (
+	statusBooked  = "BOOK"
+	statusPending = "PDNG"
+)
 
 // defaultMapper is the generic mapper for EnableBanking transactions
 func (r Reader) defaultMapper(account AccountInfo, tx EBTransaction) (*ynabber.Transaction, error) {
-	if tx.Status != "" && tx.Status != statusBooked {
+	status := ynabber.StatusBooked
+	switch tx.Status {
+	case "", statusBooked:
+	case statusPending:
+		if !r.Config.Pending {
+			return nil, nil
+		}
+		status = ynabber.StatusPending
+	default:
 		return nil, nil
 	}
 
@@ -75,14 +88,57 @@ func (r Reader) defaultMapper(account AccountInfo, tx EBTransaction) (*ynabber.T
 			Name: account.DisplayName,
 			IBAN: account.StableID(),
 		},
-		ID:     ynabber.ID(transactionID),
-		Date:   date,
-		Payee:  payee,
-		Memo:   memo,
-		Amount: amount,
+		ID:           ynabber.ID(transactionID),
+		Date:         date,
+		Payee:        payee,
+		Memo:         memo,
+		Amount:       amount,
+		Status:       status,
+		Currency:     tx.TransactionAmount.Currency,
+		ValueDate:    parseValueDate(tx),
+		Counterparty: counterparty(tx),
+		MCC:          stringFromInterface(tx.MerchantCategoryCode),
+		Reference:    cmp.Or(stringFromInterface(tx.EntryReference), stringFromInterface(tx.ReferenceNumber)),
 	}, nil
 }
 
+// parseValueDate returns the value date of tx, or the zero time if it is
+// missing or malformed. It is optional, so a bad one doesn't fail the
+// transaction.
+func parseValueDate(tx EBTransaction) time.Time {
+	if tx.ValueDate == "" {
+		return time.Time{}
+	}
+	date, err := parseDateFlexible(tx.ValueDate)
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
+
+// counterparty returns the creditor of debits and the debtor of credits.
+func counterparty(tx EBTransaction) ynabber.Counterparty {
+	party, account := tx.Debtor, tx.DebtorAccount
+	if tx.CreditDebitIndicator == "DBIT" {
+		party, account = tx.Creditor, tx.CreditorAccount
+	}
+	return ynabber.Counterparty{
+		Name: field(party, "name"),
+		IBAN: field(account, "iban"),
+	}
+}
+
+// field returns the string field called key of the JSON object value, or an
+// empty string if value is not an object or has no such field.
+func field(value interface{}, key string) string {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	s, _ := object[key].(string)
+	return strings.TrimSpace(s)
+}
+
 // resolveTransactionID returns a stable identifier for tx, trying candidates
 // in order of reliability:
 //
@@ -126,8 +182,10 @@ func resolveTransactionID(tx EBTransaction) string {
 //
 // Note: remittance_information is included in the hash. Some ASPSPs (e.g. DNB)
 // enrich this field asynchronously after booking, which changes the hash and
-// causes duplicates in YNAB. Use YNAB_DELAY=12h to avoid importing transactions
-// before their remittance information has stabilised.
+// causes duplicates in YNAB. Writers can only update enriched transactions in
+// place when their ID stays the same, so for these ASPSPs use YNAB_DELAY=12h
+// to avoid importing transactions before their remittance information has
+// stabilised.
 func syntheticTransactionID(tx EBTransaction) string {
 	parts := []string{
 		tx.BookingDate,
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/martinohansen/ynabber"
 )
 
 // TestMapper tests that the Mapper method produces a valid transaction
@@ -632,10 +634,12 @@ func TestPayeeStripAndTruncate(t *testing.T) {
 // "other status skipped" sub-tests are therefore expected to FAIL (Red state).
 func TestDefaultMapperStatusFilter(t *testing.T) {
 	tests := []struct {
-		name    string
-		status  string
-		wantNil bool // true → expect (nil, nil)
-		wantErr bool
+		name       string
+		status     string
+		pending    bool // ENABLEBANKING_PENDING
+		wantNil    bool // true → expect (nil, nil)
+		wantErr    bool
+		wantStatus ynabber.Status
 	}{
 		{
 			name:    "BOOK status mapped",
@@ -655,6 +659,14 @@ func TestDefaultMapperStatusFilter(t *testing.T) {
 			wantNil: true,
 			wantErr: false,
 		},
+		{
+			name:       "PDNG status mapped when pending is enabled",
+			status:     "PDNG",
+			pending:    true,
+			wantNil:    false,
+			wantErr:    false,
+			wantStatus: ynabber.StatusPending,
+		},
 		{
 			name:    "other status skipped",
 			status:  "OTHR",
@@ -663,10 +675,6 @@ func TestDefaultMapperStatusFilter(t *testing.T) {
 		},
 	}
 
-	reader := Reader{
-		logger: slog.New(slog.NewTextHandler(os.Stderr, nil)),
-	}
-
 	account := AccountInfo{
 		UID:         "acc-status-test",
 		AccountID:   AccountID{IBAN: randomTestIBAN(t)},
@@ -675,6 +683,10 @@ func TestDefaultMapperStatusFilter(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			reader := Reader{
+				Config: Config{Pending: tt.pending},
+				logger: slog.New(slog.NewTextHandler(os.Stderr, nil)),
+			}
 			tx := EBTransaction{
 				TransactionID:        "tx-status-" + tt.status,
 				BookingDate:          "2024-06-01",
@@ -702,6 +714,72 @@ func TestDefaultMapperStatusFilter(t *testing.T) {
 			if !tt.wantNil && result == nil {
 				t.Errorf("defaultMapper() = nil, want non-nil transaction (status %q should be mapped)", tt.status)
 			}
+
+			if tt.wantStatus != "" && result != nil && result.Status != tt.wantStatus {
+				t.Errorf("defaultMapper() status = %q, want %q", result.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDefaultMapperCounterparty(t *testing.T) {
+	tests := []struct {
+		name      string
+		indicator string
+		want      ynabber.Counterparty
+	}{
+		{
+			name:      "debit is paid to the creditor",
+			indicator: "DBIT",
+			want:      ynabber.Counterparty{Name: "Example Grocer", IBAN: "NO9386011117947"},
+		},
+		{
+			name:      "credit is received from the debtor",
+			indicator: "CRDT",
+			want:      ynabber.Counterparty{Name: "Example Employer", IBAN: "NO8330001234567"},
+		},
+	}
+
+	reader := Reader{
+		logger: slog.New(slog.NewTextHandler(os.Stderr, nil)),
+	}
+	account := AccountInfo{
+		UID:       "acc-counterparty-test",
+		AccountID: AccountID{IBAN: randomTestIBAN(t)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := EBTransaction{
+				EntryReference:       "entry-1",
+				BookingDate:          "2024-06-01",
+				ValueDate:            "2024-06-03",
+				CreditDebitIndicator: tt.indicator,
+				Status:               "BOOK",
+				MerchantCategoryCode: "5411",
+				TransactionAmount: struct {
+					Currency string `json:"currency"`
+					Amount   string `json:"amount"`
+				}{Currency: "NOK", Amount: "42.00"},
+				Creditor:        map[string]interface{}{"name": "Example Grocer"},
+				CreditorAccount: map[string]interface{}{"iban": "NO9386011117947"},
+				Debtor:          map[string]interface{}{"name": "Example Employer"},
+				DebtorAccount:   map[string]interface{}{"iban": "NO8330001234567"},
+			}
+
+			got, err := reader.defaultMapper(account, tx)
+			if err != nil {
+				t.Fatalf("defaultMapper() error = %v", err)
+			}
+			if got.Counterparty != tt.want {
+				t.Errorf("Counterparty = %+v, want %+v", got.Counterparty, tt.want)
+			}
+			if got.Status != ynabber.StatusBooked || got.Currency != "NOK" || got.MCC != "5411" || got.Reference != "entry-1" {
+				t.Errorf("got Status=%q Currency=%q MCC=%q Reference=%q", got.Status, got.Currency, got.MCC, got.Reference)
+			}
+			if want := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC); !got.ValueDate.Equal(want) {
+				t.Errorf("ValueDate = %s, want %s", got.ValueDate, want)
+			}
 		})
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/internal/status"
 )
 
 // retryBaseDelay is the backoff duration for transient (non-rate-limit) errors
@@ -85,6 +86,7 @@ func (r Reader) retryHandler(ctx context.Context, err error) error {
 		} else {
 			r.logger.Warn("transient error, backing off before retry", "error", err, "delay", delay)
 		}
+		r.status.BackOff(time.Now().Add(delay), err)
 		select {
 		case <-r.after(delay):
 			return nil
@@ -98,6 +100,7 @@ func (r Reader) retryHandler(ctx context.Context, err error) error {
 		r.logger.Warn("rate limited by API; daily quota likely exhausted — will retry at next processing window",
 			"retry_at", retryAt.Format(time.RFC3339),
 			"wait", time.Until(retryAt).Round(time.Second))
+		r.status.BackOff(retryAt, err)
 		select {
 		case <-r.after(time.Until(retryAt)):
 			return nil
@@ -107,6 +110,7 @@ func (r Reader) retryHandler(ctx context.Context, err error) error {
 	}
 
 	r.logger.Warn("transient error, backing off before retry", "error", err, "delay", retryBaseDelay)
+	r.status.BackOff(time.Now().Add(retryBaseDelay), err)
 	select {
 	case <-r.after(retryBaseDelay):
 		return nil
@@ -131,6 +135,7 @@ func (r Reader) Runner(ctx context.Context, out chan<- []ynabber.Transaction) er
 		default:
 		}
 
+		r.status.Set(status.Fetching)
 		batch, err := bulk(ctx)
 		if err != nil {
 			r.logger.Error("bulk reading transactions", "error", err)
@@ -150,12 +155,14 @@ func (r Reader) Runner(ctx context.Context, out chan<- []ynabber.Transaction) er
 
 		if r.Config.Interval > 0 {
 			r.logger.Info("waiting for next run", "in", r.Config.Interval)
+			r.status.Wait(time.Now().Add(r.Config.Interval))
 			select {
 			case <-r.after(r.Config.Interval):
 			case <-ctx.Done():
 				return ctx.Err()
 			}
 		} else {
+			r.status.Set(status.Idle)
 			return nil
 		}
 	}
@@ -7,8 +7,8 @@ import (
 	"math/rand"
 	"time"
 
-	"github.com/kelseyhightower/envconfig"
 	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/internal/status"
 )
 
 // Config for the generator reader
@@ -20,28 +20,51 @@ type Config struct {
 	BatchSize int `envconfig:"YNABBER_GENERATOR_BULK_SIZE" default:"2"`
 }
 
+func init() {
+	ynabber.RegisterReader("generator", func(opts ynabber.Options) (ynabber.Reader, error) {
+		reader, err := NewNamedReader(opts.Instance)
+		if err != nil {
+			return nil, err
+		}
+		reader.once = opts.Once
+		return reader, nil
+	}, &Config{})
+}
+
 // NewReader creates a new generator
 func NewReader() (*Reader, error) {
+	return NewNamedReader("")
+}
+
+// NewNamedReader creates a new generator for the named instance, reading its
+// config from variables prefixed with the upper-case instance name.
+func NewNamedReader(instance string) (*Reader, error) {
 	var cfg Config
-	err := envconfig.Process("", &cfg)
+	err := ynabber.ProcessEnv(ynabber.EnvPrefix(instance), &cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Reader{
-		Config: cfg,
-		logger: slog.Default().With("reader", "generator"),
+		Config:   cfg,
+		instance: instance,
+		logger:   slog.Default().With("reader", ynabber.InstanceName("generator", instance)),
+		status:   status.Register("reader", ynabber.InstanceName("generator", instance)),
 	}, nil
 }
 
 // Reader generates random transactions for testing purposes
 type Reader struct {
-	Config Config
-	logger *slog.Logger
+	Config   Config
+	instance string
+	logger   *slog.Logger
+	status   *status.Component
+	// once stops the runner after the first batch.
+	once bool
 }
 
 func (r Reader) String() string {
-	return "generator"
+	return ynabber.InstanceName("generator", r.instance)
 }
 
 // Bulk generates transactions
@@ -99,6 +122,7 @@ func (r Reader) Runner(ctx context.Context, out chan<- []ynabber.Transaction) er
 		default:
 		}
 
+		r.status.Set(status.Fetching)
 		batch, err := r.Bulk()
 		if err != nil {
 			r.logger.Error("generating transaction", "error", err)
@@ -113,7 +137,13 @@ func (r Reader) Runner(ctx context.Context, out chan<- []ynabber.Transaction) er
 			return ctx.Err()
 		}
 
+		if r.once {
+			r.status.Set(status.Idle)
+			return nil
+		}
+
 		r.logger.Info("waiting for next run", "in", r.Config.Interval)
+		r.status.Wait(time.Now().Add(r.Config.Interval))
 
 		select {
 		case <-time.After(r.Config.Interval):
@@ -11,16 +11,22 @@ import (
 	"time"
 
 	"github.com/frieser/nordigen-go-lib/v2"
+	"github.com/martinohansen/ynabber/internal/atomicfile"
+	"github.com/martinohansen/ynabber/internal/status"
 )
 
 const RequisitionRedirect = "https://martinohansen.github.io/ynabber/ok.html"
 
 // requisitionStore returns a clean path to the requisition file
 func (r Reader) requisitionStore() string {
-	// Use BankID or RequisitionFile as filename
+	// Use BankID or RequisitionFile as filename. Named instances prefix the
+	// BankID so two instances for the same bank keep separate requisitions.
 	var file string
 	if r.Config.RequisitionFile == "" {
 		file = r.Config.BankID
+		if r.instance != "" {
+			file = r.instance + "_" + file
+		}
 	} else {
 		file = r.Config.RequisitionFile
 	}
@@ -61,13 +67,33 @@ func (r Reader) Requisition() (nordigen.Requisition, error) {
 	}
 }
 
+// savedRequisition returns the requisition saved on disk without creating a
+// new one. It fails if there is no linked requisition.
+func (r Reader) savedRequisition() (nordigen.Requisition, error) {
+	requisitionFile, err := os.ReadFile(r.requisitionStore())
+	if errors.Is(err, os.ErrNotExist) {
+		return nordigen.Requisition{}, fmt.Errorf("no requisition in %s, run ynabber to authorize access first", r.requisitionStore())
+	} else if err != nil {
+		return nordigen.Requisition{}, fmt.Errorf("ReadFile: %w", err)
+	}
+
+	var requisition nordigen.Requisition
+	if err := json.Unmarshal(requisitionFile, &requisition); err != nil {
+		return nordigen.Requisition{}, fmt.Errorf("parsing requisition file: %w", err)
+	}
+	if requisition.Status != "LN" {
+		return nordigen.Requisition{}, fmt.Errorf("requisition in %s has status %q, run ynabber to authorize access again", r.requisitionStore(), requisition.Status)
+	}
+	return requisition, nil
+}
+
 func (r Reader) saveRequisition(requisition nordigen.Requisition) error {
 	requisitionFile, err := json.Marshal(requisition)
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(r.requisitionStore(), requisitionFile, 0644)
+	err = atomicfile.WriteFile(r.requisitionStore(), requisitionFile, 0644)
 	if err != nil {
 		return err
 	}
@@ -75,12 +101,14 @@ func (r Reader) saveRequisition(requisition nordigen.Requisition) error {
 }
 
 func (r Reader) createRequisition() (nordigen.Requisition, error) {
+	start := time.Now()
 	requisition, err := r.Client.CreateRequisition(nordigen.Requisition{
 		Redirect:      RequisitionRedirect,
 		Reference:     strconv.Itoa(int(time.Now().Unix())),
 		Agreement:     "",
 		InstitutionId: r.Config.BankID,
 	})
+	observe(start, err)
 	if err != nil {
 		return nordigen.Requisition{}, fmt.Errorf("CreateRequisition: %w", err)
 	}
@@ -91,8 +119,12 @@ func (r Reader) createRequisition() (nordigen.Requisition, error) {
 	r.logger.Info("initiate requisition by going to", "link", requisition.Link)
 
 	// Keep waiting for the user to accept the requisition
+	r.status.Set(status.WaitingForAuth)
+	defer r.status.Set(status.Fetching)
 	for requisition.Status != "LN" {
+		start = time.Now()
 		requisition, err = r.Client.GetRequisition(requisition.Id)
+		observe(start, err)
 		if err != nil {
 			return nordigen.Requisition{}, fmt.Errorf("GetRequisition: %w", err)
 		}
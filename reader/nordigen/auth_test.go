@@ -16,4 +16,11 @@ func TestStore(t *testing.T) {
 	if want != got {
 		t.Fatalf("default: %s != %s", want, got)
 	}
+
+	r.instance = "dnb"
+	want = "dnb_foo.json"
+	got = r.requisitionStore()
+	if want != got {
+		t.Fatalf("named instance: %s != %s", want, got)
+	}
 }
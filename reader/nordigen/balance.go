@@ -0,0 +1,68 @@
+package nordigen
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/frieser/nordigen-go-lib/v2"
+	"github.com/martinohansen/ynabber"
+)
+
+// bookedBalanceTypes are the balance types holding booked transactions only,
+// most current first.
+var bookedBalanceTypes = []string{"interimBooked", "closingBooked"}
+
+// Balances implements ynabber.BalanceReader. It fetches the booked balance of
+// every account of the saved requisition.
+func (r Reader) Balances(context.Context) ([]ynabber.Balance, error) {
+	req, err := r.savedRequisition()
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]ynabber.Balance, 0, len(req.Accounts))
+	for _, id := range req.Accounts {
+		start := time.Now()
+		metadata, err := r.Client.GetAccountMetadata(id)
+		observe(start, err)
+		if err != nil {
+			return nil, fmt.Errorf("getting account metadata: %w", err)
+		}
+		start = time.Now()
+		response, err := r.Client.GetAccountBalances(id)
+		observe(start, err)
+		if err != nil {
+			return nil, fmt.Errorf("getting account balances: %w", err)
+		}
+
+		balance, err := bookedBalance(response.Balances)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %w", metadata.Iban, err)
+		}
+		balance.Account = ynabber.Account{
+			ID:   ynabber.ID(metadata.Id),
+			Name: metadata.Iban,
+			IBAN: metadata.Iban,
+		}
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+
+// bookedBalance returns the most current booked balance in balances.
+func bookedBalance(balances []nordigen.AccountBalance) (ynabber.Balance, error) {
+	for _, balanceType := range bookedBalanceTypes {
+		for _, b := range balances {
+			if b.BalanceType != balanceType {
+				continue
+			}
+			amount, err := ynabber.MilliunitsFromString(b.BalanceAmount.Amount)
+			if err != nil {
+				return ynabber.Balance{}, fmt.Errorf("parsing %s balance: %w", balanceType, err)
+			}
+			return ynabber.Balance{Amount: amount, Currency: b.BalanceAmount.Currency}, nil
+		}
+	}
+	return ynabber.Balance{}, fmt.Errorf("no booked balance among %d balance(s)", len(balances))
+}
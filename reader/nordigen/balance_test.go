@@ -0,0 +1,50 @@
+package nordigen
+
+import (
+	"testing"
+
+	"github.com/frieser/nordigen-go-lib/v2"
+	"github.com/martinohansen/ynabber"
+)
+
+func TestBookedBalance(t *testing.T) {
+	balance := func(balanceType, amount string) nordigen.AccountBalance {
+		return nordigen.AccountBalance{
+			BalanceType:   balanceType,
+			BalanceAmount: nordigen.AccountBalanceAmount{Amount: amount, Currency: "NOK"},
+		}
+	}
+	tests := []struct {
+		name     string
+		balances []nordigen.AccountBalance
+		want     ynabber.Milliunits
+		wantErr  bool
+	}{
+		{
+			name:     "interim booked preferred",
+			balances: []nordigen.AccountBalance{balance("closingBooked", "100.00"), balance("expected", "90.00"), balance("interimBooked", "120.50")},
+			want:     120500,
+		},
+		{
+			name:     "closing booked",
+			balances: []nordigen.AccountBalance{balance("expected", "90.00"), balance("closingBooked", "-100.00")},
+			want:     -100000,
+		},
+		{
+			name:     "no booked balance",
+			balances: []nordigen.AccountBalance{balance("expected", "90.00")},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bookedBalance(tt.balances)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bookedBalance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Amount != tt.want {
+				t.Errorf("bookedBalance() = %d, want %d", got.Amount, tt.want)
+			}
+		})
+	}
+}
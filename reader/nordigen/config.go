@@ -4,8 +4,11 @@
 package nordigen
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"os/exec"
+	"slices"
 	"strings"
 	"time"
 )
@@ -77,10 +80,10 @@ type Config struct {
 	BankID string `envconfig:"NORDIGEN_BANKID"`
 
 	// SecretID is the client ID for API authentication
-	SecretID string `envconfig:"NORDIGEN_SECRET_ID"`
+	SecretID string `envconfig:"NORDIGEN_SECRET_ID" secret:"true"`
 
 	// SecretKey is the client secret for API authentication
-	SecretKey string `envconfig:"NORDIGEN_SECRET_KEY"`
+	SecretKey string `envconfig:"NORDIGEN_SECRET_KEY" secret:"true"`
 
 	// PayeeSource defines the sources and order for extracting payee
 	// information. Multiple sources can be combined with "+" to merge their
@@ -98,6 +101,9 @@ type Config struct {
 
 	// PayeeStrip contains words to remove from payee names.
 	// Example: "foo,bar" removes "foo" and "bar" from all payee names.
+	//
+	// Deprecated: add strip to YNABBER_TRANSFORMERS and set STRIP_PAYEE
+	// instead, which cleans up the payees of every reader.
 	PayeeStrip []string `envconfig:"NORDIGEN_PAYEE_STRIP"`
 
 	// PayeeStripRegex is a comma-separated list of regular expressions whose
@@ -106,6 +112,9 @@ type Config struct {
 	// literal comma.
 	// Example: "^Dk-Nota\S+\s+" turns "Dk-Nota61221 Remouladen" into
 	// "Remouladen".
+	//
+	// Deprecated: add strip to YNABBER_TRANSFORMERS and set
+	// STRIP_PAYEE_REGEX instead, which takes the same patterns.
 	PayeeStripRegex PayeeRegex `envconfig:"NORDIGEN_PAYEE_STRIP_REGEX"`
 
 	// TransactionID specifies which field to use as the unique transaction
@@ -125,11 +134,45 @@ type Config struct {
 	// The file is stored in the directory defined by YNABBER_DATADIR.
 	RequisitionFile string `envconfig:"NORDIGEN_REQUISITION_FILE"`
 
+	// Pending also reads pending transactions, like card purchases that are
+	// not booked yet. Writers that support it import them as uncleared and
+	// update them once they are booked. Others skip them.
+	Pending bool `envconfig:"NORDIGEN_PENDING" default:"false"`
+
 	// Interval determines how often to fetch new transactions.
 	// Set to 0 to run only once instead of continuously.
 	Interval time.Duration `envconfig:"NORDIGEN_INTERVAL" default:"6h"`
 }
 
+// transactionIDs are the valid values of Config.TransactionID.
+var transactionIDs = []string{"TransactionId", "InternalTransactionId", "ProprietaryBankTransactionCode"}
+
+// Check implements ynabber.Checker without contacting Nordigen.
+func (c *Config) Check() error {
+	var errs []error
+	if c.BankID == "" {
+		errs = append(errs, errors.New("NORDIGEN_BANKID: missing, find your bank's ID in the Nordigen institution list"))
+	}
+	if c.SecretID == "" {
+		errs = append(errs, errors.New("NORDIGEN_SECRET_ID: missing"))
+	}
+	if c.SecretKey == "" {
+		errs = append(errs, errors.New("NORDIGEN_SECRET_KEY: missing"))
+	}
+	if !slices.Contains(transactionIDs, c.TransactionID) {
+		errs = append(errs, fmt.Errorf("NORDIGEN_TRANSACTION_ID: unknown value %q, must be one of %s", c.TransactionID, strings.Join(transactionIDs, ", ")))
+	}
+	if c.RequisitionHook != "" {
+		if _, err := exec.LookPath(c.RequisitionHook); err != nil {
+			errs = append(errs, fmt.Errorf("NORDIGEN_REQUISITION_HOOK: %w", err))
+		}
+	}
+	if c.Interval < 0 {
+		errs = append(errs, errors.New("NORDIGEN_INTERVAL: must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
 // LogValue returns config with sensitive information redacted
 func (c *Config) LogValue() slog.Value {
 	return slog.GroupValue(
@@ -0,0 +1,44 @@
+package nordigen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigCheck(t *testing.T) {
+	valid := func() Config {
+		return Config{
+			BankID:        "DNB_DNBANOKK",
+			SecretID:      "id",
+			SecretKey:     "key",
+			TransactionID: "TransactionId",
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{name: "valid", modify: func(*Config) {}},
+		{name: "internal transaction id", modify: func(c *Config) { c.TransactionID = "InternalTransactionId" }},
+		{name: "unknown transaction id", modify: func(c *Config) { c.TransactionID = "transactionId" }, want: "NORDIGEN_TRANSACTION_ID"},
+		{name: "missing secret", modify: func(c *Config) { c.SecretKey = "" }, want: "NORDIGEN_SECRET_KEY"},
+		{name: "missing hook", modify: func(c *Config) { c.RequisitionHook = "/does/not/exist" }, want: "NORDIGEN_REQUISITION_HOOK"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(&cfg)
+			err := cfg.Check()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Check() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Check() = %v, want error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
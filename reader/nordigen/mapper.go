@@ -29,8 +29,14 @@ func (r Reader) Mapper(a ynabber.Account, t nordigen.Transaction) (*ynabber.Tran
 	}
 }
 
+// parseDate parses the booking date of t. Pending transactions often have no
+// booking date yet, so the value date is used for them instead.
 func parseDate(t nordigen.Transaction) (time.Time, error) {
-	date, err := time.Parse(dateFormat, t.BookingDate)
+	value := t.BookingDate
+	if value == "" {
+		value = t.ValueDate
+	}
+	date, err := time.Parse(dateFormat, value)
 	if err != nil {
 		return time.Time{}, fmt.Errorf("failed to parse string to time: %w", err)
 	}
@@ -79,11 +85,36 @@ func (r Reader) defaultMapper(a ynabber.Account, t nordigen.Transaction) (*ynabb
 		memo = trimmed
 	}
 	return &ynabber.Transaction{
-		Account: a,
-		ID:      ynabber.ID(id),
-		Date:    date,
-		Payee:   payee.value,
-		Memo:    memo,
-		Amount:  amount,
+		Account:      a,
+		ID:           ynabber.ID(id),
+		Date:         date,
+		Payee:        payee.value,
+		Memo:         memo,
+		Amount:       amount,
+		Status:       ynabber.StatusBooked,
+		Currency:     t.TransactionAmount.Currency,
+		ValueDate:    parseValueDate(t),
+		Counterparty: counterparty(t, amount),
+		Reference:    t.EntryReference,
 	}, nil
 }
+
+// parseValueDate returns the value date of t, or the zero time if it is
+// missing or malformed. It is optional, so a bad one doesn't fail the
+// transaction.
+func parseValueDate(t nordigen.Transaction) time.Time {
+	date, err := time.Parse(dateFormat, t.ValueDate)
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
+
+// counterparty returns the creditor of outgoing transactions and the debtor of
+// incoming ones.
+func counterparty(t nordigen.Transaction, amount ynabber.Milliunits) ynabber.Counterparty {
+	if amount < 0 {
+		return ynabber.Counterparty{Name: t.CreditorName, IBAN: t.CreditorAccount.Iban}
+	}
+	return ynabber.Counterparty{Name: t.DebtorName, IBAN: t.DebtorAccount.Iban}
+}
@@ -23,6 +23,12 @@ func TestParseDate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			transaction: nordigen.Transaction{
+				ValueDate: "2024-01-15",
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
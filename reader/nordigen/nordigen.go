@@ -1,43 +1,90 @@
 package nordigen
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"slices"
 	"time"
 
 	"github.com/frieser/nordigen-go-lib/v2"
-	"github.com/kelseyhightower/envconfig"
 	"github.com/martinohansen/ynabber"
 	"github.com/martinohansen/ynabber/internal/log"
+	"github.com/martinohansen/ynabber/internal/metrics"
+	"github.com/martinohansen/ynabber/internal/status"
 )
 
 type Reader struct {
 	Config Config
 	Client *nordigen.Client
-	logger *slog.Logger
+	// instance names the reader when several run side by side, empty for
+	// the default one.
+	instance string
+	logger   *slog.Logger
+	status   *status.Component
 	// bulkFn and afterFn let runner tests exercise the production loop without
 	// making network requests or waiting on wall-clock timers.
 	bulkFn  func() ([]ynabber.Transaction, error)
 	afterFn func(time.Duration) <-chan time.Time
 
+	// window limits the transactions read to a date range when set.
+	window ynabber.Window
+
 	// TODO(Martin): Move into Nordigen config struct
 	DataDir string
 }
 
+func init() {
+	ynabber.RegisterReader("nordigen", func(opts ynabber.Options) (ynabber.Reader, error) {
+		reader, err := NewNamedReader(opts.DataDir, opts.Instance)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Once {
+			reader.Config.Interval = 0
+		}
+		if !opts.Window.IsZero() {
+			// Nordigen returns the history the bank provides, usually 90
+			// days, so the window can only narrow it down.
+			reader.logger.Warn("Nordigen can't fetch a date range, only transactions within the bank's history are read", "from", opts.Window.From, "to", opts.Window.To)
+			reader.window = opts.Window
+		}
+		return reader, nil
+	}, &Config{})
+}
+
 func (r Reader) String() string {
-	return "nordigen"
+	return ynabber.InstanceName("nordigen", r.instance)
+}
+
+// ReadsPending implements ynabber.PendingReader.
+func (r Reader) ReadsPending() bool {
+	return r.Config.Pending
 }
 
 // NewReader returns a new nordigen reader or panics
 func NewReader(dataDir string) (Reader, error) {
-	logger := slog.Default().With("reader", "nordigen")
+	return NewNamedReader(dataDir, "")
+}
+
+// NewNamedReader returns a new nordigen reader for the named instance. Its
+// config is read from environment variables prefixed with the upper-case
+// instance name, e.g. DNB_NORDIGEN_BANKID, falling back to the unprefixed
+// variables.
+func NewNamedReader(dataDir, instance string) (Reader, error) {
+	logger := slog.Default().With("reader", ynabber.InstanceName("nordigen", instance))
 
 	cfg := Config{}
-	err := envconfig.Process("", &cfg)
+	err := ynabber.ProcessEnv(ynabber.EnvPrefix(instance), &cfg)
 	if err != nil {
 		return Reader{}, fmt.Errorf("processing config: %w", err)
 	}
 	logger.Debug("config loaded", "config", &cfg)
+	if cfg.PayeeStrip != nil || len(cfg.PayeeStripRegex) > 0 {
+		logger.Warn("NORDIGEN_PAYEE_STRIP and NORDIGEN_PAYEE_STRIP_REGEX are deprecated, use the strip transformer instead")
+	}
 
 	client, err := nordigen.NewClient(cfg.SecretID, cfg.SecretKey)
 	if err != nil {
@@ -45,10 +92,12 @@ func NewReader(dataDir string) (Reader, error) {
 	}
 
 	return Reader{
-		Client:  client,
-		Config:  cfg,
-		DataDir: dataDir,
-		logger:  logger,
+		Client:   client,
+		Config:   cfg,
+		DataDir:  dataDir,
+		instance: instance,
+		logger:   logger,
+		status:   status.Register("reader", ynabber.InstanceName("nordigen", instance)),
 	}, nil
 }
 
@@ -57,11 +106,22 @@ func (r Reader) toYnabbers(a ynabber.Account, t nordigen.AccountTransactions) ([
 
 	skipped := 0
 	y := []ynabber.Transaction{}
-	for _, v := range t.Transactions.Booked {
+	transactions := t.Transactions.Booked
+	if r.Config.Pending {
+		transactions = append(slices.Clip(transactions), t.Transactions.Pending...)
+	}
+	for i, v := range transactions {
 		transaction, err := r.Mapper(a, v)
 		if err != nil {
 			return nil, err
 		}
+		if transaction != nil && i >= len(t.Transactions.Booked) {
+			transaction.Status = ynabber.StatusPending
+		}
+
+		if transaction != nil && !r.window.IsZero() && !r.window.Contains(transaction.Date) {
+			transaction = nil
+		}
 
 		// Append transaction
 		if transaction != nil {
@@ -74,6 +134,7 @@ func (r Reader) toYnabbers(a ynabber.Account, t nordigen.AccountTransactions) ([
 
 	}
 	logger.Info("read transactions", "total", len(y)+skipped, "skipped", skipped)
+	metrics.TransactionsSkipped.Add(float64(skipped), r.String())
 	return y, nil
 }
 
@@ -85,7 +146,9 @@ func (r Reader) Bulk() (t []ynabber.Transaction, err error) {
 
 	r.logger.Info("loaded requisition", "accounts", len(req.Accounts))
 	for _, account := range req.Accounts {
+		start := time.Now()
 		accountMetadata, err := r.Client.GetAccountMetadata(account)
+		observe(start, err)
 		if err != nil {
 			return nil, fmt.Errorf("getting account metadata: %w", err)
 		}
@@ -105,7 +168,9 @@ func (r Reader) Bulk() (t []ynabber.Transaction, err error) {
 			IBAN: accountMetadata.Iban,
 		}
 
+		start = time.Now()
 		transactions, err := r.Client.GetAccountTransactions(string(account.ID))
+		observe(start, err)
 		log.Trace(r.logger, "account transactions", "account", account, "transactions", transactions)
 		if err != nil {
 			return t, fmt.Errorf("getting transactions: %w", err)
@@ -119,3 +184,58 @@ func (r Reader) Bulk() (t []ynabber.Transaction, err error) {
 	}
 	return t, nil
 }
+
+// Accounts implements ynabber.AccountLister. It lists the accounts of the
+// saved requisition.
+func (r Reader) Accounts(context.Context) ([]ynabber.AccountDetails, error) {
+	req, err := r.savedRequisition()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]ynabber.AccountDetails, 0, len(req.Accounts))
+	for _, id := range req.Accounts {
+		start := time.Now()
+		metadata, err := r.Client.GetAccountMetadata(id)
+		observe(start, err)
+		if err != nil {
+			return nil, fmt.Errorf("getting account metadata: %w", err)
+		}
+		start = time.Now()
+		details, err := r.Client.GetAccountDetails(id)
+		observe(start, err)
+		if err != nil {
+			return nil, fmt.Errorf("getting account details: %w", err)
+		}
+
+		accounts = append(accounts, ynabber.AccountDetails{
+			Account: ynabber.Account{
+				ID:   ynabber.ID(metadata.Id),
+				Name: metadata.Iban,
+				IBAN: metadata.Iban,
+			},
+			// The client library does not expose the cash account type
+			Name:     details.Account.Product,
+			Currency: details.Account.Currency,
+		})
+	}
+	return accounts, nil
+}
+
+// observe records a Nordigen API request started at start that returned err.
+// The client library does not expose its HTTP client, so requests are
+// measured around each call instead of in a transport.
+func observe(start time.Time, err error) {
+	code := http.StatusOK
+	var rl *nordigen.RateLimitError
+	var apiErr *nordigen.APIError
+	switch {
+	case errors.As(err, &rl):
+		code = http.StatusTooManyRequests
+	case errors.As(err, &apiErr):
+		code = apiErr.StatusCode
+	case err != nil:
+		code = 0
+	}
+	metrics.ObserveRequest("nordigen", code, time.Since(start))
+}
@@ -70,12 +70,15 @@ func TestToYnabber(t *testing.T) {
 				}),
 			},
 			want: []ynabber.Transaction{{
-				Account: ynabber.Account{Name: "foo", IBAN: "bar"},
-				ID:      ynabber.ID("foobar"),
-				Date:    time.Date(2025, time.May, 15, 0, 0, 0, 0, time.UTC),
-				Payee:   "SCOR",
-				Memo:    "5345 SCOR",
-				Amount:  ynabber.Milliunits(-469640)},
+				Account:      ynabber.Account{Name: "foo", IBAN: "bar"},
+				ID:           ynabber.ID("foobar"),
+				Date:         time.Date(2025, time.May, 15, 0, 0, 0, 0, time.UTC),
+				Payee:        "SCOR",
+				Memo:         "5345 SCOR",
+				Amount:       ynabber.Milliunits(-469640),
+				Status:       ynabber.StatusBooked,
+				Currency:     "NOK",
+				Counterparty: ynabber.Counterparty{Name: "Tibber Norge AS"}},
 			},
 			wantErr: false,
 		},
@@ -112,12 +115,15 @@ func TestToYnabber(t *testing.T) {
 				),
 			},
 			want: []ynabber.Transaction{{
-				Account: ynabber.Account{Name: "foo", IBAN: "bar"},
-				ID:      ynabber.ID("H00000000000000000000"),
-				Date:    time.Date(2023, time.February, 24, 0, 0, 0, 0, time.UTC),
-				Payee:   "Visa køb DKK HELLOFRESH Copenha Den",
-				Memo:    "Visa køb DKK 424,00 HELLOFRESH Copenha Den 23.02",
-				Amount:  ynabber.Milliunits(10000)},
+				Account:   ynabber.Account{Name: "foo", IBAN: "bar"},
+				ID:        ynabber.ID("H00000000000000000000"),
+				Date:      time.Date(2023, time.February, 24, 0, 0, 0, 0, time.UTC),
+				Payee:     "Visa køb DKK HELLOFRESH Copenha Den",
+				Memo:      "Visa køb DKK 424,00 HELLOFRESH Copenha Den 23.02",
+				Amount:    ynabber.Milliunits(10000),
+				Status:    ynabber.StatusBooked,
+				Currency:  "DKK",
+				ValueDate: time.Date(2023, time.February, 24, 0, 0, 0, 0, time.UTC)},
 			},
 			wantErr: false,
 		},
@@ -166,12 +172,15 @@ func TestToYnabber(t *testing.T) {
 				}),
 			},
 			want: []ynabber.Transaction{{
-				Account: ynabber.Account{Name: "foo", IBAN: "bar"},
-				ID:      ynabber.ID("CBP-209886344408903.130001"),
-				Date:    time.Date(2025, time.June, 17, 0, 0, 0, 0, time.UTC),
-				Payee:   "LOOMISP Harry s ApS DKK Den",
-				Memo:    "LOOMISP*Harry s ApS\nDKK 55,00\nDen 13.06",
-				Amount:  ynabber.Milliunits(-55000)},
+				Account:   ynabber.Account{Name: "foo", IBAN: "bar"},
+				ID:        ynabber.ID("CBP-209886344408903.130001"),
+				Date:      time.Date(2025, time.June, 17, 0, 0, 0, 0, time.UTC),
+				Payee:     "LOOMISP Harry s ApS DKK Den",
+				Memo:      "LOOMISP*Harry s ApS\nDKK 55,00\nDen 13.06",
+				Amount:    ynabber.Milliunits(-55000),
+				Status:    ynabber.StatusBooked,
+				Currency:  "DKK",
+				ValueDate: time.Date(2025, time.June, 17, 0, 0, 0, 0, time.UTC)},
 			},
 			wantErr: false,
 		},
@@ -208,12 +217,15 @@ func TestToYnabber(t *testing.T) {
 				),
 			},
 			want: []ynabber.Transaction{{
-				Account: ynabber.Account{Name: "foo", IBAN: "bar"},
-				ID:      ynabber.ID("foobar"),
-				Date:    time.Date(2023, time.February, 24, 0, 0, 0, 0, time.UTC),
-				Payee:   "PASCAL AS",
-				Memo:    "PASCAL AS",
-				Amount:  ynabber.Milliunits(10000)},
+				Account:   ynabber.Account{Name: "foo", IBAN: "bar"},
+				ID:        ynabber.ID("foobar"),
+				Date:      time.Date(2023, time.February, 24, 0, 0, 0, 0, time.UTC),
+				Payee:     "PASCAL AS",
+				Memo:      "PASCAL AS",
+				Amount:    ynabber.Milliunits(10000),
+				Status:    ynabber.StatusBooked,
+				Currency:  "NOK",
+				ValueDate: time.Date(2023, time.February, 24, 0, 0, 0, 0, time.UTC)},
 			},
 			wantErr: false,
 		},
@@ -249,12 +261,16 @@ func TestToYnabber(t *testing.T) {
 				),
 			},
 			want: []ynabber.Transaction{{
-				Account: ynabber.Account{Name: "foo", IBAN: "bar"},
-				ID:      ynabber.ID("foobar"),
-				Date:    time.Date(2025, time.May, 28, 0, 0, 0, 0, time.UTC),
-				Payee:   "Retail shop",
-				Memo:    "Retail shop",
-				Amount:  -ynabber.Milliunits(80000)},
+				Account:      ynabber.Account{Name: "foo", IBAN: "bar"},
+				ID:           ynabber.ID("foobar"),
+				Date:         time.Date(2025, time.May, 28, 0, 0, 0, 0, time.UTC),
+				Payee:        "Retail shop",
+				Memo:         "Retail shop",
+				Amount:       -ynabber.Milliunits(80000),
+				Status:       ynabber.StatusBooked,
+				Currency:     "EUR",
+				ValueDate:    time.Date(2025, time.May, 28, 0, 0, 0, 0, time.UTC),
+				Counterparty: ynabber.Counterparty{Name: "Retail shop"}},
 			},
 			wantErr: false,
 		},
@@ -290,12 +306,16 @@ func TestToYnabber(t *testing.T) {
 				),
 			},
 			want: []ynabber.Transaction{{
-				Account: ynabber.Account{Name: "foo", IBAN: "bar"},
-				ID:      ynabber.ID("foobar"),
-				Date:    time.Date(2025, time.May, 28, 0, 0, 0, 0, time.UTC),
-				Payee:   "JOHN DOE",
-				Memo:    "Hello there",
-				Amount:  ynabber.Milliunits(80000)},
+				Account:      ynabber.Account{Name: "foo", IBAN: "bar"},
+				ID:           ynabber.ID("foobar"),
+				Date:         time.Date(2025, time.May, 28, 0, 0, 0, 0, time.UTC),
+				Payee:        "JOHN DOE",
+				Memo:         "Hello there",
+				Amount:       ynabber.Milliunits(80000),
+				Status:       ynabber.StatusBooked,
+				Currency:     "EUR",
+				ValueDate:    time.Date(2025, time.May, 28, 0, 0, 0, 0, time.UTC),
+				Counterparty: ynabber.Counterparty{Name: "JOHN DOE"}},
 			},
 			wantErr: false,
 		},
@@ -323,3 +343,51 @@ func TestToYnabber(t *testing.T) {
 		})
 	}
 }
+
+func TestToYnabberPending(t *testing.T) {
+	transaction := func(id, bookingDate string) nordigen.Transaction {
+		return nordigen.Transaction{
+			TransactionId: id,
+			BookingDate:   bookingDate,
+			ValueDate:     "2025-05-20",
+			TransactionAmount: struct {
+				Amount   string "json:\"amount,omitempty\""
+				Currency string "json:\"currency,omitempty\""
+			}{Amount: "-10.00", Currency: "NOK"},
+			CreditorName: "Grocer",
+		}
+	}
+	var config Config
+	_ = envconfig.Process("", &config)
+	response := getAccountTransactions(transaction("booked", "2025-05-19"))
+	response.Transactions.Pending = []nordigen.Transaction{transaction("pending", "")}
+
+	tests := []struct {
+		name    string
+		pending bool
+		want    []ynabber.Status
+	}{
+		{name: "disabled", want: []ynabber.Status{ynabber.StatusBooked}},
+		{name: "enabled", pending: true, want: []ynabber.Status{ynabber.StatusBooked, ynabber.StatusPending}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Pending = tt.pending
+			reader := Reader{Config: config, logger: slog.Default()}
+			got, err := reader.toYnabbers(ynabber.Account{IBAN: "bar"}, response)
+			if err != nil {
+				t.Fatalf("toYnabbers() error = %v", err)
+			}
+			statuses := make([]ynabber.Status, len(got))
+			for i, transaction := range got {
+				statuses[i] = transaction.Status
+			}
+			if !cmp.Equal(statuses, tt.want) {
+				t.Errorf("statuses = %v, want %v", statuses, tt.want)
+			}
+			if tt.pending && !got[1].Date.Equal(time.Date(2025, time.May, 20, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("pending date = %v, want the value date", got[1].Date)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/frieser/nordigen-go-lib/v2"
 	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/internal/status"
 )
 
 func (r Reader) after(delay time.Duration) <-chan time.Time {
@@ -24,6 +25,7 @@ func (r Reader) retryHandler(ctx context.Context, err error) error {
 		// Handle rate limit error by waiting until the reset timer expires
 		wait := time.Duration(rl.RateLimit.Reset+1) * time.Second
 		r.logger.Info("rate limited, retrying later", "wait", wait)
+		r.status.BackOff(time.Now().Add(wait), err)
 
 		select {
 		case <-r.after(wait):
@@ -49,6 +51,7 @@ func (r Reader) Runner(ctx context.Context, out chan<- []ynabber.Transaction) er
 		default:
 		}
 
+		r.status.Set(status.Fetching)
 		batch, err := bulk()
 		if err != nil {
 			r.logger.Error("bulk reading transactions", "error", err)
@@ -69,12 +72,14 @@ func (r Reader) Runner(ctx context.Context, out chan<- []ynabber.Transaction) er
 
 		if r.Config.Interval > 0 {
 			r.logger.Info("waiting for next run", "in", r.Config.Interval)
+			r.status.Wait(time.Now().Add(r.Config.Interval))
 			select {
 			case <-r.after(r.Config.Interval):
 			case <-ctx.Done():
 				return ctx.Err()
 			}
 		} else {
+			r.status.Set(status.Idle)
 			return nil
 		}
 	}
@@ -0,0 +1,176 @@
+package ynabber
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Options are passed to the factories creating readers, writers and
+// transformers.
+type Options struct {
+	// Name is the full name the component was requested by, like
+	// "enablebanking:dnb".
+	Name string
+
+	// Instance is the instance part of Name, like "dnb". It is empty unless
+	// the component was requested with an instance name, which only readers
+	// support.
+	Instance string
+
+	// DataDir is the directory for storing files, from YNABBER_DATADIR.
+	DataDir string
+
+	// Logger is the default logger.
+	Logger *slog.Logger
+
+	// Once makes readers fetch a single time and return, ignoring their
+	// interval.
+	Once bool
+
+	// Window, when set, replaces the date range readers fetch, so history can
+	// be backfilled without changing their config.
+	Window Window
+}
+
+// EnvPrefix returns the prefix of the environment variables configuring the
+// component, see EnvPrefix.
+func (o Options) EnvPrefix() string {
+	return EnvPrefix(o.Instance)
+}
+
+type (
+	ReaderFactory      func(Options) (Reader, error)
+	WriterFactory      func(Options) (Writer, error)
+	TransformerFactory func(Options) (Transformer, error)
+)
+
+// Component describes a registered reader, writer or transformer.
+type Component struct {
+	Name string
+
+	// Config is a pointer to the config struct of the component, used to
+	// list its environment variables. It may be nil.
+	Config any
+}
+
+type registration[F any] struct {
+	Component
+	factory F
+}
+
+var registry = struct {
+	sync.RWMutex
+	readers      map[string]registration[ReaderFactory]
+	writers      map[string]registration[WriterFactory]
+	transformers map[string]registration[TransformerFactory]
+}{
+	readers:      make(map[string]registration[ReaderFactory]),
+	writers:      make(map[string]registration[WriterFactory]),
+	transformers: make(map[string]registration[TransformerFactory]),
+}
+
+// RegisterReader makes a reader available by name, typically called from the
+// init function of the package implementing it. config is a pointer to its
+// config struct, or nil. It panics if the name is already registered.
+func RegisterReader(name string, factory ReaderFactory, config any) {
+	register(registry.readers, "reader", name, factory, config)
+}
+
+// RegisterWriter makes a writer available by name, see RegisterReader.
+func RegisterWriter(name string, factory WriterFactory, config any) {
+	register(registry.writers, "writer", name, factory, config)
+}
+
+// RegisterTransformer makes a transformer available by name, see
+// RegisterReader.
+func RegisterTransformer(name string, factory TransformerFactory, config any) {
+	register(registry.transformers, "transformer", name, factory, config)
+}
+
+func register[F any](m map[string]registration[F], kind, name string, factory F, config any) {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, dup := m[name]; dup {
+		panic(fmt.Sprintf("ynabber: %s %q registered twice", kind, name))
+	}
+	m[name] = registration[F]{Component{Name: name, Config: config}, factory}
+}
+
+// NewReader creates the registered reader called name. The name may include
+// an instance name, like "enablebanking:dnb", to run a reader more than once.
+func NewReader(name string, opts Options) (Reader, error) {
+	kind, instance, err := ParseInstance(name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := lookup(registry.readers, "reader", kind)
+	if err != nil {
+		return nil, err
+	}
+	opts.Name, opts.Instance = name, instance
+	return r.factory(opts)
+}
+
+// NewWriter creates the registered writer called name.
+func NewWriter(name string, opts Options) (Writer, error) {
+	r, err := lookup(registry.writers, "writer", name)
+	if err != nil {
+		return nil, err
+	}
+	opts.Name = name
+	return r.factory(opts)
+}
+
+// NewTransformer creates the registered transformer called name.
+func NewTransformer(name string, opts Options) (Transformer, error) {
+	r, err := lookup(registry.transformers, "transformer", name)
+	if err != nil {
+		return nil, err
+	}
+	opts.Name = name
+	return r.factory(opts)
+}
+
+func lookup[F any](m map[string]registration[F], kind, name string) (registration[F], error) {
+	registry.RLock()
+	defer registry.RUnlock()
+	r, ok := m[name]
+	if !ok {
+		return r, fmt.Errorf("unknown %s %q, available: %v", kind, name, names(m))
+	}
+	return r, nil
+}
+
+// Readers returns all registered readers sorted by name.
+func Readers() []Component { return components(registry.readers) }
+
+// Writers returns all registered writers sorted by name.
+func Writers() []Component { return components(registry.writers) }
+
+// Transformers returns all registered transformers sorted by name.
+func Transformers() []Component { return components(registry.transformers) }
+
+func components[F any](m map[string]registration[F]) []Component {
+	registry.RLock()
+	defer registry.RUnlock()
+	out := make([]Component, 0, len(m))
+	for _, r := range m {
+		out = append(out, r.Component)
+	}
+	slices.SortFunc(out, func(a, b Component) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return out
+}
+
+func names[F any](m map[string]registration[F]) []string {
+	out := make([]string, 0, len(m))
+	for name := range m {
+		out = append(out, name)
+	}
+	slices.Sort(out)
+	return out
+}
@@ -0,0 +1,56 @@
+package ynabber
+
+import (
+	"strings"
+	"testing"
+)
+
+// Mock reader remembering the options it was created with
+type registeredReader struct {
+	mockOneShotReader
+	opts Options
+}
+
+func (r *registeredReader) String() string { return r.opts.Name }
+
+func TestRegistry(t *testing.T) {
+	RegisterReader("test-reader", func(opts Options) (Reader, error) {
+		return &registeredReader{opts: opts}, nil
+	}, &Config{})
+
+	reader, err := NewReader("test-reader:dnb", Options{DataDir: "/data"})
+	if err != nil {
+		t.Fatalf("NewReader() failed: %v", err)
+	}
+	opts := reader.(*registeredReader).opts
+	if opts.Name != "test-reader:dnb" || opts.Instance != "dnb" || opts.DataDir != "/data" {
+		t.Errorf("factory got options %+v", opts)
+	}
+	if got := opts.EnvPrefix(); got != "DNB" {
+		t.Errorf("EnvPrefix() = %q, want DNB", got)
+	}
+
+	if _, err := NewReader("missing", Options{}); err == nil || !strings.Contains(err.Error(), "test-reader") {
+		t.Errorf("NewReader() error = %v, want unknown reader listing the available ones", err)
+	}
+	if _, err := NewWriter("test-reader", Options{}); err == nil {
+		t.Error("NewWriter() error = nil, readers must not be found as writers")
+	}
+
+	var found bool
+	for _, c := range Readers() {
+		if c.Name == "test-reader" {
+			found = c.Config != nil
+		}
+	}
+	if !found {
+		t.Errorf("Readers() = %+v, want test-reader with its config", Readers())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a reader twice did not panic")
+		}
+	}()
+	RegisterReader("test-reader", nil, nil)
+}
@@ -0,0 +1,101 @@
+package ynabber
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Sign selects transactions by the direction of their amount.
+type Sign string
+
+const (
+	Inflow  Sign = "inflow"
+	Outflow Sign = "outflow"
+)
+
+// UnmarshalJSON implements json.Unmarshaler and rejects unknown signs.
+func (s *Sign) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch lowered := Sign(strings.ToLower(value)); lowered {
+	case "", Inflow, Outflow:
+		*s = lowered
+		return nil
+	default:
+		return fmt.Errorf("unknown sign %q, must be %q or %q", value, Inflow, Outflow)
+	}
+}
+
+// Route selects the transactions a writer receives. Every field that is set
+// must match, and an empty route matches every transaction.
+type Route struct {
+	// Account matches the account IBAN or ID.
+	Account string `json:"account,omitempty"`
+
+	// Reader matches the name of the reader the transaction came from. A
+	// plain reader name like "enablebanking" also matches all of its named
+	// instances like "enablebanking:dnb".
+	Reader string `json:"reader,omitempty"`
+
+	// Sign matches inflows or outflows.
+	Sign Sign `json:"sign,omitempty"`
+}
+
+// Match reports whether t, read by reader, is selected by the route.
+func (r Route) Match(reader string, t Transaction) bool {
+	if r.Account != "" && r.Account != t.Account.IBAN && r.Account != string(t.Account.ID) {
+		return false
+	}
+	if r.Reader != "" && r.Reader != reader && !strings.HasPrefix(reader, r.Reader+":") {
+		return false
+	}
+	switch r.Sign {
+	case Inflow:
+		return t.Amount > 0
+	case Outflow:
+		return t.Amount < 0
+	}
+	return true
+}
+
+// Routes maps writer names to the routes that select which transactions they
+// receive. A transaction is sent to a writer if any of its routes match.
+// Writers without routes receive every transaction.
+type Routes map[string][]Route
+
+// Decode implements envconfig.Decoder for Routes to decode JSON properly
+func (r *Routes) Decode(value string) error {
+	if value == "" {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(r); err != nil {
+		return fmt.Errorf("decoding routes: %w", err)
+	}
+	return nil
+}
+
+// Filter returns the transactions in batch, read by reader, that writer
+// should receive.
+func (r Routes) Filter(writer, reader string, batch []Transaction) []Transaction {
+	routes, ok := r[writer]
+	if !ok {
+		return batch
+	}
+
+	routed := make([]Transaction, 0, len(batch))
+	for _, t := range batch {
+		for _, route := range routes {
+			if route.Match(reader, t) {
+				routed = append(routed, t)
+				break
+			}
+		}
+	}
+	return routed
+}
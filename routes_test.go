@@ -0,0 +1,87 @@
+package ynabber
+
+import (
+	"testing"
+)
+
+func TestRouteMatch(t *testing.T) {
+	tx := Transaction{
+		Account: Account{ID: "acc-1", IBAN: "NO8330001234567"},
+		Amount:  MilliunitsFromAmount(-10),
+	}
+
+	tests := []struct {
+		name   string
+		route  Route
+		reader string
+		want   bool
+	}{
+		{"empty route", Route{}, "nordigen", true},
+		{"iban", Route{Account: "NO8330001234567"}, "nordigen", true},
+		{"id", Route{Account: "acc-1"}, "nordigen", true},
+		{"other account", Route{Account: "acc-2"}, "nordigen", false},
+		{"reader", Route{Reader: "nordigen"}, "nordigen", true},
+		{"other reader", Route{Reader: "enablebanking"}, "nordigen", false},
+		{"instance", Route{Reader: "nordigen:dnb"}, "nordigen:dnb", true},
+		{"kind matches instance", Route{Reader: "nordigen"}, "nordigen:dnb", true},
+		{"other instance", Route{Reader: "nordigen:nordea"}, "nordigen:dnb", false},
+		{"instance does not match kind", Route{Reader: "nordigen:dnb"}, "nordigen", false},
+		{"kind prefix", Route{Reader: "nord"}, "nordigen", false},
+		{"outflow", Route{Sign: Outflow}, "nordigen", true},
+		{"inflow", Route{Sign: Inflow}, "nordigen", false},
+		{"all fields", Route{Account: "acc-1", Reader: "nordigen", Sign: Outflow}, "nordigen", true},
+		{"one field differs", Route{Account: "acc-1", Reader: "nordigen", Sign: Inflow}, "nordigen", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.route.Match(tt.reader, tx); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoutesDecode(t *testing.T) {
+	var routes Routes
+	err := routes.Decode(`{"ynab": [{"account": "NO1"}], "actual": [{"reader": "nordigen", "sign": "Outflow"}]}`)
+	if err != nil {
+		t.Fatalf("Decode() failed: %v", err)
+	}
+	if got := routes["ynab"]; len(got) != 1 || got[0].Account != "NO1" {
+		t.Errorf("ynab routes = %+v", got)
+	}
+	if got := routes["actual"]; len(got) != 1 || got[0].Reader != "nordigen" || got[0].Sign != Outflow {
+		t.Errorf("actual routes = %+v", got)
+	}
+
+	for _, value := range []string{
+		`{"ynab": [{"sign": "sideways"}]}`,
+		`{"ynab": [{"acount": "NO1"}]}`,
+		`not json`,
+	} {
+		var routes Routes
+		if err := routes.Decode(value); err == nil {
+			t.Errorf("Decode(%q) error = nil, want error", value)
+		}
+	}
+}
+
+func TestRoutesFilter(t *testing.T) {
+	batch := []Transaction{
+		{ID: "1", Account: Account{IBAN: "NO1"}, Amount: 100},
+		{ID: "2", Account: Account{IBAN: "NO2"}, Amount: -100},
+		{ID: "3", Account: Account{IBAN: "NO3"}, Amount: -100},
+	}
+	routes := Routes{
+		"ynab": {{Account: "NO1"}, {Account: "NO2"}},
+	}
+
+	if got := routes.Filter("json", "nordigen", batch); len(got) != len(batch) {
+		t.Errorf("writer without routes got %d transactions, want %d", len(got), len(batch))
+	}
+
+	got := routes.Filter("ynab", "nordigen", batch)
+	if len(got) != 2 || got[0].ID != "1" || got[1].ID != "2" {
+		t.Errorf("Filter() = %+v, want transactions 1 and 2", got)
+	}
+}
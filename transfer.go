@@ -0,0 +1,75 @@
+package ynabber
+
+import (
+	"slices"
+	"time"
+)
+
+// TransferDays is how many days apart the two sides of a transfer may be
+// dated. Writers date the receiving side they create like the sending side,
+// while the bank may book the receiving side a few days later.
+const TransferDays = 7
+
+// HasTransfers reports whether any transaction in batch is a transfer.
+func HasTransfers(batch []Transaction) bool {
+	return slices.ContainsFunc(batch, Transaction.IsTransfer)
+}
+
+// TransferSide is one side of a transfer between two accounts of a budget,
+// as a writer is about to write it.
+type TransferSide struct {
+	// Account is the budget account of the side and Other the budget
+	// account of the other side.
+	Account string
+	Other   string
+	// Amount is in the unit of the writer, outflows are negative.
+	Amount int64
+	// Date is formatted like time.DateOnly.
+	Date string
+}
+
+// PairSending returns the index in sending, among linked, of the sending
+// side that receiving is the other side of: between the same two accounts, of
+// the opposite amount and dated at most TransferDays apart. The closest in
+// date wins. side returns the TransferSide of the type the writer keeps the
+// sides in.
+func PairSending[S any](sending []S, linked map[int]bool, receiving S, side func(S) TransferSide) (int, bool) {
+	r := side(receiving)
+	return ClosestTransfer(len(sending), r.Date, func(k int) (string, bool) {
+		s := side(sending[k])
+		return s.Date, linked[k] && s.Account == r.Other && s.Other == r.Account && s.Amount == -r.Amount
+	})
+}
+
+// ClosestTransfer returns the index, below n, of the candidate for the other
+// side of a transfer dated closest to date and at most TransferDays from it.
+// candidate returns the date of the candidate at index i and whether it may
+// be the other side at all. Of equally close candidates the first wins.
+func ClosestTransfer(n int, date string, candidate func(i int) (string, bool)) (int, bool) {
+	best, bestDays := -1, 0
+	for i := range n {
+		other, ok := candidate(i)
+		if !ok {
+			continue
+		}
+		apart, ok := DaysApart(other, date)
+		if !ok || apart > TransferDays {
+			continue
+		}
+		if best == -1 || apart < bestDays {
+			best, bestDays = i, apart
+		}
+	}
+	return best, best != -1
+}
+
+// DaysApart returns how many days apart dates a and b, formatted like
+// time.DateOnly, are.
+func DaysApart(a, b string) (int, bool) {
+	dateA, errA := time.Parse(time.DateOnly, a)
+	dateB, errB := time.Parse(time.DateOnly, b)
+	if errA != nil || errB != nil {
+		return 0, false
+	}
+	return int(dateA.Sub(dateB).Abs().Hours() / 24), true
+}
@@ -0,0 +1,89 @@
+package ynabber
+
+import "testing"
+
+func TestPairSending(t *testing.T) {
+	sending := []TransferSide{
+		{Account: "checking", Other: "savings", Amount: -1000, Date: "2024-05-01"},
+		{Account: "checking", Other: "savings", Amount: -1000, Date: "2024-05-03"},
+		{Account: "checking", Other: "savings", Amount: -1000, Date: "2024-05-03"},
+		{Account: "checking", Other: "other", Amount: -1000, Date: "2024-05-03"},
+		{Account: "checking", Other: "savings", Amount: -500, Date: "2024-05-03"},
+	}
+	all := map[int]bool{0: true, 1: true, 2: true, 3: true, 4: true}
+	tests := []struct {
+		name      string
+		linked    map[int]bool
+		receiving TransferSide
+		want      int
+		ok        bool
+	}{
+		{
+			name:      "closest in date",
+			linked:    all,
+			receiving: TransferSide{Account: "savings", Other: "checking", Amount: 1000, Date: "2024-05-04"},
+			want:      1,
+			ok:        true,
+		},
+		{
+			name:      "first of equally close",
+			linked:    map[int]bool{0: true, 2: true},
+			receiving: TransferSide{Account: "savings", Other: "checking", Amount: 1000, Date: "2024-05-02"},
+			want:      0,
+			ok:        true,
+		},
+		{
+			name:      "only linked",
+			linked:    map[int]bool{0: true},
+			receiving: TransferSide{Account: "savings", Other: "checking", Amount: 1000, Date: "2024-05-04"},
+			want:      0,
+			ok:        true,
+		},
+		{
+			name:      "other amount",
+			linked:    all,
+			receiving: TransferSide{Account: "savings", Other: "checking", Amount: 500, Date: "2024-05-03"},
+			want:      4,
+			ok:        true,
+		},
+		{
+			name:      "too far apart",
+			linked:    all,
+			receiving: TransferSide{Account: "savings", Other: "checking", Amount: 1000, Date: "2024-05-11"},
+			want:      -1,
+		},
+		{
+			name:      "other accounts",
+			linked:    all,
+			receiving: TransferSide{Account: "savings", Other: "brokerage", Amount: 1000, Date: "2024-05-03"},
+			want:      -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := PairSending(sending, tt.linked, tt.receiving, func(s TransferSide) TransferSide { return s })
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("PairSending() = %d, %v, want %d, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDaysApart(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+		ok   bool
+	}{
+		{a: "2024-05-01", b: "2024-05-01", want: 0, ok: true},
+		{a: "2024-05-01", b: "2024-05-08", want: 7, ok: true},
+		{a: "2024-05-08", b: "2024-05-01", want: 7, ok: true},
+		{a: "2024-02-28", b: "2024-03-01", want: 2, ok: true},
+		{a: "01.05.2024", b: "2024-05-01"},
+	}
+	for _, tt := range tests {
+		if got, ok := DaysApart(tt.a, tt.b); got != tt.want || ok != tt.ok {
+			t.Errorf("DaysApart(%q, %q) = %d, %v, want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.ok)
+		}
+	}
+}
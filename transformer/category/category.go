@@ -0,0 +1,60 @@
+package category
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/transformer/rules"
+)
+
+// Transformer assigns categories to transactions by rules.
+type Transformer struct {
+	Config Config
+	rules  rules.Rules
+	logger *slog.Logger
+}
+
+func init() {
+	ynabber.RegisterTransformer("category", func(ynabber.Options) (ynabber.Transformer, error) {
+		return NewTransformer()
+	}, &Config{})
+}
+
+// NewTransformer returns a new category transformer
+func NewTransformer() (Transformer, error) {
+	cfg := Config{}
+	if err := ynabber.ProcessEnv("", &cfg); err != nil {
+		return Transformer{}, fmt.Errorf("processing config: %w", err)
+	}
+
+	return Transformer{
+		Config: cfg,
+		rules:  cfg.Rules.compile(),
+		logger: slog.Default().With("transformer", "category"),
+	}, nil
+}
+
+// String returns the name of the transformer
+func (t Transformer) String() string {
+	return "category"
+}
+
+// Transform sets the category of every transaction in batch to that of the
+// first rule matching it. Transactions that already have a category, or that
+// no rule matches, are left as they are. The rules are matched by the rules
+// transformer, whose category action they are a shorthand for.
+func (t Transformer) Transform(_ context.Context, batch []ynabber.Transaction) ([]ynabber.Transaction, error) {
+	out := make([]ynabber.Transaction, len(batch))
+	for i, tx := range batch {
+		if tx.Category == "" {
+			if result, applied, _ := t.rules.Apply(tx); len(applied) > 0 {
+				tx.Category = result.Category
+				t.logger.Debug("categorized transaction", "transaction", tx.ID, "category", tx.Category)
+			}
+		}
+		out[i] = tx
+	}
+	return out, nil
+}
@@ -0,0 +1,126 @@
+package category
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/martinohansen/ynabber"
+)
+
+func TestTransform(t *testing.T) {
+	var rules Rules
+	err := rules.Decode(`[
+		{"payee": "(?i)rema 1000", "category": "Groceries"},
+		{"mcc": ["5812", "5814"], "min": "-500.00", "category": "Eating Out"},
+		{"account": "NO2", "max": 0, "category": "Savings"},
+		{"memo": "Salary", "min": 1, "category": "Inflow: Ready to Assign"}
+	]`)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	transformer := Transformer{
+		Config: Config{Rules: rules},
+		rules:  rules.compile(),
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	tests := []struct {
+		name string
+		in   ynabber.Transaction
+		want string
+	}{
+		{
+			name: "payee",
+			in:   ynabber.Transaction{Payee: "REMA 1000 Majorstuen", Amount: -25000},
+			want: "Groceries",
+		},
+		{
+			name: "mcc within range",
+			in:   ynabber.Transaction{Payee: "Cafe", MCC: "5814", Amount: -500000},
+			want: "Eating Out",
+		},
+		{
+			name: "mcc out of range",
+			in:   ynabber.Transaction{Payee: "Restaurant", MCC: "5812", Amount: -500010},
+		},
+		{
+			name: "mcc matches whole codes",
+			in:   ynabber.Transaction{Payee: "Cafe", MCC: "58140", Amount: -1000},
+		},
+		{
+			name: "account and sign",
+			in:   ynabber.Transaction{Account: ynabber.Account{IBAN: "NO2"}, Amount: -1000},
+			want: "Savings",
+		},
+		{
+			name: "memo and sign",
+			in:   ynabber.Transaction{Memo: "Salary May", Amount: 1000},
+			want: "Inflow: Ready to Assign",
+		},
+		{
+			name: "first rule wins",
+			in:   ynabber.Transaction{Account: ynabber.Account{IBAN: "NO2"}, Payee: "Rema 1000", Amount: -1000},
+			want: "Groceries",
+		},
+		{
+			name: "already categorized",
+			in:   ynabber.Transaction{Payee: "Rema 1000", Category: "Household"},
+			want: "Household",
+		},
+		{
+			name: "no match",
+			in:   ynabber.Transaction{Payee: "Bookshop", Amount: -1000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformer.Transform(context.Background(), []ynabber.Transaction{tt.in})
+			if err != nil {
+				t.Fatalf("Transform() error = %v", err)
+			}
+			if got[0].Category != tt.want {
+				t.Errorf("category = %q, want %q", got[0].Category, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr bool
+	}{
+		{name: "valid", rules: `[{"payee": "Rema", "category": "Groceries"}]`},
+		{name: "no category", rules: `[{"payee": "Rema"}]`, wantErr: true},
+		{name: "no conditions", rules: `[{"category": "Groceries"}]`, wantErr: true},
+		{name: "min above max", rules: `[{"min": 10, "max": -10, "category": "Groceries"}]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			if err := cfg.Rules.Decode(tt.rules); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if err := cfg.Check(); (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRulesDecodeInvalid(t *testing.T) {
+	for _, value := range []string{
+		`[{"payee": "(", "category": "Groceries"}]`,
+		`[{"min": "ten", "category": "Groceries"}]`,
+		`{"category": "Groceries"}`,
+		`[{"payeee": "Rema", "category": "Groceries"}]`,
+	} {
+		var rules Rules
+		if err := rules.Decode(value); err == nil {
+			t.Errorf("Decode(%s) = nil, want error", value)
+		}
+	}
+}
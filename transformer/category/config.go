@@ -0,0 +1,30 @@
+// Category assigns a budget category to transactions matching configured
+// rules, so writers can import them categorized.
+package category
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Config struct {
+	// Rules is a JSON array of rules. The category of the first rule matching
+	// a transaction is assigned to it. A rule matches when every condition it
+	// sets does: payee and memo (regular expressions), account (IBAN or
+	// account ID), mcc (a list of merchant category codes) and min and max
+	// (the amount, inclusive, negative for outflows).
+	// Example: '[{"payee": "(?i)rema 1000", "category": "Groceries"},
+	// {"mcc": ["5812", "5814"], "min": -500, "category": "Eating Out"}]'
+	Rules Rules `envconfig:"CATEGORY_RULES"`
+}
+
+// Check implements ynabber.Checker.
+func (c *Config) Check() error {
+	var errs []error
+	for i, rule := range c.Rules {
+		if err := rule.check(); err != nil {
+			errs = append(errs, fmt.Errorf("CATEGORY_RULES: rule %d: %w", i+1, err))
+		}
+	}
+	return errors.Join(errs...)
+}
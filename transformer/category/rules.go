@@ -0,0 +1,93 @@
+package category
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/martinohansen/ynabber/transformer/rules"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule assigns Category to transactions matching every condition it sets. It
+// is a shorthand for a rule of the rules transformer, which matches it, see
+// rule.
+type Rule struct {
+	// Payee and Memo match the payee and memo of the transaction.
+	Payee *rules.Pattern `yaml:"payee"`
+	Memo  *rules.Pattern `yaml:"memo"`
+	// Account matches the IBAN or ID of the account of the transaction.
+	Account string `yaml:"account"`
+	// MCC lists merchant category codes, one of which must match.
+	MCC []string `yaml:"mcc"`
+	// Min and Max bound the amount, inclusive. Outflows are negative.
+	Min *rules.Amount `yaml:"min"`
+	Max *rules.Amount `yaml:"max"`
+
+	// Category is the name of the category in the budget.
+	Category string `yaml:"category"`
+}
+
+// rule returns r as a rule of the rules transformer that sets the category
+// and stops, so the first matching rule wins.
+func (r Rule) rule() rules.Rule {
+	rule := rules.Rule{
+		Then: rules.Actions{Category: &r.Category},
+		Stop: true,
+	}
+	rule.If.Payee, rule.If.Memo = r.Payee, r.Memo
+	if r.Account != "" {
+		rule.If.Account = []string{r.Account}
+	}
+	if len(r.MCC) > 0 {
+		codes := make([]string, len(r.MCC))
+		for i, code := range r.MCC {
+			codes[i] = regexp.QuoteMeta(code)
+		}
+		rule.If.MCC = &rules.Pattern{Regexp: regexp.MustCompile("^(?:" + strings.Join(codes, "|") + ")$")}
+	}
+	rule.If.Amount.Min, rule.If.Amount.Max = r.Min, r.Max
+	return rule
+}
+
+// check returns why r can't be used, if it can't.
+func (r Rule) check() error {
+	switch {
+	case r.Category == "":
+		return errors.New("no category")
+	case r.Payee == nil && r.Memo == nil && r.Account == "" && len(r.MCC) == 0 && r.Min == nil && r.Max == nil:
+		return errors.New("no conditions")
+	case r.Min != nil && r.Max != nil && *r.Min > *r.Max:
+		return errors.New("min is greater than max")
+	}
+	return nil
+}
+
+// Rules is a list of rules. It implements envconfig.Decoder, parsing a JSON
+// array of rules.
+type Rules []Rule
+
+// Decode implements envconfig.Decoder for Rules. JSON is parsed as YAML, of
+// which it is a subset, to share the condition types of the rules
+// transformer.
+func (r *Rules) Decode(value string) error {
+	if value == "" {
+		return nil
+	}
+	decoder := yaml.NewDecoder(strings.NewReader(value))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(r); err != nil {
+		return fmt.Errorf("parsing rules: %w", err)
+	}
+	return nil
+}
+
+// compile returns r as rules of the rules transformer.
+func (r Rules) compile() rules.Rules {
+	out := make(rules.Rules, len(r))
+	for i, rule := range r {
+		out[i] = rule.rule()
+	}
+	return out
+}
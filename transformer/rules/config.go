@@ -0,0 +1,28 @@
+// Rules applies the rules of a rules file to every transaction. A rule sets
+// conditions on the transaction and actions to apply when they all match,
+// like setting the payee, category or flag, negating the amount or skipping
+// the transaction.
+package rules
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Config struct {
+	// File is the path of the rules file, in YAML or JSON. See the README of
+	// the rules transformer for the format.
+	File string `envconfig:"RULES_FILE"`
+}
+
+// Check implements ynabber.Checker. It loads the rules file to check every
+// rule in it.
+func (c *Config) Check() error {
+	if c.File == "" {
+		return errors.New("RULES_FILE: is required")
+	}
+	if _, err := Load(c.File); err != nil {
+		return fmt.Errorf("RULES_FILE: %w", err)
+	}
+	return nil
+}
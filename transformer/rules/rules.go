@@ -0,0 +1,359 @@
+package rules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+	"gopkg.in/yaml.v3"
+)
+
+// Flags are the flag colors a rule can set.
+var Flags = []string{"red", "orange", "yellow", "green", "blue", "purple"}
+
+// Pattern is a regular expression. It unmarshals from a string.
+type Pattern struct {
+	*regexp.Regexp
+}
+
+// UnmarshalYAML compiles the pattern in value.
+func (p *Pattern) UnmarshalYAML(value *yaml.Node) error {
+	var pattern string
+	if err := value.Decode(&pattern); err != nil {
+		return err
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid regex %q: %w", value.Line, pattern, err)
+	}
+	p.Regexp = re
+	return nil
+}
+
+// Amount is an amount in milliunits. It unmarshals from a decimal amount,
+// like -12.50.
+type Amount ynabber.Milliunits
+
+// UnmarshalYAML parses the decimal amount in value.
+func (a *Amount) UnmarshalYAML(value *yaml.Node) error {
+	m, err := ynabber.MilliunitsFromString(value.Value)
+	if err != nil || value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: invalid amount %q", value.Line, value.Value)
+	}
+	*a = Amount(m)
+	return nil
+}
+
+// Date is a date. It unmarshals from a date like 2006-01-02.
+type Date time.Time
+
+// UnmarshalYAML parses the date in value.
+func (d *Date) UnmarshalYAML(value *yaml.Node) error {
+	date, err := time.Parse(time.DateOnly, value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid date %q, want YYYY-MM-DD", value.Line, value.Value)
+	}
+	*d = Date(date)
+	return nil
+}
+
+// Conditions are the conditions of a rule. Every condition that is set must
+// match for the rule to apply.
+type Conditions struct {
+	// Payee, Memo, Counterparty, MCC and Currency match the field of the same
+	// name. Counterparty matches the name of the counterparty.
+	Payee        *Pattern `yaml:"payee"`
+	Memo         *Pattern `yaml:"memo"`
+	Counterparty *Pattern `yaml:"counterparty"`
+	MCC          *Pattern `yaml:"mcc"`
+	Currency     *Pattern `yaml:"currency"`
+	// Account lists accounts by IBAN or account ID, one of which must match.
+	Account []string `yaml:"account"`
+	// Amount bounds the amount. Outflows are negative.
+	Amount struct {
+		Min   *Amount `yaml:"min"`
+		Max   *Amount `yaml:"max"`
+		Below *Amount `yaml:"below"`
+		Above *Amount `yaml:"above"`
+	} `yaml:"amount"`
+	// Date bounds the date, inclusive.
+	Date struct {
+		From *Date `yaml:"from"`
+		To   *Date `yaml:"to"`
+	} `yaml:"date"`
+}
+
+// Match reports whether t matches every condition that is set.
+func (c Conditions) Match(t ynabber.Transaction) bool {
+	matches := func(p *Pattern, s string) bool {
+		return p == nil || p.MatchString(s)
+	}
+	amount := func(a *Amount) ynabber.Milliunits { return ynabber.Milliunits(*a) }
+	switch {
+	case !matches(c.Payee, t.Payee),
+		!matches(c.Memo, t.Memo),
+		!matches(c.Counterparty, t.Counterparty.Name),
+		!matches(c.MCC, t.MCC),
+		!matches(c.Currency, t.Currency):
+		return false
+	case len(c.Account) > 0 && !slices.Contains(c.Account, t.Account.IBAN) && !slices.Contains(c.Account, string(t.Account.ID)):
+		return false
+	case c.Amount.Min != nil && t.Amount < amount(c.Amount.Min),
+		c.Amount.Max != nil && t.Amount > amount(c.Amount.Max),
+		c.Amount.Below != nil && t.Amount >= amount(c.Amount.Below),
+		c.Amount.Above != nil && t.Amount <= amount(c.Amount.Above):
+		return false
+	case c.Date.From != nil && t.Date.Before(time.Time(*c.Date.From)),
+		c.Date.To != nil && t.Date.After(time.Time(*c.Date.To)):
+		return false
+	}
+	return true
+}
+
+// Replace replaces every match of Pattern in Field with With, which may refer
+// to submatches like $1.
+type Replace struct {
+	Field   string   `yaml:"field"`
+	Pattern *Pattern `yaml:"pattern"`
+	With    string   `yaml:"with"`
+}
+
+// Split is a part of a split. It has either a fixed Amount, which takes the
+// sign of the transaction, a Percent of the amount, or neither to take what
+// the other parts leave.
+type Split struct {
+	Amount   *Amount  `yaml:"amount"`
+	Percent  *float64 `yaml:"percent"`
+	Category string   `yaml:"category"`
+	Memo     string   `yaml:"memo"`
+}
+
+// Splits are the parts of a split.
+type Splits []Split
+
+// check returns why s can't be used, if it can't.
+func (s Splits) check() error {
+	if len(s) < 2 {
+		return errors.New("split: needs at least two parts")
+	}
+	remainders, percent := 0, 0.0
+	for i, part := range s {
+		switch {
+		case part.Amount != nil && part.Percent != nil:
+			return fmt.Errorf("split: part %d has both amount and percent", i+1)
+		case part.Amount != nil && *part.Amount <= 0:
+			return fmt.Errorf("split: part %d: amount must be positive, it takes the sign of the transaction", i+1)
+		case part.Percent != nil && (*part.Percent <= 0 || *part.Percent > 100):
+			return fmt.Errorf("split: part %d: percent must be above 0 and at most 100", i+1)
+		case part.Percent != nil:
+			percent += *part.Percent
+		case part.Amount == nil:
+			remainders++
+		}
+	}
+	switch {
+	case remainders > 1:
+		return errors.New("split: only one part can take the remainder")
+	case remainders == 0 && math.Abs(percent-100) > 1e-9:
+		return errors.New("split: without a part taking the remainder, the parts must be percentages adding up to 100")
+	case percent > 100:
+		return errors.New("split: percentages add up to more than 100")
+	}
+	return nil
+}
+
+// divide divides amount into the parts of s. Percentages are rounded to
+// cents and the part taking the remainder, or the last part if none does,
+// gets the rounding difference. It returns false if any part would be zero or
+// have the opposite sign of amount, like when the fixed amounts exceed it.
+func (s Splits) divide(amount ynabber.Milliunits) ([]ynabber.Split, bool) {
+	sign := ynabber.Milliunits(1)
+	if amount < 0 {
+		sign = -1
+	}
+
+	parts := make([]ynabber.Split, len(s))
+	remainder := len(s) - 1
+	var sum ynabber.Milliunits
+	for i, part := range s {
+		parts[i] = ynabber.Split{Category: part.Category, Memo: part.Memo}
+		switch {
+		case part.Amount != nil:
+			parts[i].Amount = sign * ynabber.Milliunits(*part.Amount)
+		case part.Percent != nil:
+			cents := math.Round(float64(amount) * *part.Percent / 1000)
+			parts[i].Amount = ynabber.Milliunits(cents) * 10
+		default:
+			remainder = i
+		}
+		sum += parts[i].Amount
+	}
+	parts[remainder].Amount += amount - sum
+
+	for _, part := range parts {
+		if part.Amount == 0 || (part.Amount < 0) != (amount < 0) {
+			return nil, false
+		}
+	}
+	return parts, true
+}
+
+// Actions are the actions of a rule, applied in the order of the fields.
+type Actions struct {
+	// Replace replaces text in the payee or memo.
+	Replace []Replace `yaml:"replace"`
+	// Payee, Memo and Category set the field of the same name.
+	Payee    *string `yaml:"payee"`
+	Memo     *string `yaml:"memo"`
+	Category *string `yaml:"category"`
+	// Flag sets the flag color, one of Flags.
+	Flag *string `yaml:"flag"`
+	// Negate swaps inflow and outflow.
+	Negate bool `yaml:"negate"`
+	// Split divides the transaction into parts with their own category and
+	// memo. Transactions it can't divide are left whole.
+	Split Splits `yaml:"split"`
+	// Skip leaves the transaction out, so it is never written.
+	Skip bool `yaml:"skip"`
+}
+
+// apply applies a to t and returns the result, or false if a skips it.
+func (a Actions) apply(t ynabber.Transaction) (ynabber.Transaction, bool) {
+	if a.Skip {
+		return t, false
+	}
+	for _, r := range a.Replace {
+		switch r.Field {
+		case "payee":
+			t.Payee = r.Pattern.ReplaceAllString(t.Payee, r.With)
+		case "memo":
+			t.Memo = r.Pattern.ReplaceAllString(t.Memo, r.With)
+		}
+	}
+	if a.Payee != nil {
+		t.Payee = *a.Payee
+	}
+	if a.Memo != nil {
+		t.Memo = *a.Memo
+	}
+	if a.Category != nil {
+		t.Category = *a.Category
+	}
+	if a.Flag != nil {
+		t.Flag = *a.Flag
+	}
+	if a.Negate {
+		t = t.Negate()
+	}
+	if len(a.Split) > 0 {
+		if splits, ok := a.Split.divide(t.Amount); ok {
+			t.Splits = splits
+		}
+	}
+	return t, true
+}
+
+// Rule applies its actions to transactions matching its conditions.
+type Rule struct {
+	// Name describes the rule in logs and in `ynabber rules test`.
+	Name string     `yaml:"name"`
+	If   Conditions `yaml:"if"`
+	Then Actions    `yaml:"then"`
+	// Stop stops evaluating the rules after this one when it matches.
+	Stop bool `yaml:"stop"`
+}
+
+// check returns why r can't be used, if it can't.
+func (r Rule) check() error {
+	for _, replace := range r.Then.Replace {
+		if replace.Field != "payee" && replace.Field != "memo" {
+			return fmt.Errorf("replace: field must be payee or memo, not %q", replace.Field)
+		}
+		if replace.Pattern == nil {
+			return errors.New("replace: no pattern")
+		}
+	}
+	if r.Then.Flag != nil && *r.Then.Flag != "" && !slices.Contains(Flags, *r.Then.Flag) {
+		return fmt.Errorf("flag: must be one of %v or empty, not %q", Flags, *r.Then.Flag)
+	}
+	if len(r.Then.Split) > 0 {
+		return r.Then.Split.check()
+	}
+	return nil
+}
+
+// Rules are the rules of a rules file, evaluated in order.
+type Rules []Rule
+
+// Apply applies every rule matching t in order, each to the result of the
+// ones before it. It returns the result, the names of the rules that were
+// applied and false if a rule skipped t.
+func (rules Rules) Apply(t ynabber.Transaction) (ynabber.Transaction, []string, bool) {
+	var applied []string
+	for i, rule := range rules {
+		if !rule.If.Match(t) {
+			continue
+		}
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+		applied = append(applied, name)
+
+		var keep bool
+		if t, keep = rule.Then.apply(t); !keep {
+			return t, applied, false
+		}
+		if rule.Stop {
+			break
+		}
+	}
+	return t, applied, true
+}
+
+// file is the format of a rules file.
+type file struct {
+	Rules Rules `yaml:"rules"`
+}
+
+// Load reads the rules file at path. YAML and JSON are both accepted.
+// Unknown fields are rejected so misspelled conditions don't match
+// everything.
+func Load(path string) (Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading rules file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses the rules in data, a rules file in YAML or JSON.
+func Parse(data []byte) (Rules, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var f file
+	if err := decoder.Decode(&f); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil // Empty file
+		}
+		return nil, fmt.Errorf("parsing rules: %w", err)
+	}
+
+	var errs []error
+	for i, rule := range f.Rules {
+		if err := rule.check(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i+1, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return f.Rules, nil
+}
@@ -0,0 +1,302 @@
+package rules
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/transformer/swapflow"
+)
+
+const testRules = `
+rules:
+  - name: grocer card purchases
+    if:
+      account: [NO1]
+      memo: "(?i)rema"
+      amount: {max: 0}
+    then:
+      replace:
+        - field: payee
+          pattern: "^VISA \\d+ "
+          with: ""
+      category: Groceries
+      flag: red
+  - name: refunds
+    if:
+      payee: Refund
+      amount: {above: 0}
+    then:
+      payee: Shop
+      negate: true
+  - name: internal
+    if:
+      counterparty: "^Me$"
+    then:
+      skip: true
+  - name: old
+    if:
+      date: {to: 2023-12-31}
+    then:
+      memo: Before 2024
+    stop: true
+  - name: after old
+    if:
+      date: {to: 2023-12-31}
+    then:
+      flag: blue
+`
+
+func TestApply(t *testing.T) {
+	rules, err := Parse([]byte(testRules))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	date := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		in        ynabber.Transaction
+		want      ynabber.Transaction
+		wantRules []string
+		wantKeep  bool
+	}{
+		{
+			name: "conditions and actions",
+			in:   ynabber.Transaction{Account: ynabber.Account{IBAN: "NO1"}, Date: date, Payee: "VISA 1234 Rema 1000", Memo: "REMA 1000 OSLO", Amount: -1000},
+			want: ynabber.Transaction{Account: ynabber.Account{IBAN: "NO1"}, Date: date, Payee: "Rema 1000", Memo: "REMA 1000 OSLO", Amount: -1000,
+				Category: "Groceries", Flag: "red"},
+			wantRules: []string{"grocer card purchases"},
+			wantKeep:  true,
+		},
+		{
+			name:      "other account",
+			in:        ynabber.Transaction{Account: ynabber.Account{IBAN: "NO2"}, Date: date, Payee: "VISA 1234 Rema 1000", Memo: "REMA 1000 OSLO", Amount: -1000},
+			want:      ynabber.Transaction{Account: ynabber.Account{IBAN: "NO2"}, Date: date, Payee: "VISA 1234 Rema 1000", Memo: "REMA 1000 OSLO", Amount: -1000},
+			wantRules: nil,
+			wantKeep:  true,
+		},
+		{
+			name:      "negate",
+			in:        ynabber.Transaction{Date: date, Payee: "Refund 42", Amount: 1000},
+			want:      ynabber.Transaction{Date: date, Payee: "Shop", Amount: -1000},
+			wantRules: []string{"refunds"},
+			wantKeep:  true,
+		},
+		{
+			name:      "skip",
+			in:        ynabber.Transaction{Date: date, Counterparty: ynabber.Counterparty{Name: "Me"}, Amount: 1000},
+			want:      ynabber.Transaction{Date: date, Counterparty: ynabber.Counterparty{Name: "Me"}, Amount: 1000},
+			wantRules: []string{"internal"},
+		},
+		{
+			name:      "stop",
+			in:        ynabber.Transaction{Date: date.AddDate(-1, 0, 0)},
+			want:      ynabber.Transaction{Date: date.AddDate(-1, 0, 0), Memo: "Before 2024"},
+			wantRules: []string{"old"},
+			wantKeep:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, applied, keep := rules.Apply(tt.in)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Apply() mismatch (-want +got):\n%s", diff)
+			}
+			if !slices.Equal(applied, tt.wantRules) || keep != tt.wantKeep {
+				t.Errorf("Apply() applied %v, keep %v, want %v, %v", applied, keep, tt.wantRules, tt.wantKeep)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{name: "unknown condition", rules: "rules:\n  - if: {paye: x}\n"},
+		{name: "invalid regex", rules: "rules:\n  - if: {payee: \"(\"}\n"},
+		{name: "invalid amount", rules: "rules:\n  - if: {amount: {min: ten}}\n"},
+		{name: "invalid date", rules: "rules:\n  - if: {date: {from: 01.05.2024}}\n"},
+		{name: "invalid flag", rules: "rules:\n  - then: {flag: pink}\n"},
+		{name: "invalid replace", rules: "rules:\n  - then: {replace: [{field: category, pattern: x}]}\n"},
+		{name: "single split", rules: "rules:\n  - then: {split: [{category: A}]}\n"},
+		{name: "two remainders", rules: "rules:\n  - then: {split: [{category: A}, {category: B}]}\n"},
+		{name: "percentages short of 100", rules: "rules:\n  - then: {split: [{percent: 50}, {percent: 40}]}\n"},
+		{name: "amount and percent", rules: "rules:\n  - then: {split: [{amount: 10, percent: 50}, {category: B}]}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.rules)); err == nil {
+				t.Errorf("Parse() = nil, want error")
+			}
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	rules, err := Parse([]byte(`{"rules": [{"if": {"payee": "Rema"}, "then": {"category": "Groceries"}}]}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	got, applied, _ := rules.Apply(ynabber.Transaction{Payee: "Rema 1000"})
+	if got.Category != "Groceries" || !slices.Equal(applied, []string{"rule 1"}) {
+		t.Errorf("Apply() = %+v, %v", got, applied)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(testRules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	transformer := Transformer{
+		Rules:  rules,
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	batch := []ynabber.Transaction{
+		{ID: "kept", Payee: "Refund", Amount: 1000},
+		{ID: "skipped", Counterparty: ynabber.Counterparty{Name: "Me"}},
+	}
+	got, err := transformer.Transform(context.Background(), batch)
+	if err != nil {
+		t.Fatalf("Transform() error = %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "kept" || got[0].Amount != -1000 {
+		t.Errorf("Transform() = %+v, want only the negated refund", got)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	rules, err := Parse([]byte(`
+rules:
+  - name: mortgage
+    if: {payee: Mortgage}
+    then:
+      split:
+        - amount: 3000
+          category: Interest
+          memo: Interest
+        - category: Principal
+  - name: payroll
+    if: {payee: Payroll}
+    then:
+      split:
+        - percent: 95
+          category: Salary
+        - percent: 5
+          category: Pension
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		in   ynabber.Transaction
+		want []ynabber.Split
+	}{
+		{
+			name: "fixed and remainder",
+			in:   ynabber.Transaction{Payee: "Mortgage", Amount: -10000000},
+			want: []ynabber.Split{
+				{Amount: -3000000, Category: "Interest", Memo: "Interest"},
+				{Amount: -7000000, Category: "Principal"},
+			},
+		},
+		{
+			name: "percentages rounded to cents",
+			in:   ynabber.Transaction{Payee: "Payroll", Amount: 33333330},
+			want: []ynabber.Split{
+				{Amount: 31666660, Category: "Salary"},
+				{Amount: 1666670, Category: "Pension"},
+			},
+		},
+		{
+			name: "fixed exceeding amount",
+			in:   ynabber.Transaction{Payee: "Mortgage", Amount: -2000000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, _ := rules.Apply(tt.in)
+			if diff := cmp.Diff(tt.want, got.Splits); diff != "" {
+				t.Errorf("splits mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestNegateSplits checks that splits still add up to the amount however
+// splitting and negating are ordered, within the rules or with the swapflow
+// transformer.
+func TestNegateSplits(t *testing.T) {
+	parse := func(yaml string) Transformer {
+		rules, err := Parse([]byte(yaml))
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		return Transformer{Rules: rules, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	}
+	split := `
+  - name: split
+    if: {payee: Mortgage}
+    then:
+      split:
+        - amount: 3000
+          category: Interest
+        - category: Principal
+`
+	negate := `
+  - name: negate
+    if: {payee: Mortgage}
+    then: {negate: true}
+`
+	swap := swapflow.Transformer{Config: swapflow.Config{Accounts: []string{"loan"}}}
+
+	tests := []struct {
+		name         string
+		transformers []ynabber.Transformer
+		in           ynabber.Milliunits
+	}{
+		{name: "split then negate", transformers: []ynabber.Transformer{parse("rules:" + split + negate)}, in: 10000000},
+		{name: "negate then split", transformers: []ynabber.Transformer{parse("rules:" + negate + split)}, in: 10000000},
+		{name: "rules then swapflow", transformers: []ynabber.Transformer{parse("rules:" + split), swap}, in: 10000000},
+		{name: "swapflow then rules", transformers: []ynabber.Transformer{swap, parse("rules:" + split)}, in: 10000000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := []ynabber.Transaction{{Account: ynabber.Account{IBAN: "loan"}, Payee: "Mortgage", Amount: tt.in}}
+			for _, transformer := range tt.transformers {
+				var err error
+				if batch, err = transformer.Transform(context.Background(), batch); err != nil {
+					t.Fatalf("Transform() error = %v", err)
+				}
+			}
+			got := batch[0]
+			want := []ynabber.Split{
+				{Amount: -3000000, Category: "Interest"},
+				{Amount: -7000000, Category: "Principal"},
+			}
+			if got.Amount != -10000000 {
+				t.Errorf("amount = %d, want -10000000", got.Amount)
+			}
+			if diff := cmp.Diff(want, got.Splits); diff != "" {
+				t.Errorf("splits mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
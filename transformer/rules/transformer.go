@@ -0,0 +1,68 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// Transformer applies the rules of a rules file to transactions.
+type Transformer struct {
+	Config Config
+	Rules  Rules
+	logger *slog.Logger
+}
+
+func init() {
+	ynabber.RegisterTransformer("rules", func(ynabber.Options) (ynabber.Transformer, error) {
+		return NewTransformer()
+	}, &Config{})
+}
+
+// NewTransformer returns a new rules transformer with the rules of the rules
+// file.
+func NewTransformer() (Transformer, error) {
+	cfg := Config{}
+	if err := ynabber.ProcessEnv("", &cfg); err != nil {
+		return Transformer{}, fmt.Errorf("processing config: %w", err)
+	}
+	if cfg.File == "" {
+		return Transformer{}, errors.New("RULES_FILE is required")
+	}
+	rules, err := Load(cfg.File)
+	if err != nil {
+		return Transformer{}, err
+	}
+
+	logger := slog.Default().With("transformer", "rules")
+	logger.Debug("rules loaded", "file", cfg.File, "rules", len(rules))
+	return Transformer{
+		Config: cfg,
+		Rules:  rules,
+		logger: logger,
+	}, nil
+}
+
+// String returns the name of the transformer
+func (t Transformer) String() string {
+	return "rules"
+}
+
+// Transform applies the rules to every transaction in batch. Transactions a
+// rule skips are left out.
+func (t Transformer) Transform(_ context.Context, batch []ynabber.Transaction) ([]ynabber.Transaction, error) {
+	out := make([]ynabber.Transaction, 0, len(batch))
+	for _, tx := range batch {
+		result, applied, keep := t.Rules.Apply(tx)
+		if len(applied) > 0 {
+			t.logger.Debug("applied rules", "transaction", tx.ID, "rules", applied, "skipped", !keep)
+		}
+		if keep {
+			out = append(out, result)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,61 @@
+// Strip cleans up payee and memo text for every reader. It removes configured
+// words and regular expression matches, collapses repeated whitespace and trims
+// the result, so the same cleanup rules apply to every reader and writer.
+package strip
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Regex is a list of regular expressions. It implements envconfig.Decoder,
+// parsing a comma-separated list of patterns and compiling each one. Patterns
+// themselves cannot contain a literal comma.
+type Regex []*regexp.Regexp
+
+// Decode parses value as a comma-separated list of regex patterns.
+func (r *Regex) Decode(value string) error {
+	if value == "" {
+		return nil
+	}
+	for pattern := range strings.SplitSeq(value, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid regex %q: %w", pattern, err)
+		}
+		*r = append(*r, re)
+	}
+	return nil
+}
+
+// String returns the source patterns joined by commas.
+func (r Regex) String() string {
+	parts := make([]string, len(r))
+	for i, re := range r {
+		parts[i] = re.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+type Config struct {
+	// Payee contains words to remove from payee names.
+	// Example: "foo,bar" removes "foo" and "bar" from all payee names.
+	Payee []string `envconfig:"STRIP_PAYEE"`
+
+	// PayeeRegex is a comma-separated list of regular expressions whose
+	// matches are removed from payee names. Patterns cannot contain a literal
+	// comma.
+	// Example: "^Dk-Nota\S+\s+" turns "Dk-Nota61221 Remouladen" into
+	// "Remouladen".
+	PayeeRegex Regex `envconfig:"STRIP_PAYEE_REGEX"`
+
+	// MemoRegex is a comma-separated list of regular expressions whose
+	// matches are removed from memos. Patterns cannot contain a literal
+	// comma.
+	MemoRegex Regex `envconfig:"STRIP_MEMO_REGEX"`
+}
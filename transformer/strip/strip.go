@@ -0,0 +1,70 @@
+package strip
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/martinohansen/ynabber"
+)
+
+var space = regexp.MustCompile(`\s+`) // Matches all whitespace characters
+
+// Transformer strips unwanted text from payees and memos.
+type Transformer struct {
+	Config Config
+	logger *slog.Logger
+}
+
+func init() {
+	ynabber.RegisterTransformer("strip", func(ynabber.Options) (ynabber.Transformer, error) {
+		return NewTransformer()
+	}, &Config{})
+}
+
+// NewTransformer returns a new strip transformer
+func NewTransformer() (Transformer, error) {
+	cfg := Config{}
+	if err := ynabber.ProcessEnv("", &cfg); err != nil {
+		return Transformer{}, fmt.Errorf("processing config: %w", err)
+	}
+
+	return Transformer{
+		Config: cfg,
+		logger: slog.Default().With("transformer", "strip"),
+	}, nil
+}
+
+// String returns the name of the transformer
+func (t Transformer) String() string {
+	return "strip"
+}
+
+// Transform strips payee and memo of every transaction in batch.
+func (t Transformer) Transform(_ context.Context, batch []ynabber.Transaction) ([]ynabber.Transaction, error) {
+	out := make([]ynabber.Transaction, len(batch))
+	for i, tx := range batch {
+		tx.Payee = clean(tx.Payee, t.Config.Payee, t.Config.PayeeRegex)
+		tx.Memo = clean(tx.Memo, nil, t.Config.MemoRegex)
+		if tx.Payee != batch[i].Payee || tx.Memo != batch[i].Memo {
+			t.logger.Debug("stripped transaction", "from", batch[i], "to", tx)
+		}
+		out[i] = tx
+	}
+	return out, nil
+}
+
+// clean removes each word in words and every match of each pattern in regexes
+// from s. Consecutive whitespace is collapsed into a single space and the
+// result is trimmed.
+func clean(s string, words []string, regexes Regex) string {
+	for _, word := range words {
+		s = strings.ReplaceAll(s, word, "")
+	}
+	for _, re := range regexes {
+		s = re.ReplaceAllString(s, "")
+	}
+	return strings.TrimSpace(space.ReplaceAllString(s, " "))
+}
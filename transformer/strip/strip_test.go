@@ -0,0 +1,85 @@
+package strip
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/martinohansen/ynabber"
+)
+
+func TestTransform(t *testing.T) {
+	var payeeRegex, memoRegex Regex
+	if err := payeeRegex.Decode(`^Dk-Nota\S+\s+`); err != nil {
+		t.Fatal(err)
+	}
+	if err := memoRegex.Decode(`\d{4}\*+\d{4}`); err != nil {
+		t.Fatal(err)
+	}
+
+	transformer := Transformer{
+		Config: Config{
+			Payee:      []string{"VISA"},
+			PayeeRegex: payeeRegex,
+			MemoRegex:  memoRegex,
+		},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	tests := []struct {
+		name      string
+		in        ynabber.Transaction
+		wantPayee string
+		wantMemo  string
+	}{
+		{
+			name:      "regex prefix",
+			in:        ynabber.Transaction{Payee: "Dk-Nota61221 Remouladen", Memo: "Dk-Nota61221 Remouladen"},
+			wantPayee: "Remouladen",
+			wantMemo:  "Dk-Nota61221 Remouladen",
+		},
+		{
+			name:      "word and whitespace",
+			in:        ynabber.Transaction{Payee: "  VISA   Coffee \n Shop ", Memo: "Card 1234****5678   purchase"},
+			wantPayee: "Coffee Shop",
+			wantMemo:  "Card purchase",
+		},
+		{
+			name:      "untouched",
+			in:        ynabber.Transaction{Payee: "Grocer", Memo: "Weekly groceries"},
+			wantPayee: "Grocer",
+			wantMemo:  "Weekly groceries",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformer.Transform(context.Background(), []ynabber.Transaction{tt.in})
+			if err != nil {
+				t.Fatalf("Transform() error = %v", err)
+			}
+			if got[0].Payee != tt.wantPayee {
+				t.Errorf("payee = %q, want %q", got[0].Payee, tt.wantPayee)
+			}
+			if got[0].Memo != tt.wantMemo {
+				t.Errorf("memo = %q, want %q", got[0].Memo, tt.wantMemo)
+			}
+		})
+	}
+}
+
+func TestRegexDecode(t *testing.T) {
+	var r Regex
+	if err := r.Decode("foo, ,bar+"); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got, want := r.String(), "foo,bar+"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	var invalid Regex
+	if err := invalid.Decode("("); err == nil {
+		t.Error("Decode() error = nil, want error for invalid pattern")
+	}
+}
@@ -0,0 +1,28 @@
+// SwapFlow reverses inflow to outflow and vice versa for selected accounts.
+// This is useful for credit cards where the bank reports purchases as positive
+// amounts.
+package swapflow
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/martinohansen/ynabber"
+)
+
+type Config struct {
+	// Accounts to swap inflow and outflow for, identified by IBAN or ID.
+	// Example: "DK9520000123456789,NO8330001234567"
+	Accounts []string `envconfig:"SWAPFLOW_ACCOUNTS"`
+}
+
+// Check implements ynabber.Checker.
+func (c *Config) Check() error {
+	var errs []error
+	for _, account := range c.Accounts {
+		if err := ynabber.CheckAccount(account); err != nil {
+			errs = append(errs, fmt.Errorf("SWAPFLOW_ACCOUNTS: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,69 @@
+package swapflow
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// Transformer negates the amount of transactions on configured accounts.
+type Transformer struct {
+	Config Config
+	logger *slog.Logger
+}
+
+func init() {
+	ynabber.RegisterTransformer("swapflow", func(ynabber.Options) (ynabber.Transformer, error) {
+		return NewTransformer()
+	}, &Config{})
+}
+
+// NewTransformer returns a new swapflow transformer
+func NewTransformer() (Transformer, error) {
+	cfg := Config{}
+	if err := ynabber.ProcessEnv("", &cfg); err != nil {
+		return Transformer{}, fmt.Errorf("processing config: %w", err)
+	}
+
+	return Transformer{
+		Config: cfg,
+		logger: slog.Default().With("transformer", "swapflow"),
+	}, nil
+}
+
+// String returns the name of the transformer
+func (t Transformer) String() string {
+	return "swapflow"
+}
+
+// Transform negates the amount, and the amounts of the splits, of every
+// transaction in batch whose account is matched by IBAN or ID in
+// Config.Accounts.
+func (t Transformer) Transform(_ context.Context, batch []ynabber.Transaction) ([]ynabber.Transaction, error) {
+	out := make([]ynabber.Transaction, len(batch))
+	for i, tx := range batch {
+		if t.swap(tx.Account) {
+			tx = tx.Negate()
+		}
+		out[i] = tx
+	}
+	return out, nil
+}
+
+// TransformBalance implements ynabber.BalanceTransformer. It negates the
+// balance of accounts in Config.Accounts, like their transactions.
+func (t Transformer) TransformBalance(balance ynabber.Balance) ynabber.Balance {
+	if t.swap(balance.Account) {
+		balance.Amount = balance.Amount.Negate()
+	}
+	return balance
+}
+
+func (t Transformer) swap(account ynabber.Account) bool {
+	return slices.ContainsFunc(t.Config.Accounts, func(a string) bool {
+		return a != "" && (a == account.IBAN || a == string(account.ID))
+	})
+}
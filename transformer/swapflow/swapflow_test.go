@@ -0,0 +1,76 @@
+package swapflow
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/martinohansen/ynabber"
+)
+
+func TestTransform(t *testing.T) {
+	transformer := Transformer{
+		Config: Config{Accounts: []string{"NO8330001234567", "card-uid"}},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	batch := []ynabber.Transaction{
+		{Account: ynabber.Account{IBAN: "NO8330001234567"}, Amount: 1000},
+		{Account: ynabber.Account{ID: "card-uid"}, Amount: -2000},
+		{Account: ynabber.Account{IBAN: "DK9520000123456789"}, Amount: 3000},
+	}
+
+	got, err := transformer.Transform(context.Background(), batch)
+	if err != nil {
+		t.Fatalf("Transform() error = %v", err)
+	}
+
+	want := []ynabber.Milliunits{-1000, 2000, 3000}
+	for i, tx := range got {
+		if tx.Amount != want[i] {
+			t.Errorf("transaction %d amount = %d, want %d", i, tx.Amount, want[i])
+		}
+	}
+
+	// The input batch must be left untouched.
+	if batch[0].Amount != 1000 {
+		t.Errorf("Transform() modified its input")
+	}
+}
+
+func TestTransformSplits(t *testing.T) {
+	transformer := Transformer{Config: Config{Accounts: []string{"loan"}}}
+	batch := []ynabber.Transaction{{
+		Account: ynabber.Account{IBAN: "loan"},
+		Amount:  10000,
+		Splits:  []ynabber.Split{{Amount: 3000, Category: "Interest"}, {Amount: 7000, Category: "Principal"}},
+	}}
+
+	got, err := transformer.Transform(context.Background(), batch)
+	if err != nil {
+		t.Fatalf("Transform() error = %v", err)
+	}
+	if got[0].Amount != -10000 || got[0].Splits[0].Amount != -3000 || got[0].Splits[1].Amount != -7000 {
+		t.Errorf("Transform() = %+v, want the amount and the splits negated", got[0])
+	}
+	if batch[0].Splits[0].Amount != 3000 {
+		t.Errorf("Transform() modified the splits of its input")
+	}
+}
+
+func TestTransformBalance(t *testing.T) {
+	transformer := Transformer{Config: Config{Accounts: []string{"card-uid"}}}
+	for _, tt := range []struct {
+		account ynabber.Account
+		want    ynabber.Milliunits
+	}{
+		{account: ynabber.Account{ID: "card-uid"}, want: 5000},
+		{account: ynabber.Account{IBAN: "NO8330001234567"}, want: -5000},
+	} {
+		got := transformer.TransformBalance(ynabber.Balance{Account: tt.account, Amount: -5000})
+		if got.Amount != tt.want {
+			t.Errorf("TransformBalance(%+v) = %d, want %d", tt.account, got.Amount, tt.want)
+		}
+	}
+}
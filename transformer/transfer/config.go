@@ -0,0 +1,36 @@
+// Transfer recognizes transfers between your own accounts, so writers can
+// record them as transfers instead of as spending and income with two
+// different payees.
+package transfer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/martinohansen/ynabber"
+)
+
+type Config struct {
+	// Accounts lists your own accounts, by IBAN, in addition to the accounts
+	// Ynabber reads. List accounts at banks Ynabber doesn't read from so
+	// transfers to them are recognized too.
+	// Example: "NO8330001234567,DK9520000123456789"
+	Accounts []string `envconfig:"TRANSFER_ACCOUNTS"`
+
+	// Days is how many days apart the two sides of a transfer may be booked.
+	Days int `envconfig:"TRANSFER_DAYS" default:"3"`
+}
+
+// Check implements ynabber.Checker.
+func (c *Config) Check() error {
+	var errs []error
+	for _, account := range c.Accounts {
+		if err := ynabber.CheckAccount(account); err != nil {
+			errs = append(errs, fmt.Errorf("TRANSFER_ACCOUNTS: %w", err))
+		}
+	}
+	if c.Days < 0 {
+		errs = append(errs, errors.New("TRANSFER_DAYS: must not be negative"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,119 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// Transformer marks transactions moving money between your own accounts as
+// transfers.
+type Transformer struct {
+	Config Config
+	logger *slog.Logger
+	// accounts remembers every account seen in a batch, by IBAN, so
+	// transfers are recognized when the two sides come from different
+	// readers.
+	accounts *sync.Map
+}
+
+func init() {
+	ynabber.RegisterTransformer("transfer", func(ynabber.Options) (ynabber.Transformer, error) {
+		return NewTransformer()
+	}, &Config{})
+}
+
+// NewTransformer returns a new transfer transformer
+func NewTransformer() (Transformer, error) {
+	cfg := Config{}
+	if err := ynabber.ProcessEnv("", &cfg); err != nil {
+		return Transformer{}, fmt.Errorf("processing config: %w", err)
+	}
+
+	t := Transformer{
+		Config:   cfg,
+		logger:   slog.Default().With("transformer", "transfer"),
+		accounts: &sync.Map{},
+	}
+	for _, iban := range cfg.Accounts {
+		t.accounts.Store(iban, ynabber.Account{IBAN: iban})
+	}
+	return t, nil
+}
+
+// String returns the name of the transformer
+func (t Transformer) String() string {
+	return "transfer"
+}
+
+// Transform sets Transfer on every transaction in batch whose counterparty
+// IBAN is another of your own accounts. The other side of such a transfer,
+// with the opposite amount in that account and booked at most Config.Days
+// apart, is marked too, even if its bank doesn't report the counterparty.
+func (t Transformer) Transform(_ context.Context, batch []ynabber.Transaction) ([]ynabber.Transaction, error) {
+	for _, tx := range batch {
+		if tx.Account.IBAN != "" {
+			t.accounts.Store(tx.Account.IBAN, tx.Account)
+		}
+	}
+
+	out := make([]ynabber.Transaction, len(batch))
+	copy(out, batch)
+	for i, tx := range out {
+		if tx.IsTransfer() {
+			continue
+		}
+		other, ok := t.own(tx.Counterparty.IBAN)
+		if !ok || other.IBAN == tx.Account.IBAN {
+			continue
+		}
+		out[i].Transfer = other
+		t.logger.Debug("found transfer", "transaction", tx.ID, "to", other.IBAN)
+
+		if j, ok := t.otherSide(out, i, other); ok {
+			out[j].Transfer = tx.Account
+			t.logger.Debug("found transfer", "transaction", out[j].ID, "to", tx.Account.IBAN)
+		}
+	}
+	return out, nil
+}
+
+// own returns your own account with iban.
+func (t Transformer) own(iban string) (ynabber.Account, bool) {
+	if iban == "" {
+		return ynabber.Account{}, false
+	}
+	account, ok := t.accounts.Load(iban)
+	if !ok {
+		return ynabber.Account{}, false
+	}
+	return account.(ynabber.Account), true
+}
+
+// otherSide returns the index of the transaction in batch that is the other
+// side of the transfer batch[i] to account, if any. The closest in date wins.
+func (t Transformer) otherSide(batch []ynabber.Transaction, i int, account ynabber.Account) (int, bool) {
+	from := batch[i]
+	window := time.Duration(t.Config.Days) * 24 * time.Hour
+	best, found := 0, false
+	for j, to := range batch {
+		if j == i || to.IsTransfer() || to.Account.IBAN != account.IBAN || to.Amount != from.Amount.Negate() {
+			continue
+		}
+		if iban := to.Counterparty.IBAN; iban != "" && iban != from.Account.IBAN {
+			continue
+		}
+		gap := to.Date.Sub(from.Date).Abs()
+		if gap > window {
+			continue
+		}
+		if !found || gap < batch[best].Date.Sub(from.Date).Abs() {
+			best, found = j, true
+		}
+	}
+	return best, found
+}
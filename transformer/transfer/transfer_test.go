@@ -0,0 +1,109 @@
+package transfer
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+func TestTransform(t *testing.T) {
+	checking := ynabber.Account{IBAN: "NO9386011117947"}
+	savings := ynabber.Account{IBAN: "NO8330001234567"}
+	elsewhere := ynabber.Account{IBAN: "DK9520000123456789"}
+	day := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		batch []ynabber.Transaction
+		want  []ynabber.Account // Transfer of every transaction
+	}{
+		{
+			name: "both sides",
+			batch: []ynabber.Transaction{
+				{Account: checking, Date: day, Amount: -5000, Counterparty: ynabber.Counterparty{IBAN: savings.IBAN}},
+				{Account: savings, Date: day.AddDate(0, 0, 1), Amount: 5000},
+			},
+			want: []ynabber.Account{savings, checking},
+		},
+		{
+			name: "configured account",
+			batch: []ynabber.Transaction{
+				{Account: checking, Date: day, Amount: -5000, Counterparty: ynabber.Counterparty{IBAN: elsewhere.IBAN}},
+			},
+			want: []ynabber.Account{elsewhere},
+		},
+		{
+			name: "other side too late",
+			batch: []ynabber.Transaction{
+				{Account: checking, Date: day, Amount: -5000, Counterparty: ynabber.Counterparty{IBAN: savings.IBAN}},
+				{Account: savings, Date: day.AddDate(0, 0, 4), Amount: 5000},
+			},
+			want: []ynabber.Account{savings, {}},
+		},
+		{
+			name: "other side different amount",
+			batch: []ynabber.Transaction{
+				{Account: checking, Date: day, Amount: -5000, Counterparty: ynabber.Counterparty{IBAN: savings.IBAN}},
+				{Account: savings, Date: day, Amount: 4000},
+			},
+			want: []ynabber.Account{savings, {}},
+		},
+		{
+			name: "unknown counterparty",
+			batch: []ynabber.Transaction{
+				{Account: checking, Date: day, Amount: -5000, Counterparty: ynabber.Counterparty{IBAN: "GB82WEST12345698765432"}},
+				{Account: savings, Date: day, Amount: 5000},
+			},
+			want: []ynabber.Account{{}, {}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transformer := Transformer{
+				Config:   Config{Days: 3},
+				logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+				accounts: &sync.Map{},
+			}
+			transformer.accounts.Store(elsewhere.IBAN, elsewhere)
+
+			got, err := transformer.Transform(context.Background(), tt.batch)
+			if err != nil {
+				t.Fatalf("Transform() error = %v", err)
+			}
+			for i, tx := range got {
+				if tx.Transfer != tt.want[i] {
+					t.Errorf("transaction %d transfer = %+v, want %+v", i, tx.Transfer, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTransformRemembersAccounts(t *testing.T) {
+	checking := ynabber.Account{IBAN: "NO9386011117947"}
+	savings := ynabber.Account{IBAN: "NO8330001234567"}
+	transformer := Transformer{
+		Config:   Config{Days: 3},
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		accounts: &sync.Map{},
+	}
+
+	// The savings account is read by another reader, in an earlier batch
+	if _, err := transformer.Transform(context.Background(), []ynabber.Transaction{{Account: savings}}); err != nil {
+		t.Fatalf("Transform() error = %v", err)
+	}
+	got, err := transformer.Transform(context.Background(), []ynabber.Transaction{
+		{Account: checking, Amount: -5000, Counterparty: ynabber.Counterparty{IBAN: savings.IBAN}},
+	})
+	if err != nil {
+		t.Fatalf("Transform() error = %v", err)
+	}
+	if got[0].Transfer != savings {
+		t.Errorf("transfer = %+v, want %+v", got[0].Transfer, savings)
+	}
+}
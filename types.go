@@ -90,6 +90,28 @@ func MilliunitsFromString(s string) (Milliunits, error) {
 	return Milliunits(result), nil
 }
 
+// Status is the booking status of a transaction.
+type Status string
+
+const (
+	StatusBooked  Status = "booked"
+	StatusPending Status = "pending"
+)
+
+// Counterparty is the other party of a transaction, the creditor of money
+// going out or the debtor of money coming in.
+type Counterparty struct {
+	Name string `json:"name,omitempty"`
+	IBAN string `json:"iban,omitempty"`
+}
+
+// Split is a part of a split transaction.
+type Split struct {
+	Amount   Milliunits `json:"amount"`
+	Category string     `json:"category,omitempty"`
+	Memo     string     `json:"memo,omitempty"`
+}
+
 // Transaction represents a financial transaction
 type Transaction struct {
 	Account Account `json:"account"`
@@ -99,4 +121,59 @@ type Transaction struct {
 	Payee  string     `json:"payee"`
 	Memo   string     `json:"memo"`
 	Amount Milliunits `json:"amount"`
+
+	// The fields below are optional and only set when the bank reports them.
+
+	// Status is the booking status. Readers that only return booked
+	// transactions may leave it empty.
+	Status Status `json:"status,omitempty"`
+	// Currency is the ISO 4217 code of the currency of Amount.
+	Currency string `json:"currency,omitempty"`
+	// ValueDate is the date the amount is available or starts to accrue
+	// interest, which can differ from Date.
+	ValueDate time.Time `json:"value_date,omitzero"`
+	// Counterparty is who the money was paid to or received from.
+	Counterparty Counterparty `json:"counterparty,omitzero"`
+	// MCC is the ISO 18245 merchant category code of card payments.
+	MCC string `json:"mcc,omitempty"`
+	// Reference is the reference the bank gave the transaction, as is. ID
+	// may be derived from it or from other fields.
+	Reference string `json:"reference,omitempty"`
+
+	// Category is the name of the budget category of the transaction. It is
+	// set by the category transformer.
+	Category string `json:"category,omitempty"`
+	// Flag is the color of the flag of the transaction, as YNAB names them:
+	// red, orange, yellow, green, blue or purple. It is set by the rules
+	// transformer.
+	Flag string `json:"flag,omitempty"`
+	// Splits divides the transaction into parts with their own category and
+	// memo. The amounts of the parts add up to Amount. It is set by the rules
+	// transformer.
+	Splits []Split `json:"splits,omitempty"`
+
+	// Transfer is the other of your own accounts when the transaction moves
+	// money between them. It is set by the transfer transformer.
+	Transfer Account `json:"transfer,omitzero"`
+}
+
+// Negate swaps inflow and outflow of t, changing the sign of its amount and
+// of the amounts of its splits so they still add up.
+func (t Transaction) Negate() Transaction {
+	t.Amount = t.Amount.Negate()
+	if t.Splits != nil {
+		splits := make([]Split, len(t.Splits))
+		for i, split := range t.Splits {
+			split.Amount = split.Amount.Negate()
+			splits[i] = split
+		}
+		t.Splits = splits
+	}
+	return t
+}
+
+// IsTransfer reports whether t moves money to or from another of your own
+// accounts.
+func (t Transaction) IsTransfer() bool {
+	return t.Transfer != Account{}
 }
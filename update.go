@@ -0,0 +1,114 @@
+package ynabber
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Updater is implemented by writers that can update the transactions they
+// wrote before. When the bank enriches a delivered transaction, changing its
+// payee or memo but keeping its ID, date and amount, Ynabber calls Update
+// instead of delivering it again, which the budget would ignore as a
+// duplicate. Updates need the ledger, which supplies the previous version.
+type Updater interface {
+	// Update changes the transactions written for the previous versions in
+	// enrichments to match their current versions. Writers decide per field,
+	// following their UpdatePolicy settings, whether to apply a change, so
+	// edits made in the budget are kept. A transaction that is no longer in
+	// the budget is not an error.
+	//
+	// Update receives every enriched transaction of a batch at once so
+	// writers can keep the number of requests down, which matters for
+	// budgets with rate limited APIs.
+	Update(ctx context.Context, enrichments []Enrichment) error
+}
+
+// Enrichment is a delivered transaction that the bank has enriched since.
+type Enrichment struct {
+	// Previous is the transaction as it was delivered, from the ledger.
+	Previous Transaction
+	// Current is the transaction as the bank returns it now.
+	Current Transaction
+}
+
+// UpdatePolicy decides whether a writer updates a field of a transaction it
+// wrote before when the bank changes it.
+type UpdatePolicy string
+
+const (
+	// UpdateNever keeps the field as it was first written.
+	UpdateNever UpdatePolicy = "never"
+	// UpdateUnedited updates the field unless it was edited in the budget
+	// since it was written.
+	UpdateUnedited UpdatePolicy = "unedited"
+	// UpdateAlways overwrites the field, discarding edits made in the budget.
+	UpdateAlways UpdatePolicy = "always"
+)
+
+// Decode implements envconfig.Decoder for UpdatePolicy.
+func (p *UpdatePolicy) Decode(value string) error {
+	lowered := UpdatePolicy(strings.ToLower(value))
+	switch lowered {
+	case UpdateNever, UpdateUnedited, UpdateAlways:
+		*p = lowered
+		return nil
+	default:
+		return fmt.Errorf("unknown update policy %q, want never, unedited or always", value)
+	}
+}
+
+// Allows reports whether a field that was written as written and now holds
+// budget may be overwritten.
+func (p UpdatePolicy) Allows(written, budget string) bool {
+	switch p {
+	case UpdateAlways:
+		return true
+	case UpdateUnedited:
+		return written == budget
+	default:
+		return false
+	}
+}
+
+// enriched reports whether current differs from previous only in fields
+// writers can update in place. A changed date or amount yields a new import
+// ID, so such transactions are delivered again instead.
+func enriched(previous, current Transaction) bool {
+	return previous.Date.Format(time.DateOnly) == current.Date.Format(time.DateOnly) &&
+		previous.Amount == current.Amount
+}
+
+// updateEnriched updates the transactions in batch that were delivered to
+// writer before and have been enriched since, and returns the rest. Failures
+// are logged and retried with the next batch.
+func (y *Ynabber) updateEnriched(ctx context.Context, logger *slog.Logger, writer Writer, updater Updater, batch []Transaction) []Transaction {
+	name := writer.String()
+	remaining := make([]Transaction, 0, len(batch))
+	var enrichments []Enrichment
+	var updated []Transaction
+	for _, t := range batch {
+		previous, ok := y.Ledger.Previous(name, t)
+		if !ok || !enriched(previous, t) {
+			remaining = append(remaining, t)
+			continue
+		}
+		enrichments = append(enrichments, Enrichment{Previous: previous, Current: t})
+		updated = append(updated, t)
+	}
+	if len(enrichments) == 0 {
+		return remaining
+	}
+
+	if err := updater.Update(ctx, enrichments); err != nil {
+		logger.Error("updating enriched transactions", "count", len(enrichments), "error", err)
+		return remaining
+	}
+	logger.Info("updated enriched transactions", "count", len(enrichments))
+	if err := y.Ledger.Record(name, updated); err != nil {
+		logger.Error("recording updates in ledger", "error", err)
+	}
+	return remaining
+}
@@ -0,0 +1,99 @@
+package ynabber
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdatePolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		written string
+		budget  string
+		want    bool
+		wantErr bool
+	}{
+		{value: "never", written: "a", budget: "a", want: false},
+		{value: "unedited", written: "a", budget: "a", want: true},
+		{value: "Unedited", written: "a", budget: "b", want: false},
+		{value: "always", written: "a", budget: "b", want: true},
+		{value: "sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var p UpdatePolicy
+			err := p.Decode(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := p.Allows(tt.written, tt.budget); got != tt.want {
+				t.Errorf("Allows(%q, %q) = %v, want %v", tt.written, tt.budget, got, tt.want)
+			}
+		})
+	}
+}
+
+// Mock writer that delivers every transaction and records updates
+type mockUpdater struct {
+	mockDeliverer
+	updated [][2]Transaction
+	mu      sync.Mutex
+}
+
+func (w *mockUpdater) Update(ctx context.Context, enrichments []Enrichment) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, e := range enrichments {
+		w.updated = append(w.updated, [2]Transaction{e.Previous, e.Current})
+	}
+	return nil
+}
+
+func TestEnrichedTransactionsAreUpdated(t *testing.T) {
+	ledger, err := OpenLedger(filepath.Join(t.TempDir(), "ledger.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delivered := Transaction{
+		Account: Account{IBAN: "NO1"},
+		ID:      "tx-1",
+		Date:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Memo:    "VISA",
+		Amount:  -1000,
+	}
+	enriched := delivered
+	enriched.Memo = "VISA 1234 Grocer"
+	rebooked := delivered
+	rebooked.ID, rebooked.Amount = "tx-2", -2000
+	if err := ledger.Record("mock-writer", []Transaction{delivered, rebooked}); err != nil {
+		t.Fatal(err)
+	}
+	rebooked.Amount = -2500
+
+	writer := &mockUpdater{}
+	y := &Ynabber{
+		Readers: []Reader{&mockOneShotReader{data: []Transaction{enriched, rebooked}}},
+		Writers: []Writer{writer},
+		Ledger:  ledger,
+		logger:  *slog.Default(),
+	}
+	if err := y.Run(); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	if len(writer.updated) != 1 || writer.updated[0][0].Memo != "VISA" || writer.updated[0][1].Memo != "VISA 1234 Grocer" {
+		t.Errorf("updated %+v, want tx-1 updated from its delivered version", writer.updated)
+	}
+	batches := writer.getBatches()
+	if len(batches) != 1 || len(batches[0]) != 1 || batches[0][0].ID != "tx-2" {
+		t.Errorf("writer received %+v, want only tx-2 whose amount changed", batches)
+	}
+	if !ledger.Delivered("mock-writer", enriched) {
+		t.Error("updated transaction was not recorded in the ledger")
+	}
+}
@@ -0,0 +1,46 @@
+package ynabber
+
+import "time"
+
+// Window is a range of dates, including both From and To. Only the date part
+// of each time is used.
+type Window struct {
+	From time.Time
+	To   time.Time
+}
+
+// IsZero reports whether the window is unset.
+func (w Window) IsZero() bool {
+	return w.From.IsZero() && w.To.IsZero()
+}
+
+// Contains reports whether the date of t falls within w.
+func (w Window) Contains(t time.Time) bool {
+	d := dateOf(t)
+	return !d.Before(dateOf(w.From)) && !d.After(dateOf(w.To))
+}
+
+// Split divides w into consecutive windows of at most days days each, so a
+// long backfill can be fetched in pieces the bank accepts.
+func (w Window) Split(days int) []Window {
+	from, to := dateOf(w.From), dateOf(w.To)
+	if days <= 0 || to.Before(from) {
+		return []Window{w}
+	}
+
+	var windows []Window
+	for start := from; !start.After(to); start = start.AddDate(0, 0, days) {
+		end := start.AddDate(0, 0, days-1)
+		if end.After(to) {
+			end = to
+		}
+		windows = append(windows, Window{From: start, To: end})
+	}
+	return windows
+}
+
+// dateOf truncates t to midnight UTC of its date.
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,77 @@
+package ynabber
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestWindowSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		window Window
+		days   int
+		want   []Window
+	}{
+		{
+			name:   "fits",
+			window: Window{From: date("2024-01-01"), To: date("2024-01-31")},
+			days:   90,
+			want:   []Window{{From: date("2024-01-01"), To: date("2024-01-31")}},
+		},
+		{
+			name:   "split",
+			window: Window{From: date("2024-01-01"), To: date("2024-01-10")},
+			days:   4,
+			want: []Window{
+				{From: date("2024-01-01"), To: date("2024-01-04")},
+				{From: date("2024-01-05"), To: date("2024-01-08")},
+				{From: date("2024-01-09"), To: date("2024-01-10")},
+			},
+		},
+		{
+			name:   "single day",
+			window: Window{From: date("2024-01-01"), To: date("2024-01-01")},
+			days:   1,
+			want:   []Window{{From: date("2024-01-01"), To: date("2024-01-01")}},
+		},
+		{
+			name:   "time of day is ignored",
+			window: Window{From: date("2024-01-01").Add(15 * time.Hour), To: date("2024-01-02").Add(time.Hour)},
+			days:   1,
+			want: []Window{
+				{From: date("2024-01-01"), To: date("2024-01-01")},
+				{From: date("2024-01-02"), To: date("2024-01-02")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Split(tt.days); !slices.Equal(got, tt.want) {
+				t.Errorf("Split() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	w := Window{From: date("2024-01-01"), To: date("2024-01-31")}
+	for day, want := range map[string]bool{
+		"2023-12-31": false,
+		"2024-01-01": true,
+		"2024-01-31": true,
+		"2024-02-01": false,
+	} {
+		if got := w.Contains(date(day).Add(12 * time.Hour)); got != want {
+			t.Errorf("Contains(%s) = %v, want %v", day, got, want)
+		}
+	}
+}
@@ -13,8 +13,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/kelseyhightower/envconfig"
 	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/internal/metrics"
 	"github.com/martinohansen/ynabber/writer/actual/client"
 )
 
@@ -34,6 +34,8 @@ type Writer struct {
 	logger *slog.Logger
 	now    func() time.Time
 	client importer
+	// categories caches the category IDs of the budget.
+	categories *ynabber.CategoryCache
 }
 
 // String returns the name of the writer.
@@ -41,10 +43,16 @@ func (w Writer) String() string {
 	return "actual"
 }
 
+func init() {
+	ynabber.RegisterWriter("actual", func(ynabber.Options) (ynabber.Writer, error) {
+		return NewWriter()
+	}, &Config{})
+}
+
 // NewWriter returns a new Actual writer.
 func NewWriter() (Writer, error) {
 	cfg := Config{}
-	if err := envconfig.Process("", &cfg); err != nil {
+	if err := ynabber.ProcessEnv("", &cfg); err != nil {
 		return Writer{}, fmt.Errorf("processing config: %w", err)
 	}
 
@@ -59,29 +67,66 @@ func NewWriter() (Writer, error) {
 	}
 
 	logger := slog.Default().With("writer", "actual", "budget_id", cfg.BudgetID)
-	c := client.NewClient(cfg.BaseURL, cfg.APIKey, cfg.EncryptionPassword, &http.Client{Timeout: 30 * time.Second}, logger)
+	logger.Debug("config loaded", "config", &cfg)
+	c := client.NewClient(cfg.BaseURL, cfg.APIKey, cfg.EncryptionPassword, &http.Client{Timeout: 30 * time.Second, Transport: metrics.Transport("actual", nil)}, logger)
 
 	return Writer{
-		Config: cfg,
-		logger: logger,
-		now:    time.Now,
-		client: c,
+		Config:     cfg,
+		logger:     logger,
+		now:        time.Now,
+		client:     c,
+		categories: &ynabber.CategoryCache{},
 	}, nil
 }
 
 // Bulk sends a batch of transactions to Actual Budget, grouped by account.
 func (w Writer) Bulk(ctx context.Context, transactions []ynabber.Transaction) error {
+	_, err := w.Deliver(ctx, transactions)
+	return err
+}
+
+// Deliver sends a batch of transactions to Actual Budget, grouped by account,
+// and returns the transactions that were imported. Transactions filtered out
+// by date, that failed to map, or that belong to an account whose import
+// failed are left out. Categories are sent by ID, looked up by name, and left
+// out if the budget has no category of that name. Transfers between two Actual
+// accounts are imported with the transfer payee of the other account from the
+// sending side, and the receiving side is left to Actual when it creates it,
+// see settleTransfers.
+func (w Writer) Deliver(ctx context.Context, transactions []ynabber.Transaction) ([]ynabber.Transaction, error) {
 	if len(transactions) == 0 {
 		w.logger.Info("no transactions received")
-		return nil
+		return nil, nil
 	}
 
 	skipped := 0
 	failed := 0
 
-	grouped := make(map[string][]client.Transaction)
+	var transferPayees map[string]string
+	if ynabber.HasTransfers(transactions) {
+		var err error
+		if transferPayees, err = w.transferPayees(ctx); err != nil {
+			return nil, fmt.Errorf("listing payees for transfers: %w", err)
+		}
+	}
+	var categoryIDs ynabber.Categories
+	if ynabber.HasCategories(transactions) {
+		var err error
+		if categoryIDs, err = w.categoryIDs(ctx, transactions); err != nil {
+			return nil, fmt.Errorf("listing categories: %w", err)
+		}
+	}
 
-	for _, src := range transactions {
+	grouped := make(map[string][]client.Transaction)
+	sources := make(map[string][]ynabber.Transaction)
+	// unsent are delivered without being imported: the receiving sides of
+	// transfers, delivered by importing the other side
+	var unsent []ynabber.Transaction
+	// sending and receiving are the sides of transfers between two budget
+	// accounts, settled once the whole batch is mapped
+	var sending, receiving []transferSide
+
+	for i, src := range transactions {
 		if !w.isDateAllowed(src.Date) {
 			w.logger.Debug("date out of range", "transaction", src)
 			skipped++
@@ -97,12 +142,47 @@ func (w Writer) Bulk(ctx context.Context, transactions []ynabber.Transaction) er
 			continue
 		}
 
+		// Split transactions are categorized by their parts only
+		if len(src.Splits) == 0 {
+			payload.Category = w.categoryID(categoryIDs, src.Category, src)
+		}
+		for j, split := range src.Splits {
+			payload.Subtransactions[j].Category = w.categoryID(categoryIDs, split.Category, src)
+		}
+		if transferID, ok := w.transferAccount(src); ok {
+			side := transferSide{index: i, src: src, payload: payload, other: transferID}
+			if w.inflowSide(src) {
+				receiving = append(receiving, side)
+			} else {
+				sending = append(sending, side)
+			}
+			continue
+		}
+
 		grouped[accountID] = append(grouped[accountID], payload)
+		sources[accountID] = append(sources[accountID], src)
+	}
+	if len(sending) > 0 || len(receiving) > 0 {
+		imports, left, err := w.settleTransfers(ctx, sending, receiving, transferPayees)
+		if err != nil {
+			return nil, fmt.Errorf("settling transfers: %w", err)
+		}
+		for _, side := range imports {
+			grouped[side.payload.Account] = append(grouped[side.payload.Account], side.payload)
+			sources[side.payload.Account] = append(sources[side.payload.Account], side.src)
+		}
+		for _, side := range left {
+			unsent = append(unsent, side.src)
+		}
 	}
 
+	// A dry run imports nothing, so nothing counts as delivered.
+	if w.Config.DryRun {
+		unsent = nil
+	}
 	if len(grouped) == 0 {
-		w.logger.Info("all transactions filtered out", "skipped", skipped, "failed", failed)
-		return nil
+		w.logger.Info("all transactions filtered out", "skipped", skipped, "failed", failed, "transfers", len(unsent))
+		return unsent, nil
 	}
 
 	accountIDs := make([]string, 0, len(grouped))
@@ -119,6 +199,7 @@ func (w Writer) Bulk(ctx context.Context, transactions []ynabber.Transaction) er
 	submitted := 0
 	added := 0
 	updated := 0
+	delivered := unsent
 	var importErrors []error
 	for _, accountID := range accountIDs {
 		payloads := grouped[accountID]
@@ -130,9 +211,13 @@ func (w Writer) Bulk(ctx context.Context, transactions []ynabber.Transaction) er
 		submitted += len(payloads)
 		added += result.Added
 		updated += result.Updated
+		// A dry run imports nothing, so nothing counts as delivered.
+		if !opts.DryRun {
+			delivered = append(delivered, sources[accountID]...)
+		}
 	}
 	if len(importErrors) > 0 {
-		return fmt.Errorf("failed to import into %d Actual account(s): %w", len(importErrors), errors.Join(importErrors...))
+		return delivered, fmt.Errorf("failed to import into %d Actual account(s): %w", len(importErrors), errors.Join(importErrors...))
 	}
 
 	w.logger.Info(
@@ -144,7 +229,7 @@ func (w Writer) Bulk(ctx context.Context, transactions []ynabber.Transaction) er
 		"skipped", skipped,
 		"failed", failed,
 	)
-	return nil
+	return delivered, nil
 }
 
 // Runner reads batches of transactions from in and writes them using Bulk.
@@ -172,20 +257,32 @@ func (w Writer) Runner(ctx context.Context, in <-chan []ynabber.Transaction) err
 // future. Future dates are always rejected regardless of whether Delay is
 // configured.
 func (w Writer) isDateAllowed(date time.Time) bool {
+	return w.dateSkipReason(date) == ""
+}
+
+// dateSkipReason returns why isDateAllowed rejects date, or an empty string
+// if it is allowed.
+func (w Writer) dateSkipReason(date time.Time) string {
 	if date.IsZero() {
-		return false
+		return "no date"
 	}
 
 	now := w.now()
 	if !w.Config.FromDate.Time().IsZero() && date.Before(w.Config.FromDate.Time()) {
-		return false
+		return "before ACTUAL_FROM_DATE"
 	}
 
 	if date.After(now.Add(-w.Config.Delay)) {
-		return false
+		return "within ACTUAL_DELAY or in the future"
 	}
 
-	return true
+	return ""
+}
+
+// MapAccount implements ynabber.AccountMapper.
+func (w Writer) MapAccount(account ynabber.Account) (string, bool) {
+	id, err := accountParser(account, w.Config.AccountMap)
+	return id, err == nil
 }
 
 // accountParser takes an Account and returns the matching Actual account ID in
@@ -278,6 +375,17 @@ func (w Writer) toActual(src ynabber.Transaction) (client.Transaction, string, e
 		ImportedPayee: importedPayee,
 		ImportedID:    makeID(src),
 	}
+	for _, split := range src.Splits {
+		amount, err := toActualAmount(split.Amount)
+		if err != nil {
+			return client.Transaction{}, "", fmt.Errorf("split: %w", err)
+		}
+		payload.Subtransactions = append(payload.Subtransactions, client.Subtransaction{Amount: amount, Notes: split.Memo})
+	}
+	// Pending transactions are uncleared until they are promoted
+	if src.Status == ynabber.StatusPending {
+		payload.Cleared = new(false)
+	}
 
 	w.logger.Debug("mapped transaction", "from", src, "to", payload)
 	return payload, accountID, nil
@@ -2,6 +2,7 @@ package actual
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -690,3 +691,72 @@ func TestBulkAllFiltered(t *testing.T) {
 		t.Fatalf("expected no client calls when all filtered, got %d", len(fc.calls))
 	}
 }
+
+func TestDeliverReturnsTransactionsOfSuccessfulAccounts(t *testing.T) {
+	fc := &fakeClient{errByAccount: map[string]error{"account-2": errors.New("boom")}}
+
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-1",
+			AccountMap: AccountMap{"IBAN1": "account-1", "IBAN2": "account-2"},
+		},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		now:    func() time.Time { return time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC) },
+		client: fc,
+	}
+
+	txns := []ynabber.Transaction{
+		{
+			Account: ynabber.Account{IBAN: "IBAN1"},
+			ID:      "1",
+			Date:    time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+			Amount:  ynabber.Milliunits(1000),
+		},
+		{
+			Account: ynabber.Account{IBAN: "IBAN2"},
+			ID:      "2",
+			Date:    time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+			Amount:  ynabber.Milliunits(1000),
+		},
+		{
+			Account: ynabber.Account{IBAN: "UNKNOWN"},
+			ID:      "3",
+			Date:    time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+			Amount:  ynabber.Milliunits(1000),
+		},
+	}
+
+	delivered, err := writer.Deliver(context.Background(), txns)
+	if err == nil {
+		t.Fatal("expected error for failed account")
+	}
+	if len(delivered) != 1 || delivered[0].ID != "1" {
+		t.Fatalf("Deliver() = %+v, want only transaction 1", delivered)
+	}
+}
+
+func TestDeliverDryRunDeliversNothing(t *testing.T) {
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-1",
+			AccountMap: AccountMap{"IBAN1": "account-1"},
+			DryRun:     true,
+		},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		now:    func() time.Time { return time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC) },
+		client: &fakeClient{},
+	}
+
+	delivered, err := writer.Deliver(context.Background(), []ynabber.Transaction{{
+		Account: ynabber.Account{IBAN: "IBAN1"},
+		ID:      "1",
+		Date:    time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+		Amount:  ynabber.Milliunits(1000),
+	}})
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if len(delivered) != 0 {
+		t.Errorf("Deliver() = %+v, want nothing delivered in dry run", delivered)
+	}
+}
@@ -0,0 +1,53 @@
+package actual
+
+import (
+	"context"
+	"errors"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/writer/actual/client"
+)
+
+type categoryLister interface {
+	CategoryGroups(ctx context.Context, budgetID string) ([]client.CategoryGroup, error)
+}
+
+// categoryIDs returns the categories of the budget, fetched through the
+// cache of the writer, see ynabber.CategoryCache.
+func (w Writer) categoryIDs(ctx context.Context, batch []ynabber.Transaction) (ynabber.Categories, error) {
+	return w.categories.Get(ctx, batch, w.fetchCategories)
+}
+
+// fetchCategories lists the categories of the budget.
+func (w Writer) fetchCategories(ctx context.Context) (ynabber.Categories, error) {
+	lister, ok := w.client.(categoryLister)
+	if !ok {
+		return nil, errors.New("client can't list categories")
+	}
+	groups, err := lister.CategoryGroups(ctx, w.Config.BudgetID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(ynabber.Categories)
+	for _, group := range groups {
+		for _, category := range group.Categories {
+			ids.Add(group.Name, category.Name, category.ID)
+		}
+	}
+	w.logger.Debug("fetched categories", "categories", len(ids))
+	return ids, nil
+}
+
+// categoryID returns the ID of the category named name in ids, or an empty
+// string if name is empty or the budget has no such category.
+func (w Writer) categoryID(ids ynabber.Categories, name string, t ynabber.Transaction) string {
+	if name == "" {
+		return ""
+	}
+	id, ok := ids.Lookup(name)
+	if !ok {
+		w.logger.Warn("no such category in budget", "category", name, "transaction", t.ID)
+	}
+	return id
+}
@@ -0,0 +1,132 @@
+package actual
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/writer/actual/client"
+)
+
+// categoryClient is a fakeClient that also lists categories.
+type categoryClient struct {
+	fakeClient
+	groups  []client.CategoryGroup
+	lookups int
+}
+
+func (c *categoryClient) CategoryGroups(ctx context.Context, budgetID string) ([]client.CategoryGroup, error) {
+	c.lookups++
+	return c.groups, nil
+}
+
+func TestDeliverCategories(t *testing.T) {
+	account := ynabber.Account{IBAN: "IBAN1"}
+	date := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	batch := []ynabber.Transaction{
+		{Account: account, ID: "groceries", Date: date, Payee: "Grocer", Amount: -1000, Category: "Groceries"},
+		{Account: account, ID: "qualified", Date: date, Payee: "Cafe", Amount: -2000, Category: "other: eating out"},
+		{Account: account, ID: "new", Date: date, Payee: "Shop", Amount: -3000, Category: "New"},
+		{Account: account, ID: "unknown", Date: date, Payee: "Kiosk", Amount: -4000, Category: "Unknown"},
+	}
+	// New is only read after it is added to the budget
+	first := slices.Delete(slices.Clone(batch), 2, 3)
+
+	fc := &categoryClient{groups: []client.CategoryGroup{
+		{Name: "Usual Expenses", Categories: []client.Category{{ID: "cat-groceries", Name: "Groceries"}, {ID: "cat-eating-out", Name: "Eating Out"}}},
+		{Name: "Other", Categories: []client.Category{{ID: "cat-other-eating-out", Name: "Eating Out"}}},
+	}}
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-1",
+			AccountMap: AccountMap{"IBAN1": "account-1"},
+		},
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		now:        func() time.Time { return time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC) },
+		client:     fc,
+		categories: &ynabber.CategoryCache{},
+	}
+	for i, delivery := range [][]ynabber.Transaction{first, batch, batch} {
+		if _, err := writer.Deliver(context.Background(), delivery); err != nil {
+			t.Fatalf("Deliver() error = %v", err)
+		}
+		if i == 0 {
+			fc.groups[1].Categories = []client.Category{{ID: "cat-other-eating-out", Name: "Eating Out"}, {ID: "cat-new", Name: "New"}}
+		}
+	}
+	// Unknown is missing every time, but only fetched for once
+	if fc.lookups != 2 {
+		t.Errorf("fetched categories %d times, want twice, the second time for the new category", fc.lookups)
+	}
+
+	if len(fc.calls) != 3 || len(fc.calls[0].transactions) != 3 || len(fc.calls[2].transactions) != 4 {
+		t.Fatalf("imported %+v, want every transaction read", fc.calls)
+	}
+	want := []string{"cat-groceries", "cat-other-eating-out", "cat-new", ""}
+	for _, call := range fc.calls[1:] {
+		for i, got := range call.transactions {
+			if got.Category != want[i] {
+				t.Errorf("category of %s = %q, want %q", batch[i].ID, got.Category, want[i])
+			}
+		}
+	}
+}
+
+func TestDeliverSplits(t *testing.T) {
+	date := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	batch := []ynabber.Transaction{
+		{
+			Account:  ynabber.Account{IBAN: "IBAN1"},
+			ID:       "payroll",
+			Date:     date,
+			Payee:    "Employer",
+			Amount:   5000000,
+			Category: "Income",
+			Splits: []ynabber.Split{
+				{Amount: 4750000, Category: "Income", Memo: "Salary"},
+				{Amount: 250000, Category: "Pension"},
+			},
+		},
+		{
+			Account: ynabber.Account{IBAN: "IBAN1"},
+			ID:      "sub-cent",
+			Date:    date,
+			Amount:  1000,
+			Splits:  []ynabber.Split{{Amount: 995}, {Amount: 5}},
+		},
+	}
+
+	fc := &categoryClient{groups: []client.CategoryGroup{
+		{Name: "Income", Categories: []client.Category{{ID: "cat-income", Name: "Income"}, {ID: "cat-pension", Name: "Pension"}}},
+	}}
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-1",
+			AccountMap: AccountMap{"IBAN1": "account-1"},
+		},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		now:    func() time.Time { return time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC) },
+		client: fc,
+	}
+	delivered, err := writer.Deliver(context.Background(), batch)
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if len(delivered) != 1 || len(fc.calls) != 1 || len(fc.calls[0].transactions) != 1 {
+		t.Fatalf("imported %+v, want only the payroll, splits must be whole cents", fc.calls)
+	}
+
+	got := fc.calls[0].transactions[0]
+	want := []client.Subtransaction{
+		{Amount: 475000, Category: "cat-income", Notes: "Salary"},
+		{Amount: 25000, Category: "cat-pension"},
+	}
+	if got.Category != "" || !reflect.DeepEqual(got.Subtransactions, want) {
+		t.Errorf("imported %+v, want subtransactions %+v and no category", got, want)
+	}
+}
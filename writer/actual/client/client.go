@@ -18,14 +18,37 @@ import (
 const maxResponseBodyBytes = 10 * 1024 * 1024
 
 type Transaction struct {
+	ID            string `json:"id,omitempty"`
 	Account       string `json:"account"`
 	Date          string `json:"date"`
 	Amount        int64  `json:"amount"`
+	Payee         string `json:"payee,omitempty"`
 	PayeeName     string `json:"payee_name,omitempty"`
+	Category      string `json:"category,omitempty"`
 	Notes         string `json:"notes,omitempty"`
 	ImportedPayee string `json:"imported_payee,omitempty"`
 	ImportedID    string `json:"imported_id,omitempty"`
 	Cleared       *bool  `json:"cleared,omitempty"`
+	// Subtransactions split the transaction, each with its own category.
+	Subtransactions []Subtransaction `json:"subtransactions,omitempty"`
+}
+
+// Subtransaction is a part of a split transaction.
+type Subtransaction struct {
+	Amount   int64  `json:"amount"`
+	Category string `json:"category,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+// TransactionUpdate holds the fields UpdateTransaction changes. Fields left
+// empty or nil keep their value.
+type TransactionUpdate struct {
+	Date          string  `json:"date,omitempty"`
+	Amount        *int64  `json:"amount,omitempty"`
+	Notes         *string `json:"notes,omitempty"`
+	ImportedPayee *string `json:"imported_payee,omitempty"`
+	ImportedID    string  `json:"imported_id,omitempty"`
+	Cleared       *bool   `json:"cleared,omitempty"`
 }
 
 type importTransactionsRequest struct {
@@ -103,30 +126,11 @@ func (c *Client) ImportTransactions(ctx context.Context, budgetID, accountID str
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if c.apiKey != "" {
-		req.Header.Set("x-api-key", c.apiKey)
-	}
-	if c.encryptionPassword != "" {
-		req.Header.Set("budget-encryption-password", c.encryptionPassword)
-	}
-
 	log.Trace(c.logger, "http request", "method", req.Method, "url", req.URL.String(), "body", payload)
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return ImportTransactionsResult{}, fmt.Errorf("sending request: %w", err)
-	}
-	defer res.Body.Close()
-
-	resPayload, err := io.ReadAll(io.LimitReader(res.Body, maxResponseBodyBytes))
+	resPayload, err := c.do(req)
 	if err != nil {
-		return ImportTransactionsResult{}, fmt.Errorf("reading response body: %w", err)
-	}
-
-	log.Trace(c.logger, "http response", "status", res.StatusCode, "body", resPayload)
-
-	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		return ImportTransactionsResult{}, fmt.Errorf("actual api response %d: %s", res.StatusCode, responseError(resPayload))
+		return ImportTransactionsResult{}, err
 	}
 
 	var response importTransactionsResponse
@@ -155,6 +159,163 @@ func (c *Client) ImportTransactions(ctx context.Context, budgetID, accountID str
 	return result, nil
 }
 
+// Transactions returns the transactions of an account dated on or after
+// since. Nothing is written.
+func (c *Client) Transactions(ctx context.Context, budgetID, accountID string, since time.Time) ([]Transaction, error) {
+	query := url.Values{"since_date": {since.Format(time.DateOnly)}}
+	endpoint := fmt.Sprintf("%s/v1/budgets/%s/accounts/%s/transactions?%s", c.baseURL, url.PathEscape(budgetID), url.PathEscape(accountID), query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	log.Trace(c.logger, "http request", "method", req.Method, "url", req.URL.String())
+
+	resPayload, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data []Transaction `json:"data"`
+	}
+	if err := json.Unmarshal(resPayload, &response); err != nil {
+		return nil, fmt.Errorf("parsing response body: %w", err)
+	}
+	return response.Data, nil
+}
+
+// Payee is a payee of a budget. Every account has a transfer payee, whose
+// TransferAccount is the ID of the account.
+type Payee struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	TransferAccount string `json:"transfer_acct,omitempty"`
+}
+
+// Payees returns the payees of a budget.
+func (c *Client) Payees(ctx context.Context, budgetID string) ([]Payee, error) {
+	endpoint := fmt.Sprintf("%s/v1/budgets/%s/payees", c.baseURL, url.PathEscape(budgetID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	log.Trace(c.logger, "http request", "method", req.Method, "url", req.URL.String())
+
+	resPayload, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data []Payee `json:"data"`
+	}
+	if err := json.Unmarshal(resPayload, &response); err != nil {
+		return nil, fmt.Errorf("parsing response body: %w", err)
+	}
+	return response.Data, nil
+}
+
+// CategoryGroup is a group of categories of a budget.
+type CategoryGroup struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Categories []Category `json:"categories"`
+}
+
+// Category is a category of a budget.
+type Category struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// CategoryGroups returns the category groups of a budget along with their
+// categories.
+func (c *Client) CategoryGroups(ctx context.Context, budgetID string) ([]CategoryGroup, error) {
+	endpoint := fmt.Sprintf("%s/v1/budgets/%s/categorygroups", c.baseURL, url.PathEscape(budgetID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	log.Trace(c.logger, "http request", "method", req.Method, "url", req.URL.String())
+
+	resPayload, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data []CategoryGroup `json:"data"`
+	}
+	if err := json.Unmarshal(resPayload, &response); err != nil {
+		return nil, fmt.Errorf("parsing response body: %w", err)
+	}
+	return response.Data, nil
+}
+
+// UpdateTransaction changes the fields of the transaction with id that are
+// set in update.
+func (c *Client) UpdateTransaction(ctx context.Context, budgetID, id string, update TransactionUpdate) error {
+	payload, err := json.Marshal(struct {
+		Transaction TransactionUpdate `json:"transaction"`
+	}{update})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/budgets/%s/transactions/%s", c.baseURL, url.PathEscape(budgetID), url.PathEscape(id))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	log.Trace(c.logger, "http request", "method", req.Method, "url", req.URL.String(), "body", payload)
+
+	_, err = c.do(req)
+	return err
+}
+
+// DeleteTransaction deletes the transaction with id.
+func (c *Client) DeleteTransaction(ctx context.Context, budgetID, id string) error {
+	endpoint := fmt.Sprintf("%s/v1/budgets/%s/transactions/%s", c.baseURL, url.PathEscape(budgetID), url.PathEscape(id))
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	log.Trace(c.logger, "http request", "method", req.Method, "url", req.URL.String())
+
+	_, err = c.do(req)
+	return err
+}
+
+// do authenticates and sends req, and returns the body of a successful
+// response.
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	if c.apiKey != "" {
+		req.Header.Set("x-api-key", c.apiKey)
+	}
+	if c.encryptionPassword != "" {
+		req.Header.Set("budget-encryption-password", c.encryptionPassword)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer res.Body.Close()
+
+	resPayload, err := io.ReadAll(io.LimitReader(res.Body, maxResponseBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	log.Trace(c.logger, "http response", "status", res.StatusCode, "body", resPayload)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, fmt.Errorf("actual api response %d: %s", res.StatusCode, responseError(resPayload))
+	}
+	return resPayload, nil
+}
+
 func importErrorMessage(raw json.RawMessage) string {
 	var importErr struct {
 		Message string `json:"message"`
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 )
 
 type capturingTransport struct {
@@ -171,3 +172,85 @@ func TestImportTransactionsEscapesPathComponents(t *testing.T) {
 		t.Fatalf("expected URL path %q, got %q", want, got)
 	}
 }
+
+func TestTransactions(t *testing.T) {
+	var request *http.Request
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		request = req
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"data":[{"account":"account-1","date":"2024-05-10","amount":-1234,"imported_id":"YA:1"}]}`)),
+			Header:     make(http.Header),
+		}, nil
+	})
+	c := NewClient("https://actual.example.com", "key", "pass", &http.Client{Transport: transport}, nil)
+
+	transactions, err := c.Transactions(context.Background(), "budget-1", "account/1", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Transactions() error = %v", err)
+	}
+	if len(transactions) != 1 || transactions[0].ImportedID != "YA:1" {
+		t.Fatalf("unexpected transactions %+v", transactions)
+	}
+
+	if request.Method != http.MethodGet {
+		t.Fatalf("expected GET got %s", request.Method)
+	}
+	if got, want := request.URL.EscapedPath(), "/v1/budgets/budget-1/accounts/account%2F1/transactions"; got != want {
+		t.Fatalf("expected URL path %q, got %q", want, got)
+	}
+	if got := request.URL.Query().Get("since_date"); got != "2024-05-01" {
+		t.Fatalf("expected since_date 2024-05-01, got %q", got)
+	}
+	if request.Header.Get("x-api-key") != "key" || request.Header.Get("budget-encryption-password") != "pass" {
+		t.Fatalf("expected auth headers")
+	}
+}
+
+func TestPayees(t *testing.T) {
+	var request *http.Request
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		request = req
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"data":[{"id":"payee-1","name":"Grocer"},{"id":"payee-2","name":"","transfer_acct":"account-1"}]}`)),
+			Header:     make(http.Header),
+		}, nil
+	})
+	c := NewClient("https://actual.example.com", "key", "pass", &http.Client{Transport: transport}, nil)
+
+	payees, err := c.Payees(context.Background(), "budget-1")
+	if err != nil {
+		t.Fatalf("Payees() error = %v", err)
+	}
+	if len(payees) != 2 || payees[1].TransferAccount != "account-1" {
+		t.Fatalf("unexpected payees %+v", payees)
+	}
+	if got, want := request.URL.EscapedPath(), "/v1/budgets/budget-1/payees"; request.Method != http.MethodGet || got != want {
+		t.Fatalf("expected GET %q got %s %q", want, request.Method, got)
+	}
+}
+
+func TestCategoryGroups(t *testing.T) {
+	var request *http.Request
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		request = req
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"data":[{"id":"group-1","name":"Everyday","categories":[{"id":"category-1","name":"Groceries","group_id":"group-1"}]}]}`)),
+			Header:     make(http.Header),
+		}, nil
+	})
+	c := NewClient("https://actual.example.com", "key", "pass", &http.Client{Transport: transport}, nil)
+
+	groups, err := c.CategoryGroups(context.Background(), "budget-1")
+	if err != nil {
+		t.Fatalf("CategoryGroups() error = %v", err)
+	}
+	if len(groups) != 1 || len(groups[0].Categories) != 1 || groups[0].Categories[0].ID != "category-1" {
+		t.Fatalf("unexpected category groups %+v", groups)
+	}
+	if got, want := request.URL.EscapedPath(), "/v1/budgets/budget-1/categorygroups"; request.Method != http.MethodGet || got != want {
+		t.Fatalf("expected GET %q got %s %q", want, request.Method, got)
+	}
+}
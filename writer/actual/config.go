@@ -4,8 +4,15 @@ package actual
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"slices"
 	"time"
+
+	"github.com/martinohansen/ynabber"
 )
 
 type Date time.Time
@@ -52,7 +59,7 @@ type Config struct {
 	BaseURL string `envconfig:"ACTUAL_BASE_URL"`
 
 	// APIKey is an optional shared secret that will be sent via the x-api-key header.
-	APIKey string `envconfig:"ACTUAL_API_KEY"`
+	APIKey string `envconfig:"ACTUAL_API_KEY" secret:"true"`
 
 	// BudgetID is the Actual Sync ID for the budget to update.
 	BudgetID string `envconfig:"ACTUAL_BUDGET_ID"`
@@ -62,18 +69,30 @@ type Config struct {
 	AccountMap AccountMap `envconfig:"ACTUAL_ACCOUNTMAP"`
 
 	// EncryptionPassword optionally unlocks end-to-end encrypted budgets.
-	EncryptionPassword string `envconfig:"ACTUAL_ENCRYPTION_PASSWORD"`
+	EncryptionPassword string `envconfig:"ACTUAL_ENCRYPTION_PASSWORD" secret:"true"`
 
 	// FromDate only imports transactions from this date onward. For
 	// example: 2006-01-02
 	FromDate Date `envconfig:"ACTUAL_FROM_DATE"`
 
 	// Delay sending transactions to Actual by this duration. This can be
-	// necessary if the bank changes transaction IDs after some time, or
-	// enriches remittance information after booking (which can cause duplicate
-	// imports). Default is 0 (no delay).
+	// necessary if the bank changes transaction IDs after some time, for
+	// example when it enriches remittance information after booking and the
+	// ID is derived from it (which can cause duplicate imports). Enriched
+	// transactions that keep their ID are updated in place instead. Default is
+	// 0 (no delay).
 	Delay time.Duration `envconfig:"ACTUAL_DELAY" default:"0"`
 
+	// UpdateNotes decides whether the notes of an imported transaction are
+	// updated when the bank enriches it later. Possible values: never,
+	// unedited (only if they weren't changed in Actual), always.
+	UpdateNotes ynabber.UpdatePolicy `envconfig:"ACTUAL_UPDATE_NOTES" default:"unedited"`
+
+	// UpdateImportedPayee decides whether the imported payee, the raw bank
+	// text Actual's payee rules match against, is updated when the bank
+	// enriches a transaction later. Possible values: never, unedited, always.
+	UpdateImportedPayee ynabber.UpdatePolicy `envconfig:"ACTUAL_UPDATE_IMPORTED_PAYEE" default:"unedited"`
+
 	// Cleared sets the transaction cleared flag for newly created transactions.
 	// Default is false.
 	Cleared bool `envconfig:"ACTUAL_CLEARED" default:"false"`
@@ -86,3 +105,60 @@ type Config struct {
 	// verifying mappings and deduplication before writing. Default is false.
 	DryRun bool `envconfig:"ACTUAL_DRY_RUN" default:"false"`
 }
+
+// Check implements ynabber.Checker without contacting Actual.
+func (c *Config) Check() error {
+	var errs []error
+	if u, err := url.Parse(c.BaseURL); c.BaseURL == "" || err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("ACTUAL_BASE_URL: %q is not an absolute URL like https://actual.example.com", c.BaseURL))
+	}
+	if c.BudgetID == "" {
+		errs = append(errs, errors.New("ACTUAL_BUDGET_ID: missing, use the Sync ID from the advanced settings of your budget"))
+	}
+	if len(c.AccountMap) == 0 {
+		errs = append(errs, errors.New("ACTUAL_ACCOUNTMAP: no accounts mapped"))
+	}
+	for _, account := range slices.Sorted(maps.Keys(c.AccountMap)) {
+		id := c.AccountMap[account]
+		if err := ynabber.CheckAccount(account); err != nil {
+			errs = append(errs, fmt.Errorf("ACTUAL_ACCOUNTMAP: %w", err))
+		}
+		if id == "" {
+			errs = append(errs, fmt.Errorf("ACTUAL_ACCOUNTMAP: no Actual account for %s", account))
+		}
+	}
+	if from := c.FromDate.Time(); from.After(time.Now()) {
+		errs = append(errs, fmt.Errorf("ACTUAL_FROM_DATE: %s is in the future", from.Format(time.DateOnly)))
+	}
+	if c.Delay < 0 {
+		errs = append(errs, errors.New("ACTUAL_DELAY: must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// LogValue returns config with sensitive information redacted
+func (c *Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("base_url", c.BaseURL),
+		slog.String("api_key", redact(c.APIKey)),
+		slog.String("budget_id", c.BudgetID),
+		slog.Any("account_map", c.AccountMap),
+		slog.String("encryption_password", redact(c.EncryptionPassword)),
+		slog.Time("from_date", c.FromDate.Time()),
+		slog.Duration("delay", c.Delay),
+		slog.String("update_notes", string(c.UpdateNotes)),
+		slog.String("update_imported_payee", string(c.UpdateImportedPayee)),
+		slog.Bool("cleared", c.Cleared),
+		slog.Bool("reimport_deleted", c.ReimportDeleted),
+		slog.Bool("dry_run", c.DryRun),
+	)
+}
+
+// redact hides secret but keeps whether it was set, since both secrets of the
+// Actual writer are optional.
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "******"
+}
@@ -0,0 +1,81 @@
+package actual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/writer/actual/client"
+)
+
+type transactionEditor interface {
+	transactionLister
+	UpdateTransaction(ctx context.Context, budgetID, id string, update client.TransactionUpdate) error
+	DeleteTransaction(ctx context.Context, budgetID, id string) error
+}
+
+// Promote implements ynabber.Promoter. It updates the transaction imported
+// for pending, found by its imported_id, with the date, amount, notes and
+// imported payee of booked, and clears it according to ACTUAL_CLEARED. The
+// payee and category are left as they are. The imported_id is replaced with
+// the one of booked so later imports of it are recognized as duplicates.
+func (w Writer) Promote(ctx context.Context, pending, booked ynabber.Transaction) error {
+	editor, ok := w.client.(transactionEditor)
+	if !ok {
+		return errors.New("client can't edit transactions")
+	}
+	payload, accountID, err := w.toActual(booked)
+	if err != nil {
+		return err
+	}
+	existing, ok, err := w.findImported(ctx, editor, accountID, pending)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("transaction imported for pending %s not found", pending.ID)
+	}
+
+	return editor.UpdateTransaction(ctx, w.Config.BudgetID, existing.ID, client.TransactionUpdate{
+		Date:          payload.Date,
+		Amount:        &payload.Amount,
+		Notes:         &payload.Notes,
+		ImportedPayee: &payload.ImportedPayee,
+		ImportedID:    payload.ImportedID,
+		Cleared:       new(w.Config.Cleared),
+	})
+}
+
+// Remove implements ynabber.Promoter. It deletes the transaction imported for
+// pending, if it is still there.
+func (w Writer) Remove(ctx context.Context, pending ynabber.Transaction) error {
+	editor, ok := w.client.(transactionEditor)
+	if !ok {
+		return errors.New("client can't edit transactions")
+	}
+	accountID, err := accountParser(pending.Account, w.Config.AccountMap)
+	if err != nil {
+		return err
+	}
+	existing, ok, err := w.findImported(ctx, editor, accountID, pending)
+	if err != nil || !ok {
+		return err
+	}
+	return editor.DeleteTransaction(ctx, w.Config.BudgetID, existing.ID)
+}
+
+// findImported returns the transaction imported for t into accountID.
+func (w Writer) findImported(ctx context.Context, lister transactionLister, accountID string, t ynabber.Transaction) (client.Transaction, bool, error) {
+	transactions, err := lister.Transactions(ctx, w.Config.BudgetID, accountID, t.Date)
+	if err != nil {
+		return client.Transaction{}, false, fmt.Errorf("listing transactions of account %s: %w", accountID, err)
+	}
+	importedID := makeID(t)
+	for _, existing := range transactions {
+		if existing.ImportedID == importedID {
+			return existing, true, nil
+		}
+	}
+	return client.Transaction{}, false, nil
+}
@@ -0,0 +1,96 @@
+package actual
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/writer/actual/client"
+)
+
+// editingClient is a listingClient that also updates and deletes
+// transactions.
+type editingClient struct {
+	listingClient
+	updated map[string]client.TransactionUpdate
+	deleted []string
+}
+
+func (c *editingClient) UpdateTransaction(ctx context.Context, budgetID, id string, update client.TransactionUpdate) error {
+	c.updated[id] = update
+	return nil
+}
+
+func (c *editingClient) DeleteTransaction(ctx context.Context, budgetID, id string) error {
+	c.deleted = append(c.deleted, id)
+	return nil
+}
+
+func TestPromoteAndRemove(t *testing.T) {
+	day := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	pending := ynabber.Transaction{
+		Account: ynabber.Account{IBAN: "IBAN1"},
+		ID:      "pdng-1",
+		Status:  ynabber.StatusPending,
+		Date:    day,
+		Payee:   "Grocer",
+		Amount:  -1000,
+	}
+	booked := pending
+	booked.ID, booked.Status, booked.Date, booked.Memo = "book-1", ynabber.StatusBooked, day.AddDate(0, 0, 2), "Card purchase"
+
+	fc := &editingClient{
+		listingClient: listingClient{
+			existing: map[string][]client.Transaction{
+				"account-1": {{ID: "actual-1", ImportedID: makeID(pending)}},
+			},
+			since: make(map[string]time.Time),
+		},
+		updated: make(map[string]client.TransactionUpdate),
+	}
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-1",
+			AccountMap: AccountMap{"IBAN1": "account-1"},
+			Cleared:    true,
+		},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		client: fc,
+	}
+
+	if err := writer.Promote(context.Background(), pending, booked); err != nil {
+		t.Fatalf("Promote() error = %v", err)
+	}
+	update, ok := fc.updated["actual-1"]
+	if !ok {
+		t.Fatalf("updated %+v, want actual-1", fc.updated)
+	}
+	if update.Date != "2024-05-12" || *update.Amount != -100 || *update.Notes != "Card purchase" {
+		t.Errorf("update = %+v, want the date, amount and notes of the booked transaction", update)
+	}
+	if update.ImportedID != makeID(booked) {
+		t.Errorf("imported_id = %q, want %q", update.ImportedID, makeID(booked))
+	}
+	if update.Cleared == nil || !*update.Cleared {
+		t.Errorf("cleared = %v, want true from ACTUAL_CLEARED", update.Cleared)
+	}
+
+	if err := writer.Remove(context.Background(), pending); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if len(fc.deleted) != 1 || fc.deleted[0] != "actual-1" {
+		t.Errorf("deleted %v, want [actual-1]", fc.deleted)
+	}
+
+	// A transaction that is already gone is not an error.
+	fc.existing = nil
+	if err := writer.Remove(context.Background(), pending); err != nil {
+		t.Errorf("Remove() of missing transaction error = %v", err)
+	}
+	if len(fc.deleted) != 1 {
+		t.Errorf("deleted %v, want nothing more", fc.deleted)
+	}
+}
@@ -0,0 +1,95 @@
+package actual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/writer/actual/client"
+)
+
+type transactionLister interface {
+	Transactions(ctx context.Context, budgetID, accountID string, since time.Time) ([]client.Transaction, error)
+}
+
+// Plan implements ynabber.Planner. It maps every transaction like Deliver
+// does and lists the transactions of each Actual account since the oldest
+// date written to it, to find the ones Actual already has under the same
+// imported_id. Receiving sides of transfers are skipped when Actual would
+// create them, see settleTransfers. Unlike ACTUAL_DRY_RUN, nothing is sent to
+// the import endpoint.
+func (w Writer) Plan(ctx context.Context, batch []ynabber.Transaction) ([]ynabber.Change, error) {
+	lister, ok := w.client.(transactionLister)
+	if !ok {
+		return nil, errors.New("client can't list transactions")
+	}
+
+	changes := make([]ynabber.Change, len(batch))
+	since := make(map[string]time.Time)
+	var sending, receiving []transferSide
+	for i, t := range batch {
+		changes[i] = ynabber.Change{Transaction: t, Action: ynabber.ActionCreate}
+		if reason := w.dateSkipReason(t.Date); reason != "" {
+			changes[i].Action, changes[i].Reason = ynabber.ActionSkip, reason
+			continue
+		}
+		payload, accountID, err := w.toActual(t)
+		if err != nil {
+			changes[i].Action, changes[i].Reason = ynabber.ActionSkip, err.Error()
+			continue
+		}
+		if other, ok := w.transferAccount(t); ok {
+			side := transferSide{index: i, src: t, payload: payload, other: other}
+			if w.inflowSide(t) {
+				receiving = append(receiving, side)
+			} else {
+				sending = append(sending, side)
+			}
+		}
+		changes[i].Account, changes[i].ImportID = accountID, payload.ImportedID
+		if s, ok := since[accountID]; !ok || t.Date.Before(s) {
+			since[accountID] = t.Date
+		}
+	}
+
+	existing := make(map[string]string)
+	for accountID, date := range since {
+		transactions, err := lister.Transactions(ctx, w.Config.BudgetID, accountID, date)
+		if err != nil {
+			return nil, fmt.Errorf("listing transactions of account %s: %w", accountID, err)
+		}
+		for _, t := range transactions {
+			if t.ImportedID != "" {
+				existing[accountID+"/"+t.ImportedID] = "imported_id already in the account"
+			}
+		}
+	}
+
+	for i, c := range changes {
+		if c.Action != ynabber.ActionCreate {
+			continue
+		}
+		key := c.Account + "/" + c.ImportID
+		if reason, ok := existing[key]; ok {
+			changes[i].Action, changes[i].Reason = ynabber.ActionDuplicate, reason
+			continue
+		}
+		existing[key] = "imported_id appears earlier in the batch"
+	}
+	if len(sending) > 0 || len(receiving) > 0 {
+		payees, err := w.transferPayees(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing payees for transfers: %w", err)
+		}
+		_, left, err := w.settleTransfers(ctx, sending, receiving, payees)
+		if err != nil {
+			return nil, fmt.Errorf("settling transfers: %w", err)
+		}
+		for _, side := range left {
+			changes[side.index].Action, changes[side.index].Reason = ynabber.ActionSkip, "receiving side of a transfer, which Actual creates with the sending side"
+		}
+	}
+	return changes, nil
+}
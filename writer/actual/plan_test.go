@@ -0,0 +1,100 @@
+package actual
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/writer/actual/client"
+)
+
+// listingClient is a fakeClient that also lists the transactions of each
+// account.
+type listingClient struct {
+	fakeClient
+	existing map[string][]client.Transaction
+	since    map[string]time.Time
+}
+
+func (c *listingClient) Transactions(ctx context.Context, budgetID, accountID string, since time.Time) ([]client.Transaction, error) {
+	c.since[accountID] = since
+	return c.existing[accountID], nil
+}
+
+func TestPlan(t *testing.T) {
+	tx := func(id, iban string, day int, amount ynabber.Milliunits) ynabber.Transaction {
+		return ynabber.Transaction{
+			Account: ynabber.Account{IBAN: iban},
+			ID:      ynabber.ID(id),
+			Date:    time.Date(2024, 5, day, 0, 0, 0, 0, time.UTC),
+			Amount:  amount,
+		}
+	}
+	existing := tx("existing", "IBAN1", 10, 1000)
+	batch := []ynabber.Transaction{
+		tx("new", "IBAN1", 12, 1000),
+		existing,
+		tx("unmapped", "IBAN3", 12, 1000),
+		tx("sub-cent", "IBAN1", 12, 1001),
+		tx("old", "IBAN2", 1, 1000),
+		tx("other-account", "IBAN2", 11, 1000),
+	}
+
+	fc := &listingClient{
+		existing: map[string][]client.Transaction{
+			"account-1": {{ImportedID: makeID(existing)}},
+		},
+		since: make(map[string]time.Time),
+	}
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-1",
+			AccountMap: AccountMap{"IBAN1": "account-1", "IBAN2": "account-2"},
+			FromDate:   Date(time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC)),
+		},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		now:    func() time.Time { return time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC) },
+		client: fc,
+	}
+
+	changes, err := writer.Plan(context.Background(), batch)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	want := []ynabber.Action{
+		ynabber.ActionCreate,
+		ynabber.ActionDuplicate,
+		ynabber.ActionSkip,
+		ynabber.ActionSkip,
+		ynabber.ActionSkip,
+		ynabber.ActionCreate,
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Plan() returned %d changes, want %d", len(changes), len(want))
+	}
+	for i, change := range changes {
+		if change.Action != want[i] {
+			t.Errorf("change %d (%s) action = %s, want %s, reason %q", i, change.Transaction.ID, change.Action, want[i], change.Reason)
+		}
+	}
+	if got := changes[5].Account; got != "account-2" {
+		t.Errorf("account = %q, want account-2", got)
+	}
+
+	if len(fc.calls) != 0 {
+		t.Errorf("Plan() imported %d batch(es), want none", len(fc.calls))
+	}
+	wantSince := map[string]time.Time{
+		"account-1": time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+		"account-2": time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC),
+	}
+	for account, want := range wantSince {
+		if got := fc.since[account]; !got.Equal(want) {
+			t.Errorf("listed %s since %s, want %s", account, got, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package actual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/writer/actual/client"
+)
+
+// adjustmentNotes are the notes of the transactions Adjust adds.
+const adjustmentNotes = "Added by Ynabber to match the bank balance"
+
+// ClearedBalance implements ynabber.Reconciler. It returns the sum of the
+// cleared transactions of the Actual account that account is mapped to.
+func (w Writer) ClearedBalance(ctx context.Context, account ynabber.Account) (ynabber.Milliunits, bool, error) {
+	lister, ok := w.client.(transactionLister)
+	if !ok {
+		return 0, false, errors.New("client can't list transactions")
+	}
+	accountID, err := accountParser(account, w.Config.AccountMap)
+	if err != nil {
+		return 0, false, nil
+	}
+
+	transactions, err := lister.Transactions(ctx, w.Config.BudgetID, accountID, time.Time{})
+	if err != nil {
+		return 0, false, fmt.Errorf("listing transactions of account %s: %w", accountID, err)
+	}
+	var cents int64
+	for _, transaction := range transactions {
+		if transaction.Cleared != nil && *transaction.Cleared {
+			cents += transaction.Amount
+		}
+	}
+	return ynabber.Milliunits(cents * 10), true, nil
+}
+
+// Adjust implements ynabber.Reconciler. It imports a cleared transaction of
+// amount into the Actual account that account is mapped to, with
+// ynabber.AdjustmentPayee as payee.
+func (w Writer) Adjust(ctx context.Context, account ynabber.Account, amount ynabber.Milliunits, date time.Time) error {
+	payload, accountID, err := w.toActual(ynabber.Transaction{
+		Account: account,
+		Date:    date,
+		Payee:   ynabber.AdjustmentPayee,
+		Memo:    adjustmentNotes,
+		Amount:  amount,
+	})
+	if err != nil {
+		return err
+	}
+	payload.Cleared = new(true)
+
+	opts := client.ImportTransactionsOptions{DefaultCleared: true, DryRun: w.Config.DryRun}
+	result, err := w.client.ImportTransactions(ctx, w.Config.BudgetID, accountID, []client.Transaction{payload}, opts)
+	if err != nil {
+		return fmt.Errorf("account %s: %w", accountID, err)
+	}
+	w.logger.Info("adjusted balance", "account", accountID, "amount", payload.Amount, "added", result.Added)
+	return nil
+}
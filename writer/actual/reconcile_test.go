@@ -0,0 +1,67 @@
+package actual
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/writer/actual/client"
+)
+
+func TestClearedBalance(t *testing.T) {
+	fc := &listingClient{
+		existing: map[string][]client.Transaction{
+			"account-1": {
+				{ID: "a", Amount: 1000, Cleared: new(true)},
+				{ID: "b", Amount: -250, Cleared: new(true)},
+				{ID: "c", Amount: -400, Cleared: new(false)},
+			},
+		},
+		since: make(map[string]time.Time),
+	}
+	writer := Writer{
+		Config: Config{BudgetID: "budget-1", AccountMap: AccountMap{"IBAN1": "account-1"}},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		client: fc,
+	}
+
+	got, ok, err := writer.ClearedBalance(context.Background(), ynabber.Account{IBAN: "IBAN1"})
+	if err != nil || !ok {
+		t.Fatalf("ClearedBalance() = %v, %v", ok, err)
+	}
+	if got != 7500 {
+		t.Errorf("ClearedBalance() = %d, want 7500", got)
+	}
+	if since := fc.since["account-1"]; !since.IsZero() {
+		t.Errorf("listed transactions since %s, want all", since)
+	}
+
+	if _, ok, err := writer.ClearedBalance(context.Background(), ynabber.Account{IBAN: "IBAN2"}); ok || err != nil {
+		t.Errorf("ClearedBalance() of unmapped account = %v, %v, want false", ok, err)
+	}
+}
+
+func TestAdjust(t *testing.T) {
+	fc := &fakeClient{}
+	writer := Writer{
+		Config: Config{BudgetID: "budget-1", AccountMap: AccountMap{"IBAN1": "account-1"}},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		client: fc,
+	}
+	date := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	if err := writer.Adjust(context.Background(), ynabber.Account{IBAN: "IBAN1"}, -2500, date); err != nil {
+		t.Fatalf("Adjust() error = %v", err)
+	}
+
+	if len(fc.calls) != 1 || len(fc.calls[0].transactions) != 1 {
+		t.Fatalf("calls = %+v, want one transaction", fc.calls)
+	}
+	got := fc.calls[0].transactions[0]
+	if got.Account != "account-1" || got.Date != "2024-05-10" || got.Amount != -250 ||
+		got.PayeeName != ynabber.AdjustmentPayee || got.Cleared == nil || !*got.Cleared {
+		t.Errorf("imported %+v", got)
+	}
+}
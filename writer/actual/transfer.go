@@ -0,0 +1,195 @@
+package actual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/writer/actual/client"
+)
+
+type payeeLister interface {
+	Payees(ctx context.Context, budgetID string) ([]client.Payee, error)
+}
+
+// transferPayees returns the ID of the transfer payee of every account in the
+// budget, keyed by account ID. Actual links a transaction to the other side
+// of a transfer when its payee is the transfer payee of the other account.
+func (w Writer) transferPayees(ctx context.Context) (map[string]string, error) {
+	lister, ok := w.client.(payeeLister)
+	if !ok {
+		return nil, errors.New("client can't list payees")
+	}
+	payees, err := lister.Payees(ctx, w.Config.BudgetID)
+	if err != nil {
+		return nil, err
+	}
+	transfers := make(map[string]string)
+	for _, payee := range payees {
+		if payee.TransferAccount != "" {
+			transfers[payee.TransferAccount] = payee.ID
+		}
+	}
+	return transfers, nil
+}
+
+// transferAccount returns the Actual account src is a transfer to, or false
+// if it isn't a transfer or the other account isn't in ACTUAL_ACCOUNTMAP.
+func (w Writer) transferAccount(src ynabber.Transaction) (string, bool) {
+	if !src.IsTransfer() {
+		return "", false
+	}
+	accountID, err := accountParser(src.Transfer, w.Config.AccountMap)
+	return accountID, err == nil
+}
+
+// inflowSide reports whether src is the receiving side of a transfer between
+// two Actual accounts. Actual creates it along with the sending side when that
+// is imported with the transfer payee, see settleTransfers.
+func (w Writer) inflowSide(src ynabber.Transaction) bool {
+	_, ok := w.transferAccount(src)
+	return ok && src.Amount >= 0
+}
+
+// transferSide is one side of a transfer between two Actual accounts.
+type transferSide struct {
+	// index is the position of the transaction in the batch.
+	index   int
+	src     ynabber.Transaction
+	payload client.Transaction
+	// other is the Actual account of the other side.
+	other string
+}
+
+// side returns s to pair it with the other side, see ynabber.PairSending.
+func (s transferSide) side() ynabber.TransferSide {
+	return ynabber.TransferSide{
+		Account: s.payload.Account,
+		Other:   s.other,
+		Amount:  s.payload.Amount,
+		Date:    s.payload.Date,
+	}
+}
+
+// settleTransfers decides how the sides of transfers between two Actual
+// accounts are imported. It returns the sides to import and the receiving
+// sides Actual creates itself.
+//
+// Sending sides are imported with the transfer payee of the other account,
+// which makes Actual create the receiving side, unless the budget already has
+// the receiving side imported on its own. Receiving sides are left to Actual
+// when their sending side is imported with the transfer payee in the same
+// delivery, or the budget has them as a transfer from the other account
+// already. The other receiving sides are imported like any other
+// transaction, so none are lost when the sending side was written as
+// ordinary spending, for example because it was read before the other
+// account was known.
+func (w Writer) settleTransfers(ctx context.Context, sending, receiving []transferSide, payees map[string]string) ([]transferSide, []transferSide, error) {
+	budget, err := w.transferCandidates(ctx, sending, receiving)
+	if err != nil {
+		return nil, nil, err
+	}
+	transferPayee := make(map[string]bool, len(payees))
+	for _, payee := range payees {
+		transferPayee[payee] = true
+	}
+	used := make(map[string]bool)
+
+	var imports []transferSide
+	// linked are the indexes in imports of sending sides imported with the
+	// transfer payee, not yet paired with a receiving side
+	linked := make(map[int]bool)
+	for _, side := range sending {
+		payee := payees[side.other]
+		if payee != "" {
+			// The receiving side imported on its own would be doubled
+			found, ok := findBudgetSide(budget[side.other], used, -side.payload.Amount, side.payload.Date, func(t client.Transaction) bool {
+				return t.ImportedID != "" && !transferPayee[t.Payee]
+			})
+			if ok {
+				w.logger.Debug("receiving side of transfer already imported, importing it as an ordinary transaction", "transaction", side.src)
+				used[found.ID], payee = true, ""
+			}
+		}
+		if payee != "" {
+			// Transfers between budget accounts have no category
+			side.payload.Payee, side.payload.PayeeName, side.payload.Category = payee, "", ""
+			linked[len(imports)] = true
+		}
+		imports = append(imports, side)
+	}
+
+	var left []transferSide
+	for _, side := range receiving {
+		if k, ok := ynabber.PairSending(imports, linked, side, transferSide.side); ok {
+			delete(linked, k)
+			w.logger.Debug("left to Actual as the other side of a transfer", "transaction", side.src)
+			left = append(left, side)
+			continue
+		}
+		// Actual gives the side it creates the transfer payee of the other
+		// account
+		found, ok := findBudgetSide(budget[side.payload.Account], used, side.payload.Amount, side.payload.Date, func(t client.Transaction) bool {
+			return t.Payee != "" && t.Payee == payees[side.other]
+		})
+		if ok {
+			used[found.ID] = true
+			w.logger.Debug("already in Actual as the other side of a transfer", "transaction", side.src)
+			left = append(left, side)
+			continue
+		}
+		w.logger.Debug("sending side of transfer not imported as a transfer, importing the receiving side on its own", "transaction", side.src)
+		imports = append(imports, side)
+	}
+	return imports, left, nil
+}
+
+// findBudgetSide returns the transaction in transactions with amount, dated
+// at most ynabber.TransferDays from date, that match accepts. The closest in
+// date wins, and transactions in used are left out.
+func findBudgetSide(transactions []client.Transaction, used map[string]bool, amount int64, date string, match func(client.Transaction) bool) (client.Transaction, bool) {
+	j, ok := ynabber.ClosestTransfer(len(transactions), date, func(j int) (string, bool) {
+		t := transactions[j]
+		return t.Date, !used[t.ID] && t.Amount == amount && match(t)
+	})
+	if !ok {
+		return client.Transaction{}, false
+	}
+	return transactions[j], true
+}
+
+// transferCandidates lists the transactions that may be the other side of
+// sending or receiving, keyed by account, from ynabber.TransferDays before
+// the oldest of them. Nothing is listed if the client can't list
+// transactions.
+func (w Writer) transferCandidates(ctx context.Context, sending, receiving []transferSide) (map[string][]client.Transaction, error) {
+	lister, ok := w.client.(transactionLister)
+	if !ok {
+		return nil, nil
+	}
+	since := make(map[string]time.Time)
+	add := func(accountID string, date time.Time) {
+		from := date.AddDate(0, 0, -ynabber.TransferDays)
+		if s, ok := since[accountID]; !ok || from.Before(s) {
+			since[accountID] = from
+		}
+	}
+	for _, side := range sending {
+		add(side.other, side.src.Date)
+	}
+	for _, side := range receiving {
+		add(side.payload.Account, side.src.Date)
+	}
+
+	candidates := make(map[string][]client.Transaction, len(since))
+	for accountID, from := range since {
+		transactions, err := lister.Transactions(ctx, w.Config.BudgetID, accountID, from)
+		if err != nil {
+			return nil, fmt.Errorf("listing transactions of account %s: %w", accountID, err)
+		}
+		candidates[accountID] = transactions
+	}
+	return candidates, nil
+}
@@ -0,0 +1,202 @@
+package actual
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/writer/actual/client"
+)
+
+// payeeClient is a fakeClient that also lists payees.
+type payeeClient struct {
+	fakeClient
+	payees []client.Payee
+}
+
+func (c *payeeClient) Payees(ctx context.Context, budgetID string) ([]client.Payee, error) {
+	return c.payees, nil
+}
+
+func TestDeliverTransfers(t *testing.T) {
+	checking := ynabber.Account{IBAN: "IBAN1"}
+	savings := ynabber.Account{IBAN: "IBAN2"}
+	date := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	batch := []ynabber.Transaction{
+		{Account: checking, ID: "out", Date: date, Payee: "Savings", Amount: -5000, Transfer: savings},
+		{Account: savings, ID: "in", Date: date, Payee: "Checking", Amount: 5000, Transfer: checking},
+	}
+
+	fc := &payeeClient{payees: []client.Payee{
+		{ID: "payee-1", Name: "Grocer"},
+		{ID: "payee-checking", TransferAccount: "account-1"},
+		{ID: "payee-savings", TransferAccount: "account-2"},
+	}}
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-1",
+			AccountMap: AccountMap{"IBAN1": "account-1", "IBAN2": "account-2"},
+		},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		now:    func() time.Time { return time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC) },
+		client: fc,
+	}
+
+	delivered, err := writer.Deliver(context.Background(), batch)
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if len(delivered) != 2 {
+		t.Errorf("delivered %d transactions, want both sides", len(delivered))
+	}
+	if len(fc.calls) != 1 || fc.calls[0].accountID != "account-1" {
+		t.Fatalf("imported %+v, want only the sending side into account-1", fc.calls)
+	}
+	if got := fc.calls[0].transactions[0]; got.Payee != "payee-savings" || got.PayeeName != "" {
+		t.Errorf("payee = %q/%q, want the transfer payee of account-2", got.Payee, got.PayeeName)
+	}
+}
+
+// budgetClient is an Actual budget that keeps the transactions imported into
+// it and, like Actual, creates the receiving side of a transfer imported with
+// a transfer payee.
+type budgetClient struct {
+	payees       []client.Payee
+	transactions []client.Transaction
+	imported     []client.Transaction
+}
+
+func (c *budgetClient) Payees(ctx context.Context, budgetID string) ([]client.Payee, error) {
+	return c.payees, nil
+}
+
+func (c *budgetClient) Transactions(ctx context.Context, budgetID, accountID string, since time.Time) ([]client.Transaction, error) {
+	var transactions []client.Transaction
+	for _, t := range c.transactions {
+		if t.Account == accountID {
+			transactions = append(transactions, t)
+		}
+	}
+	return transactions, nil
+}
+
+func (c *budgetClient) ImportTransactions(ctx context.Context, budgetID, accountID string, transactions []client.Transaction, opts client.ImportTransactionsOptions) (client.ImportTransactionsResult, error) {
+	for _, t := range transactions {
+		c.imported = append(c.imported, t)
+		if slices.ContainsFunc(c.transactions, func(existing client.Transaction) bool {
+			return existing.Account == accountID && existing.ImportedID == t.ImportedID
+		}) {
+			continue
+		}
+		t.ID = fmt.Sprintf("tx-%d", len(c.transactions))
+		c.transactions = append(c.transactions, t)
+		for _, payee := range c.payees {
+			if payee.ID == t.Payee && payee.TransferAccount != "" {
+				c.transactions = append(c.transactions, client.Transaction{
+					ID:      fmt.Sprintf("tx-%d", len(c.transactions)),
+					Account: payee.TransferAccount,
+					Date:    t.Date,
+					Amount:  -t.Amount,
+					Payee:   c.transferPayee(accountID),
+				})
+			}
+		}
+	}
+	return client.ImportTransactionsResult{Added: len(transactions)}, nil
+}
+
+func (c *budgetClient) transferPayee(accountID string) string {
+	for _, payee := range c.payees {
+		if payee.TransferAccount == accountID {
+			return payee.ID
+		}
+	}
+	return ""
+}
+
+// TestDeliverTransfersAcrossReaders delivers the two sides of a transfer
+// read by two readers, in separate batches, and checks that the budget ends
+// up with each side exactly once.
+func TestDeliverTransfersAcrossReaders(t *testing.T) {
+	checking := ynabber.Account{IBAN: "IBAN1"}
+	savings := ynabber.Account{IBAN: "IBAN2"}
+	date := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	plainOut := ynabber.Transaction{Account: checking, ID: "out", Date: date, Payee: "Savings", Amount: -5000}
+	out := plainOut
+	out.Transfer = savings
+	in := ynabber.Transaction{Account: savings, ID: "in", Date: date.AddDate(0, 0, 1), Payee: "Checking", Amount: 5000, Transfer: checking}
+
+	tests := []struct {
+		name    string
+		batches [][]ynabber.Transaction
+		// imported are the payees of the transactions imported, in order
+		imported []string
+	}{
+		{
+			// The transfer transformer doesn't know savings yet when the
+			// checking batch is read, so the sending side is ordinary
+			// spending and the receiving side must be imported on its own
+			name:     "first run",
+			batches:  [][]ynabber.Transaction{{plainOut}, {in}},
+			imported: []string{"Savings", "Checking"},
+		},
+		{
+			name:     "accounts known",
+			batches:  [][]ynabber.Transaction{{out}, {in}},
+			imported: []string{"payee-savings"},
+		},
+		{
+			name:     "receiving side read first",
+			batches:  [][]ynabber.Transaction{{in}, {out}},
+			imported: []string{"Checking", "Savings"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := &budgetClient{payees: []client.Payee{
+				{ID: "payee-checking", TransferAccount: "account-1"},
+				{ID: "payee-savings", TransferAccount: "account-2"},
+			}}
+			writer := Writer{
+				Config: Config{
+					BudgetID:   "budget-1",
+					AccountMap: AccountMap{"IBAN1": "account-1", "IBAN2": "account-2"},
+				},
+				logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+				now:    func() time.Time { return time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC) },
+				client: budget,
+			}
+			for _, batch := range tt.batches {
+				delivered, err := writer.Deliver(context.Background(), batch)
+				if err != nil {
+					t.Fatalf("Deliver() error = %v", err)
+				}
+				if len(delivered) != len(batch) {
+					t.Errorf("delivered %d transactions, want %d", len(delivered), len(batch))
+				}
+			}
+
+			var imported []string
+			for _, t := range budget.imported {
+				imported = append(imported, t.PayeeName+t.Payee)
+			}
+			if fmt.Sprint(imported) != fmt.Sprint(tt.imported) {
+				t.Errorf("imported %q, want %q", imported, tt.imported)
+			}
+			balances := make(map[string][2]int64)
+			for _, t := range budget.transactions {
+				balance := balances[t.Account]
+				balances[t.Account] = [2]int64{balance[0] + 1, balance[1] + t.Amount}
+			}
+			want := map[string][2]int64{"account-1": {1, -500}, "account-2": {1, 500}}
+			if fmt.Sprint(balances) != fmt.Sprint(want) {
+				t.Errorf("budget has %v (count, sum), want each side once: %v", balances, want)
+			}
+		})
+	}
+}
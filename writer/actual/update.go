@@ -0,0 +1,85 @@
+package actual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/writer/actual/client"
+)
+
+// Update implements ynabber.Updater. It looks up the transactions imported
+// for the previous versions and sets their notes and imported payee to those
+// of the current versions, where ACTUAL_UPDATE_NOTES and
+// ACTUAL_UPDATE_IMPORTED_PAYEE allow it. The payee is left alone since Actual
+// only accepts it by ID.
+//
+// The transactions are looked up with one request per account, from the
+// oldest date. Actual has no bulk update, so each change is its own request.
+// Transactions that fail to map are logged and left out.
+func (w Writer) Update(ctx context.Context, enrichments []ynabber.Enrichment) error {
+	editor, ok := w.client.(transactionEditor)
+	if !ok {
+		return errors.New("client can't edit transactions")
+	}
+
+	type enrichment struct {
+		written, updated client.Transaction
+	}
+	accounts := make(map[string][]enrichment)
+	since := make(map[string]time.Time)
+	for _, e := range enrichments {
+		written, accountID, err := w.toActual(e.Previous)
+		if err != nil {
+			w.logger.Error("mapping transaction", "transaction", e.Previous, "error", err)
+			continue
+		}
+		updated, _, err := w.toActual(e.Current)
+		if err != nil {
+			w.logger.Error("mapping transaction", "transaction", e.Current, "error", err)
+			continue
+		}
+		accounts[accountID] = append(accounts[accountID], enrichment{written, updated})
+		if oldest, ok := since[accountID]; !ok || e.Previous.Date.Before(oldest) {
+			since[accountID] = e.Previous.Date
+		}
+	}
+
+	for _, accountID := range slices.Sorted(maps.Keys(accounts)) {
+		transactions, err := editor.Transactions(ctx, w.Config.BudgetID, accountID, since[accountID])
+		if err != nil {
+			return fmt.Errorf("listing transactions of account %s: %w", accountID, err)
+		}
+		byImportedID := make(map[string]client.Transaction, len(transactions))
+		for _, t := range transactions {
+			byImportedID[t.ImportedID] = t
+		}
+
+		for _, pair := range accounts[accountID] {
+			written, updated := pair.written, pair.updated
+			existing, ok := byImportedID[written.ImportedID]
+			if !ok {
+				continue
+			}
+			var change client.TransactionUpdate
+			if updated.Notes != written.Notes && w.Config.UpdateNotes.Allows(written.Notes, existing.Notes) {
+				change.Notes = &updated.Notes
+			}
+			if updated.ImportedPayee != written.ImportedPayee && w.Config.UpdateImportedPayee.Allows(written.ImportedPayee, existing.ImportedPayee) {
+				change.ImportedPayee = &updated.ImportedPayee
+			}
+			if change.Notes == nil && change.ImportedPayee == nil {
+				w.logger.Debug("keeping transaction as it is", "transaction", existing.ID, "update_notes", w.Config.UpdateNotes, "update_imported_payee", w.Config.UpdateImportedPayee)
+				continue
+			}
+			if err := editor.UpdateTransaction(ctx, w.Config.BudgetID, existing.ID, change); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package actual
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/writer/actual/client"
+)
+
+func TestUpdate(t *testing.T) {
+	previous := ynabber.Transaction{
+		Account: ynabber.Account{IBAN: "IBAN1"},
+		ID:      "tx-1",
+		Date:    time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+		Payee:   "VISA",
+		Memo:    "VISA",
+		Amount:  -1000,
+	}
+	current := previous
+	current.Memo = "VISA 1234 Grocer"
+
+	tests := []struct {
+		name          string
+		notes         ynabber.UpdatePolicy
+		importedPayee ynabber.UpdatePolicy
+		budgetNotes   string
+		wantNotes     bool
+		wantImported  bool
+	}{
+		{name: "unedited", notes: ynabber.UpdateUnedited, importedPayee: ynabber.UpdateUnedited, budgetNotes: "VISA", wantNotes: true, wantImported: true},
+		{name: "edited notes", notes: ynabber.UpdateUnedited, importedPayee: ynabber.UpdateUnedited, budgetNotes: "Lunch", wantImported: true},
+		{name: "never", notes: ynabber.UpdateNever, importedPayee: ynabber.UpdateNever, budgetNotes: "VISA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := &editingClient{
+				listingClient: listingClient{
+					existing: map[string][]client.Transaction{
+						"account-1": {{ID: "actual-1", ImportedID: makeID(previous), Notes: tt.budgetNotes, ImportedPayee: "VISA"}},
+					},
+					since: make(map[string]time.Time),
+				},
+				updated: make(map[string]client.TransactionUpdate),
+			}
+			writer := Writer{
+				Config: Config{
+					BudgetID:            "budget-1",
+					AccountMap:          AccountMap{"IBAN1": "account-1"},
+					UpdateNotes:         tt.notes,
+					UpdateImportedPayee: tt.importedPayee,
+				},
+				logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+				client: fc,
+			}
+			if err := writer.Update(context.Background(), []ynabber.Enrichment{{Previous: previous, Current: current}}); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			update, updated := fc.updated["actual-1"]
+			if updated != (tt.wantNotes || tt.wantImported) {
+				t.Fatalf("updated %+v, want update %v", fc.updated, tt.wantNotes || tt.wantImported)
+			}
+			if got := update.Notes != nil && *update.Notes == current.Memo; got != tt.wantNotes {
+				t.Errorf("notes = %v, want updated %v", update.Notes, tt.wantNotes)
+			}
+			if got := update.ImportedPayee != nil && *update.ImportedPayee == current.Memo; got != tt.wantImported {
+				t.Errorf("imported payee = %v, want updated %v", update.ImportedPayee, tt.wantImported)
+			}
+			if update.Amount != nil || update.Date != "" {
+				t.Errorf("update = %+v, want only notes and imported payee", update)
+			}
+		})
+	}
+}
+
+// countingClient counts the requests listing transactions
+type countingClient struct {
+	*editingClient
+	listed map[string]int
+}
+
+func (c *countingClient) Transactions(ctx context.Context, budgetID, accountID string, since time.Time) ([]client.Transaction, error) {
+	c.listed[accountID]++
+	return c.editingClient.Transactions(ctx, budgetID, accountID, since)
+}
+
+func TestUpdateListsOncePerAccount(t *testing.T) {
+	tx := func(id, iban string, day int) ynabber.Transaction {
+		return ynabber.Transaction{
+			Account: ynabber.Account{IBAN: iban},
+			ID:      ynabber.ID(id),
+			Date:    time.Date(2024, 5, day, 0, 0, 0, 0, time.UTC),
+			Memo:    "VISA",
+			Amount:  -1000,
+		}
+	}
+	previous := []ynabber.Transaction{tx("tx-1", "IBAN1", 10), tx("tx-2", "IBAN1", 8), tx("tx-3", "IBAN2", 9)}
+	existing := make(map[string][]client.Transaction)
+	var enrichments []ynabber.Enrichment
+	for i, p := range previous {
+		accountID := map[string]string{"IBAN1": "account-1", "IBAN2": "account-2"}[p.Account.IBAN]
+		existing[accountID] = append(existing[accountID], client.Transaction{ID: fmt.Sprintf("actual-%d", i+1), ImportedID: makeID(p), Notes: "VISA", ImportedPayee: "VISA"})
+		current := p
+		current.Memo = "VISA 1234 Grocer"
+		enrichments = append(enrichments, ynabber.Enrichment{Previous: p, Current: current})
+	}
+
+	fc := &countingClient{
+		editingClient: &editingClient{
+			listingClient: listingClient{existing: existing, since: make(map[string]time.Time)},
+			updated:       make(map[string]client.TransactionUpdate),
+		},
+		listed: make(map[string]int),
+	}
+	writer := Writer{
+		Config: Config{
+			BudgetID:    "budget-1",
+			AccountMap:  AccountMap{"IBAN1": "account-1", "IBAN2": "account-2"},
+			UpdateNotes: ynabber.UpdateUnedited,
+		},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		client: fc,
+	}
+	if err := writer.Update(context.Background(), enrichments); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if fc.listed["account-1"] != 1 || fc.listed["account-2"] != 1 {
+		t.Errorf("listed %v, want each account once", fc.listed)
+	}
+	if got := fc.since["account-1"]; !got.Equal(previous[1].Date) {
+		t.Errorf("listed account-1 since %v, want the oldest date %v", got, previous[1].Date)
+	}
+	if len(fc.updated) != 3 {
+		t.Errorf("updated %v, want all three transactions", fc.updated)
+	}
+}
@@ -11,6 +11,12 @@ import (
 	"github.com/martinohansen/ynabber"
 )
 
+func init() {
+	ynabber.RegisterWriter("json", func(ynabber.Options) (ynabber.Writer, error) {
+		return Writer{}, nil
+	}, nil)
+}
+
 type Writer struct{}
 
 func (w Writer) String() string {
@@ -26,6 +32,23 @@ func (w Writer) Bulk(tx []ynabber.Transaction) error {
 	return nil
 }
 
+// Deliver prints batch as JSON. Every transaction is delivered.
+func (w Writer) Deliver(_ context.Context, batch []ynabber.Transaction) ([]ynabber.Transaction, error) {
+	if err := w.Bulk(batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// Plan implements ynabber.Planner. Every transaction would be printed.
+func (w Writer) Plan(_ context.Context, batch []ynabber.Transaction) ([]ynabber.Change, error) {
+	changes := make([]ynabber.Change, len(batch))
+	for i, t := range batch {
+		changes[i] = ynabber.Change{Transaction: t, Action: ynabber.ActionCreate}
+	}
+	return changes, nil
+}
+
 func (w Writer) Runner(ctx context.Context, in <-chan []ynabber.Transaction) error {
 	for {
 		select {
@@ -0,0 +1,72 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// categoryIDs returns the categories of the budget, fetched through the
+// cache of the writer, see ynabber.CategoryCache.
+func (w Writer) categoryIDs(ctx context.Context, batch []ynabber.Transaction) (ynabber.Categories, error) {
+	return w.categories.Get(ctx, batch, w.fetchCategories)
+}
+
+// fetchCategories lists the categories of the budget that are not deleted.
+func (w Writer) fetchCategories(ctx context.Context) (ynabber.Categories, error) {
+	status, body, err := w.request(ctx, http.MethodGet, "/categories", nil)
+	if err != nil {
+		return nil, err
+	}
+	if status.code != http.StatusOK {
+		return nil, fmt.Errorf("failed to send request: %s", status)
+	}
+
+	var response struct {
+		Data struct {
+			CategoryGroups []struct {
+				Name       string `json:"name"`
+				Deleted    bool   `json:"deleted"`
+				Categories []struct {
+					ID      string `json:"id"`
+					Name    string `json:"name"`
+					Deleted bool   `json:"deleted"`
+				} `json:"categories"`
+			} `json:"category_groups"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("parsing response body: %w", err)
+	}
+
+	ids := make(ynabber.Categories)
+	for _, group := range response.Data.CategoryGroups {
+		if group.Deleted {
+			continue
+		}
+		for _, category := range group.Categories {
+			if category.Deleted {
+				continue
+			}
+			ids.Add(group.Name, category.Name, category.ID)
+		}
+	}
+	w.logger.Debug("fetched categories", "categories", len(ids))
+	return ids, nil
+}
+
+// categoryID returns the ID of the category named name in ids, or an empty
+// string if name is empty or the budget has no such category.
+func (w Writer) categoryID(ids ynabber.Categories, name string, t ynabber.Transaction) string {
+	if name == "" {
+		return ""
+	}
+	id, ok := ids.Lookup(name)
+	if !ok {
+		w.logger.Warn("no such category in budget", "category", name, "transaction", t.ID)
+	}
+	return id
+}
@@ -0,0 +1,157 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+func TestDeliverCategories(t *testing.T) {
+	account := ynabber.Account{IBAN: "checking"}
+	date := time.Now().UTC().AddDate(0, 0, -2)
+	batch := []ynabber.Transaction{
+		{Account: account, ID: "groceries", Date: date, Payee: "Grocer", Amount: -1000, Category: "groceries"},
+		{Account: account, ID: "qualified", Date: date, Payee: "Cafe", Amount: -2000, Category: "Fun: Eating Out"},
+		{Account: account, ID: "new", Date: date, Payee: "Shop", Amount: -3000, Category: "New"},
+		{Account: account, ID: "none", Date: date, Payee: "Bank", Amount: -4000},
+		{Account: account, ID: "unknown", Date: date, Payee: "Kiosk", Amount: -5000, Category: "Unknown"},
+	}
+	// New is only read after it is added to the budget
+	first := slices.Delete(slices.Clone(batch), 2, 3)
+
+	var sent []Transactions
+	lookups := 0
+	added := ""
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+			lookups++
+			if got, want := request.URL.Path, "/budgets/budget-id/categories"; got != want {
+				t.Errorf("path = %q, want %q", got, want)
+			}
+			fmt.Fprintf(response, `{"data":{"category_groups":[
+				{"name":"Bills","categories":[{"id":"cat-groceries","name":"Groceries"},{"id":"cat-old","name":"Old","deleted":true}]},
+				{"name":"Fun","categories":[{"id":"cat-eating-out","name":"Eating Out"}]},
+				{"name":"Other","categories":[{"id":"cat-other-eating-out","name":"Eating Out"}%s]}
+			]}}`, added)
+		case http.MethodPost:
+			body, _ := io.ReadAll(request.Body)
+			var transactions Transactions
+			if err := json.Unmarshal(body, &transactions); err != nil {
+				t.Errorf("parsing request body: %v", err)
+			}
+			sent = append(sent, transactions)
+			response.WriteHeader(http.StatusCreated)
+			fmt.Fprint(response, `{"data":{}}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-id",
+			AccountMap: AccountMap{"checking": "ynab-checking"},
+		},
+		logger:     slog.Default(),
+		client:     server.Client(),
+		baseURL:    server.URL,
+		categories: &ynabber.CategoryCache{},
+	}
+	for i, delivery := range [][]ynabber.Transaction{first, batch, batch} {
+		if _, err := writer.Deliver(context.Background(), delivery); err != nil {
+			t.Fatalf("Deliver() error = %v", err)
+		}
+		if i == 0 {
+			added = `,{"id":"cat-new","name":"New"}`
+		}
+	}
+	// Unknown is missing every time, but only fetched for once
+	if lookups != 2 {
+		t.Errorf("fetched categories %d times, want twice, the second time for the new category", lookups)
+	}
+
+	if len(sent) != 3 || len(sent[0].Transactions) != 4 || len(sent[2].Transactions) != 5 {
+		t.Fatalf("sent %+v, want every transaction read", sent)
+	}
+	want := []string{"cat-groceries", "cat-eating-out", "cat-new", "", ""}
+	for _, transactions := range sent[1:] {
+		for i, got := range transactions.Transactions {
+			if got.CategoryID != want[i] {
+				t.Errorf("category of %s = %q, want %q", batch[i].ID, got.CategoryID, want[i])
+			}
+		}
+	}
+}
+
+func TestDeliverSplits(t *testing.T) {
+	date := time.Now().UTC().AddDate(0, 0, -2)
+	mortgage := ynabber.Transaction{
+		Account:  ynabber.Account{IBAN: "checking"},
+		ID:       "mortgage",
+		Date:     date,
+		Payee:    "Bank",
+		Amount:   -10000000,
+		Category: "Mortgage",
+		Splits: []ynabber.Split{
+			{Amount: -3000000, Category: "Interest", Memo: "Interest"},
+			{Amount: -7000000, Category: "Principal"},
+		},
+	}
+	swapped := mortgage
+	swapped.Account = ynabber.Account{IBAN: "card"}
+
+	var sent Transactions
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+			fmt.Fprint(response, `{"data":{"category_groups":[{"name":"Bills","categories":[
+				{"id":"cat-interest","name":"Interest"},{"id":"cat-principal","name":"Principal"},{"id":"cat-mortgage","name":"Mortgage"}
+			]}]}}`)
+		case http.MethodPost:
+			if err := json.NewDecoder(request.Body).Decode(&sent); err != nil {
+				t.Errorf("parsing request body: %v", err)
+			}
+			response.WriteHeader(http.StatusCreated)
+			fmt.Fprint(response, `{"data":{}}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-id",
+			AccountMap: AccountMap{"checking": "ynab-checking", "card": "ynab-card"},
+			SwapFlow:   []string{"card"},
+		},
+		logger:  slog.Default(),
+		client:  server.Client(),
+		baseURL: server.URL,
+	}
+	if _, err := writer.Deliver(context.Background(), []ynabber.Transaction{mortgage, swapped}); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	if len(sent.Transactions) != 2 {
+		t.Fatalf("sent %+v, want both transactions", sent.Transactions)
+	}
+	want := []Subtransaction{
+		{Amount: "-3000000", CategoryID: "cat-interest", Memo: "Interest"},
+		{Amount: "-7000000", CategoryID: "cat-principal"},
+	}
+	if got := sent.Transactions[0]; got.CategoryID != "" || !reflect.DeepEqual(got.Subtransactions, want) {
+		t.Errorf("sent %+v, want subtransactions %+v and no category", got, want)
+	}
+	if got := sent.Transactions[1].Subtransactions; got[0].Amount != "3000000" || got[1].Amount != "7000000" {
+		t.Errorf("swapped subtransactions = %+v, want positive amounts", got)
+	}
+}
@@ -5,9 +5,16 @@ package ynab
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/martinohansen/ynabber"
 )
 
 const dateFormat = "2006-01-02"
@@ -66,7 +73,7 @@ type Config struct {
 
 	// Token is your personal access token obtained from the YNAB developer
 	// settings section
-	Token string `envconfig:"YNAB_TOKEN"`
+	Token string `envconfig:"YNAB_TOKEN" secret:"true"`
 
 	// AccountMap maps reader accounts to YNAB accounts. See reader for more
 	// details. For example: '{"<IBAN, BBAN or CPAN>": "<YNAB Account ID>"}'
@@ -76,16 +83,124 @@ type Config struct {
 	FromDate Date `envconfig:"YNAB_FROM_DATE"`
 
 	// Delay sending transactions to YNAB by this duration. This can be
-	// necessary if the bank changes transaction IDs after some time, or
-	// enriches remittance information after booking (which can cause duplicate
-	// imports). Default is 0 (no delay).
+	// necessary if the bank changes transaction IDs after some time, for
+	// example when it enriches remittance information after booking and the
+	// ID is derived from it (which can cause duplicate imports). Enriched
+	// transactions that keep their ID are updated in place instead. Default is
+	// 0 (no delay).
 	Delay time.Duration `envconfig:"YNAB_DELAY" default:"0"`
 
+	// UpdatePayee decides whether the payee of an imported transaction is
+	// updated when the bank enriches it later. Possible values: never,
+	// unedited (only if it wasn't changed in YNAB, including by payee rename
+	// rules), always.
+	UpdatePayee ynabber.UpdatePolicy `envconfig:"YNAB_UPDATE_PAYEE" default:"unedited"`
+
+	// UpdateMemo decides whether the memo of an imported transaction is
+	// updated when the bank enriches it later. Possible values: never,
+	// unedited (only if it wasn't changed in YNAB), always.
+	UpdateMemo ynabber.UpdatePolicy `envconfig:"YNAB_UPDATE_MEMO" default:"unedited"`
+
+	// Match looks for transactions entered by hand before creating new ones.
+	// A transaction matches one without an import ID with the same amount in
+	// the same account, dated at most MatchDays apart, whose payee is at
+	// least MatchSimilarity similar. The match is cleared instead of a new
+	// transaction being created, and remembered in ynab-matches.json in
+	// YNABBER_DATADIR.
+	Match bool `envconfig:"YNAB_MATCH" default:"false"`
+
+	// MatchDays is how many days apart a transaction entered by hand may be
+	// dated to match.
+	MatchDays int `envconfig:"YNAB_MATCH_DAYS" default:"3"`
+
+	// MatchSimilarity is how similar, from 0 to 1, the payee of a transaction
+	// entered by hand must be to match. Case and punctuation are ignored and
+	// a payee contained in the other, like "Rema" in "REMA 1000", is fully
+	// similar. Set to 0 to match by amount and date only.
+	MatchSimilarity float64 `envconfig:"YNAB_MATCH_SIMILARITY" default:"0.5"`
+
+	// ReimportDeleted controls whether transactions that were imported and
+	// then deleted in YNAB are imported again. When false, Ynabber follows
+	// the deletions with delta requests and remembers them in
+	// ynab-deleted.json in YNABBER_DATADIR, since YNAB eventually forgets
+	// their import IDs. Default is false.
+	ReimportDeleted bool `envconfig:"YNAB_REIMPORT_DELETED" default:"false"`
+
 	// Cleared sets the transaction status. Possible values: cleared, uncleared,
 	// reconciled.
 	Cleared TransactionStatus `envconfig:"YNAB_CLEARED" default:"cleared"`
 
 	// SwapFlow reverses inflow to outflow and vice versa for any account
 	// identified by IBAN or ID. Example: "DK9520000123456789,NO8330001234567"
+	//
+	// Deprecated: add swapflow to YNABBER_TRANSFORMERS and set
+	// SWAPFLOW_ACCOUNTS instead. The transformer swaps the accounts for every
+	// writer and keeps the import IDs YNAB_SWAPFLOW gives, so switching
+	// doesn't duplicate transactions in YNAB.
 	SwapFlow []string `envconfig:"YNAB_SWAPFLOW"`
 }
+
+// uuid matches the IDs YNAB uses for budgets and accounts.
+var uuid = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Check implements ynabber.Checker without contacting YNAB.
+func (c *Config) Check() error {
+	var errs []error
+	if c.BudgetID == "" {
+		errs = append(errs, errors.New("YNAB_BUDGETID: missing, copy it from the URL of your budget"))
+	} else if c.BudgetID != "last-used" && c.BudgetID != "default" && !uuid.MatchString(c.BudgetID) {
+		errs = append(errs, fmt.Errorf("YNAB_BUDGETID: %q is not a YNAB budget ID", c.BudgetID))
+	}
+	if c.Token == "" {
+		errs = append(errs, errors.New("YNAB_TOKEN: missing, create one under Developer Settings in YNAB"))
+	}
+	if len(c.AccountMap) == 0 {
+		errs = append(errs, errors.New("YNAB_ACCOUNTMAP: no accounts mapped"))
+	}
+	for _, account := range slices.Sorted(maps.Keys(c.AccountMap)) {
+		id := c.AccountMap[account]
+		if err := ynabber.CheckAccount(account); err != nil {
+			errs = append(errs, fmt.Errorf("YNAB_ACCOUNTMAP: %w", err))
+		}
+		if !uuid.MatchString(id) {
+			errs = append(errs, fmt.Errorf("YNAB_ACCOUNTMAP: %q for %s is not a YNAB account ID", id, account))
+		}
+	}
+	for _, account := range c.SwapFlow {
+		if err := ynabber.CheckAccount(account); err != nil {
+			errs = append(errs, fmt.Errorf("YNAB_SWAPFLOW: %w", err))
+		}
+	}
+	if from := time.Time(c.FromDate); from.After(time.Now()) {
+		errs = append(errs, fmt.Errorf("YNAB_FROM_DATE: %s is in the future", from.Format(dateFormat)))
+	}
+	if c.Delay < 0 {
+		errs = append(errs, errors.New("YNAB_DELAY: must not be negative"))
+	}
+	if c.MatchDays < 0 {
+		errs = append(errs, errors.New("YNAB_MATCH_DAYS: must not be negative"))
+	}
+	if c.MatchSimilarity < 0 || c.MatchSimilarity > 1 {
+		errs = append(errs, errors.New("YNAB_MATCH_SIMILARITY: must be between 0 and 1"))
+	}
+	return errors.Join(errs...)
+}
+
+// LogValue returns config with sensitive information redacted
+func (c *Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("budget_id", c.BudgetID),
+		slog.String("token", "******"),
+		slog.Any("account_map", c.AccountMap),
+		slog.Time("from_date", time.Time(c.FromDate)),
+		slog.Duration("delay", c.Delay),
+		slog.String("update_payee", string(c.UpdatePayee)),
+		slog.String("update_memo", string(c.UpdateMemo)),
+		slog.Bool("match", c.Match),
+		slog.Int("match_days", c.MatchDays),
+		slog.Float64("match_similarity", c.MatchSimilarity),
+		slog.Bool("reimport_deleted", c.ReimportDeleted),
+		slog.String("cleared", c.Cleared.String()),
+		slog.String("swap_flow", strings.Join(c.SwapFlow, ",")),
+	)
+}
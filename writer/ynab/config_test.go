@@ -1,6 +1,7 @@
 package ynab
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -35,3 +36,55 @@ func TestDateDecode(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigCheck(t *testing.T) {
+	valid := func() Config {
+		return Config{
+			BudgetID:   "b9d1a2c3-4e5f-4a6b-8c7d-9e0f1a2b3c4d",
+			Token:      "secret",
+			AccountMap: AccountMap{"NO8330001234567": "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"},
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{name: "valid", modify: func(*Config) {}},
+		{name: "missing token", modify: func(c *Config) { c.Token = "" }, want: "YNAB_TOKEN"},
+		{name: "budget id", modify: func(c *Config) { c.BudgetID = "my budget" }, want: "YNAB_BUDGETID"},
+		{name: "last used budget", modify: func(c *Config) { c.BudgetID = "last-used" }},
+		{name: "empty account map", modify: func(c *Config) { c.AccountMap = nil }, want: "YNAB_ACCOUNTMAP"},
+		{
+			name:   "mistyped IBAN",
+			modify: func(c *Config) { c.AccountMap = AccountMap{"NO8330001234568": "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"} },
+			want:   "checksum",
+		},
+		{
+			name:   "account name instead of ID",
+			modify: func(c *Config) { c.AccountMap = AccountMap{"NO8330001234567": "Checking"} },
+			want:   "not a YNAB account ID",
+		},
+		{
+			name:   "future from date",
+			modify: func(c *Config) { c.FromDate = Date(time.Now().AddDate(1, 0, 0)) },
+			want:   "YNAB_FROM_DATE",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(&cfg)
+			err := cfg.Check()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Check() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Check() = %v, want error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/martinohansen/ynabber/internal/atomicfile"
+)
+
+// deletedFile is the file in YNABBER_DATADIR remembering the transactions
+// deleted in YNAB.
+const deletedFile = "ynab-deleted.json"
+
+// deletedStore remembers the import IDs of transactions deleted in YNAB so
+// they are not imported again. YNAB forgets the import IDs of deleted
+// transactions after a while, for example when the account is reconciled, and
+// would then accept them as new.
+//
+// The store follows the budget with delta requests: YNAB returns the
+// transactions changed since the server knowledge of the previous request,
+// including deleted ones, so only the first request of a budget reads more
+// than the latest changes.
+type deletedStore struct {
+	mu    sync.Mutex
+	path  string
+	state deletedState
+}
+
+// deletedState is the content of the store file.
+type deletedState struct {
+	// BudgetID is the budget ServerKnowledge belongs to. The store starts
+	// over if YNAB_BUDGETID changes.
+	BudgetID string `json:"budget_id"`
+	// ServerKnowledge is the server knowledge of the budget transactions as
+	// of the last request.
+	ServerKnowledge int64 `json:"server_knowledge"`
+	// Deleted holds the transactions deleted in YNAB, keyed like
+	// "account/import ID".
+	Deleted map[string]bool `json:"deleted"`
+	// Removed holds the transactions Ynabber deleted itself, like pending
+	// transactions the bank dropped, which may be imported again.
+	Removed map[string]bool `json:"removed,omitempty"`
+}
+
+// openDeletedStore reads the deleted store at path. A missing file is an
+// empty store.
+func openDeletedStore(path string) (*deletedStore, error) {
+	s := &deletedStore{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading deleted transactions: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &s.state); err != nil {
+			return nil, fmt.Errorf("parsing deleted transactions %s: %w", path, err)
+		}
+	}
+	if s.state.Deleted == nil {
+		s.state.Deleted = make(map[string]bool)
+	}
+	if s.state.Removed == nil {
+		s.state.Removed = make(map[string]bool)
+	}
+	return s, nil
+}
+
+// save writes the store to its file. The caller must hold s.mu.
+func (s *deletedStore) save() error {
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling deleted transactions: %w", err)
+	}
+	if err := atomicfile.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("writing deleted transactions: %w", err)
+	}
+	return nil
+}
+
+// remove records that Ynabber deleted the transaction with key itself, so
+// it isn't mistaken for one deleted in YNAB.
+func (s *deletedStore) remove(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Removed[key] = true
+	return s.save()
+}
+
+// deletedTransaction is a transaction in a delta response.
+type deletedTransaction struct {
+	AccountID string `json:"account_id"`
+	ImportID  string `json:"import_id"`
+	Deleted   bool   `json:"deleted"`
+}
+
+// deletedTransactions returns the transactions deleted in YNAB, keyed like
+// "account/import ID", after requesting the changes since the last call and
+// saving the server knowledge. It returns nil if YNAB_REIMPORT_DELETED is
+// enabled.
+func (w Writer) deletedTransactions(ctx context.Context) (map[string]bool, error) {
+	return w.followDeleted(ctx, true)
+}
+
+// peekDeleted is deletedTransactions without saving, for Plan. The changes
+// it reads are read again by the next delivery.
+func (w Writer) peekDeleted(ctx context.Context) (map[string]bool, error) {
+	return w.followDeleted(ctx, false)
+}
+
+// followDeleted requests the changes to the budget since the server
+// knowledge in the store and returns the transactions deleted in YNAB. The
+// store and its file are only updated if save is set.
+func (w Writer) followDeleted(ctx context.Context, save bool) (map[string]bool, error) {
+	s := w.deleted
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := deletedState{
+		BudgetID: w.Config.BudgetID,
+		Deleted:  make(map[string]bool),
+		Removed:  make(map[string]bool),
+	}
+	if s.state.BudgetID == w.Config.BudgetID {
+		state.ServerKnowledge = s.state.ServerKnowledge
+		maps.Copy(state.Deleted, s.state.Deleted)
+		maps.Copy(state.Removed, s.state.Removed)
+	}
+
+	// The first request only needs the server knowledge, since YNAB leaves
+	// deleted transactions out of full responses, so it asks for today's
+	// transactions to keep the response small.
+	now := time.Now()
+	if w.now != nil {
+		now = w.now()
+	}
+	query := url.Values{"since_date": {now.Format(dateFormat)}}
+	if state.ServerKnowledge > 0 {
+		query = url.Values{"last_knowledge_of_server": {strconv.FormatInt(state.ServerKnowledge, 10)}}
+	}
+	status, body, err := w.request(ctx, http.MethodGet, "/transactions?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if status.code != http.StatusOK {
+		return nil, fmt.Errorf("failed to send request: %s", status)
+	}
+
+	var response struct {
+		Data struct {
+			Transactions    []deletedTransaction `json:"transactions"`
+			ServerKnowledge int64                `json:"server_knowledge"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("parsing response body: %w", err)
+	}
+
+	for _, t := range response.Data.Transactions {
+		if !strings.HasPrefix(t.ImportID, "YBBR:") {
+			continue
+		}
+		key := t.AccountID + "/" + t.ImportID
+		switch {
+		case !t.Deleted:
+			// Imported again, for example with YNAB_REIMPORT_DELETED
+			delete(state.Deleted, key)
+		case state.Removed[key]:
+			delete(state.Removed, key)
+		default:
+			if save {
+				w.logger.Info("transaction deleted in YNAB, it won't be imported again", "import_id", t.ImportID)
+			}
+			state.Deleted[key] = true
+		}
+	}
+	state.ServerKnowledge = response.Data.ServerKnowledge
+	if !save {
+		return state.Deleted, nil
+	}
+	s.state = state
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+	return maps.Clone(state.Deleted), nil
+}
@@ -0,0 +1,287 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// deltaServer answers transaction requests with the transactions in delta
+// once the client has server knowledge, and records what it was asked and
+// sent.
+type deltaServer struct {
+	delta     string
+	knowledge []string
+	created   []Transaction
+}
+
+func (s *deltaServer) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		knowledge := request.URL.Query().Get("last_knowledge_of_server")
+		s.knowledge = append(s.knowledge, knowledge)
+		transactions := "[]"
+		if knowledge != "" {
+			transactions = s.delta
+		}
+		fmt.Fprintf(response, `{"data":{"transactions":%s,"server_knowledge":%d}}`, transactions, 10+len(s.knowledge))
+	case http.MethodPost:
+		var body Transactions
+		json.NewDecoder(request.Body).Decode(&body)
+		s.created = append(s.created, body.Transactions...)
+		response.WriteHeader(http.StatusCreated)
+		fmt.Fprint(response, `{"data":{}}`)
+	}
+}
+
+func TestDeliverSkipsDeletedTransactions(t *testing.T) {
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -2)
+	tx := func(id string) ynabber.Transaction {
+		return ynabber.Transaction{Account: ynabber.Account{IBAN: "checking"}, ID: ynabber.ID(id), Date: day, Payee: "Shop", Amount: -10000}
+	}
+	deletedTx, removedTx, keptTx := tx("deleted"), tx("removed"), tx("kept")
+
+	server := &deltaServer{delta: fmt.Sprintf(`[
+		{"account_id":"ynab-checking","import_id":%q,"deleted":true},
+		{"account_id":"ynab-checking","import_id":%q,"deleted":true},
+		{"account_id":"ynab-checking","import_id":"YNAB:-10000:2024-01-01:1","deleted":true}
+	]`, makeID(deletedTx), makeID(removedTx))}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	path := filepath.Join(t.TempDir(), deletedFile)
+	store, err := openDeletedStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-id",
+			AccountMap: AccountMap{"checking": "ynab-checking"},
+			Cleared:    Cleared,
+		},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		client:  httpServer.Client(),
+		baseURL: httpServer.URL,
+		deleted: store,
+	}
+
+	// The first run only learns the server knowledge
+	if _, err := writer.Deliver(context.Background(), []ynabber.Transaction{keptTx}); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	// Ynabber deleted this one itself, like a pending transaction the bank
+	// dropped
+	if err := store.remove("ynab-checking/" + makeID(removedTx)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later run gets the deletions since then from a reopened store
+	if writer.deleted, err = openDeletedStore(path); err != nil {
+		t.Fatal(err)
+	}
+	server.created = nil
+	delivered, err := writer.Deliver(context.Background(), []ynabber.Transaction{deletedTx, removedTx, keptTx})
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if want := []string{"", "11"}; fmt.Sprint(server.knowledge) != fmt.Sprint(want) {
+		t.Errorf("last_knowledge_of_server = %q, want %q", server.knowledge, want)
+	}
+	if len(delivered) != 3 {
+		t.Errorf("delivered %d transactions, want all 3", len(delivered))
+	}
+	var created []string
+	for _, c := range server.created {
+		created = append(created, c.ImportID)
+	}
+	if want := []string{makeID(removedTx), makeID(keptTx)}; fmt.Sprint(created) != fmt.Sprint(want) {
+		t.Errorf("created %q, want %q", created, want)
+	}
+
+	// The deletion is remembered after YNAB stops reporting it
+	reopened, err := openDeletedStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reopened.state.Deleted["ynab-checking/"+makeID(deletedTx)] || len(reopened.state.Deleted) != 1 {
+		t.Errorf("deleted = %v, want only the transaction deleted in YNAB", reopened.state.Deleted)
+	}
+	if reopened.state.ServerKnowledge != 12 {
+		t.Errorf("server knowledge = %d, want 12", reopened.state.ServerKnowledge)
+	}
+}
+
+func TestDeletedStoreStartsOverForNewBudget(t *testing.T) {
+	server := &deltaServer{}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	store, err := openDeletedStore(filepath.Join(t.TempDir(), deletedFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.state = deletedState{BudgetID: "old-budget", ServerKnowledge: 100, Deleted: map[string]bool{"a/YBBR:1": true}}
+	writer := Writer{
+		Config:  Config{BudgetID: "new-budget"},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		client:  httpServer.Client(),
+		baseURL: httpServer.URL,
+		deleted: store,
+	}
+	deleted, err := writer.deletedTransactions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 0 || server.knowledge[0] != "" {
+		t.Errorf("deleted = %v after asking with knowledge %q, want a fresh start", deleted, server.knowledge[0])
+	}
+}
+
+func TestPlanLeavesDeletedStoreAlone(t *testing.T) {
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -2)
+	deletedTx := ynabber.Transaction{Account: ynabber.Account{IBAN: "checking"}, ID: "deleted", Date: day, Payee: "Shop", Amount: -10000}
+	server := &deltaServer{delta: fmt.Sprintf(`[{"account_id":"ynab-checking","import_id":%q,"deleted":true}]`, makeID(deletedTx))}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	path := filepath.Join(t.TempDir(), deletedFile)
+	store, err := openDeletedStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.state.BudgetID, store.state.ServerKnowledge = "budget-id", 5
+	if err := store.save(); err != nil {
+		t.Fatal(err)
+	}
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-id",
+			AccountMap: AccountMap{"checking": "ynab-checking"},
+			Cleared:    Cleared,
+		},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		client:  httpServer.Client(),
+		baseURL: httpServer.URL,
+		deleted: store,
+	}
+
+	changes, err := writer.Plan(context.Background(), []ynabber.Transaction{deletedTx})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if changes[0].Action != ynabber.ActionDuplicate {
+		t.Errorf("Plan() = %+v, want the deleted transaction as a duplicate", changes)
+	}
+	reopened, err := openDeletedStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.state.ServerKnowledge != 5 || len(reopened.state.Deleted) != 0 || store.state.ServerKnowledge != 5 {
+		t.Errorf("Plan() saved the store: %+v", reopened.state)
+	}
+
+	// The next delivery still sees the deletion the plan saw
+	if _, err := writer.Deliver(context.Background(), []ynabber.Transaction{deletedTx}); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if len(server.created) != 0 {
+		t.Errorf("created %+v, want nothing", server.created)
+	}
+	if !store.state.Deleted["ynab-checking/"+makeID(deletedTx)] {
+		t.Errorf("deleted = %v, want the transaction recorded by Deliver", store.state.Deleted)
+	}
+}
+
+// TestNewWriterTracksDeletions checks that deletions are followed by
+// default, without YNAB_REIMPORT_DELETED.
+func TestNewWriterTracksDeletions(t *testing.T) {
+	t.Setenv("YNAB_BUDGETID", "budget-id")
+	t.Setenv("YNAB_TOKEN", "token")
+	t.Setenv("YNAB_ACCOUNTMAP", `{"checking": "ynab-checking"}`)
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -2)
+	deletedTx := ynabber.Transaction{Account: ynabber.Account{IBAN: "checking"}, ID: "deleted", Date: day, Payee: "Shop", Amount: -10000}
+	server := &deltaServer{delta: fmt.Sprintf(`[{"account_id":"ynab-checking","import_id":%q,"deleted":true}]`, makeID(deletedTx))}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	dir := t.TempDir()
+	writer, err := NewWriter(dir)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	writer.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	writer.client, writer.baseURL = httpServer.Client(), httpServer.URL
+
+	// The first delivery learns the server knowledge, the second the deletion
+	for range 2 {
+		if _, err := writer.Deliver(context.Background(), []ynabber.Transaction{deletedTx}); err != nil {
+			t.Fatalf("Deliver() error = %v", err)
+		}
+	}
+	if len(server.created) != 1 {
+		t.Errorf("created %+v, want the transaction only before it was deleted", server.created)
+	}
+	store, err := openDeletedStore(filepath.Join(dir, deletedFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !store.state.Deleted["ynab-checking/"+makeID(deletedTx)] {
+		t.Errorf("deleted = %v, want the transaction saved in the data directory", store.state.Deleted)
+	}
+
+	t.Setenv("YNAB_REIMPORT_DELETED", "true")
+	if writer, err = NewWriter(dir); err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if writer.deleted != nil {
+		t.Errorf("NewWriter() follows deletions with YNAB_REIMPORT_DELETED")
+	}
+}
+
+func TestRemoveRecordsDeletionOnlyOnSuccess(t *testing.T) {
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -2)
+	pending := ynabber.Transaction{Account: ynabber.Account{IBAN: "checking"}, ID: "pdng-1", Status: ynabber.StatusPending, Date: day, Payee: "Shop", Amount: -1000}
+	key := "ynab-checking/" + makeID(pending)
+
+	for _, code := range []int{http.StatusInternalServerError, http.StatusOK} {
+		server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			switch request.Method {
+			case http.MethodGet:
+				fmt.Fprintf(response, `{"data":{"transactions":[{"id":"ynab-1","import_id":%q}]}}`, makeID(pending))
+			case http.MethodDelete:
+				response.WriteHeader(code)
+				fmt.Fprint(response, `{"data":{}}`)
+			}
+		}))
+		store, err := openDeletedStore(filepath.Join(t.TempDir(), deletedFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer := Writer{
+			Config:  Config{BudgetID: "budget-id", AccountMap: AccountMap{"checking": "ynab-checking"}},
+			logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+			client:  server.Client(),
+			baseURL: server.URL,
+			deleted: store,
+		}
+		err = writer.Remove(context.Background(), pending)
+		if (err != nil) != (code != http.StatusOK) {
+			t.Errorf("Remove() with status %d error = %v", code, err)
+		}
+		if got, want := store.state.Removed[key], code == http.StatusOK; got != want {
+			t.Errorf("Remove() with status %d recorded the removal = %v, want %v", code, got, want)
+		}
+		server.Close()
+	}
+}
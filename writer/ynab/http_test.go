@@ -141,6 +141,51 @@ func TestBulkUsesProductionHTTPDefaults(t *testing.T) {
 	}
 }
 
+func TestDeliverReturnsSentTransactions(t *testing.T) {
+	t.Parallel()
+
+	writer, source := testHTTPWriter(&recordingHTTPClient{
+		response: &http.Response{
+			StatusCode: http.StatusCreated,
+			Status:     "201 Created",
+			Body:       io.NopCloser(strings.NewReader(`{"data":{}}`)),
+		},
+	}, "https://ynab.invalid/v1")
+
+	unmapped := source
+	unmapped.Account = ynabber.Account{IBAN: "unmapped-iban"}
+	tooOld := source
+	tooOld.Date = source.Date.AddDate(-6, 0, 0)
+
+	delivered, err := writer.Deliver(context.Background(), []ynabber.Transaction{source, unmapped, tooOld})
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if len(delivered) != 1 || delivered[0].Account.IBAN != source.Account.IBAN {
+		t.Fatalf("Deliver() = %+v, want only the mapped transaction", delivered)
+	}
+}
+
+func TestDeliverReturnsNothingOnAPIError(t *testing.T) {
+	t.Parallel()
+
+	writer, source := testHTTPWriter(&recordingHTTPClient{
+		response: &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Status:     "500 Internal Server Error",
+			Body:       io.NopCloser(strings.NewReader(`{}`)),
+		},
+	}, "https://ynab.invalid/v1")
+
+	delivered, err := writer.Deliver(context.Background(), []ynabber.Transaction{source})
+	if err == nil {
+		t.Fatal("Deliver() error = nil, want API error")
+	}
+	if len(delivered) != 0 {
+		t.Errorf("Deliver() = %+v, want no delivered transactions", delivered)
+	}
+}
+
 func testHTTPWriter(client httpClient, baseURL string) (Writer, ynabber.Transaction) {
 	now := time.Now().UTC()
 	source := ynabber.Transaction{
@@ -0,0 +1,325 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/martinohansen/ynabber"
+	"github.com/martinohansen/ynabber/internal/atomicfile"
+)
+
+// matchFile is the file in YNABBER_DATADIR remembering matched transactions.
+const matchFile = "ynab-matches.json"
+
+// matchStore remembers which transaction entered by hand every import ID was
+// matched to. YNAB can't add an import ID to an existing transaction, so
+// without it the bank transaction would be created again on the next run.
+type matchStore struct {
+	mu   sync.Mutex
+	path string
+	// matched maps import IDs to the ID of the YNAB transaction they were
+	// matched to.
+	matched map[string]string
+}
+
+// openMatchStore reads the match store at path. A missing file is an empty
+// store.
+func openMatchStore(path string) (*matchStore, error) {
+	s := &matchStore{path: path, matched: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading matches: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.matched); err != nil {
+		return nil, fmt.Errorf("parsing matches %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// lookup returns the YNAB transaction importID was matched to.
+func (s *matchStore) lookup(importID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.matched[importID]
+	return id, ok
+}
+
+// used returns the IDs of every YNAB transaction matched so far.
+func (s *matchStore) used() map[string]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	used := make(map[string]bool, len(s.matched))
+	for _, id := range s.matched {
+		used[id] = true
+	}
+	return used
+}
+
+// record remembers that the import IDs in matched, mapped to YNAB
+// transaction IDs, were matched and saves the store.
+func (s *matchStore) record(matched map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for importID, id := range matched {
+		s.matched[importID] = id
+	}
+	data, err := json.MarshalIndent(s.matched, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling matches: %w", err)
+	}
+	if err := atomicfile.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("writing matches: %w", err)
+	}
+	return nil
+}
+
+// manual is a transaction entered by hand in YNAB, without an import ID.
+type manual struct {
+	ID        string `json:"id"`
+	Date      string `json:"date"`
+	Amount    int64  `json:"amount"`
+	PayeeName string `json:"payee_name"`
+	Cleared   string `json:"cleared"`
+	ImportID  string `json:"import_id"`
+	Transfer  string `json:"transfer_account_id"`
+	Deleted   bool   `json:"deleted"`
+}
+
+// matchable reports whether source can be matched to a transaction entered
+// by hand. Pending transactions are left out since promoting them later looks
+// them up by import ID, and transfers between budget accounts since they are
+// sent with a transfer payee.
+func (w Writer) matchable(source ynabber.Transaction) bool {
+	_, transfer := w.transferAccount(source)
+	return source.Status != ynabber.StatusPending && !transfer
+}
+
+// findMatches returns the transaction entered by hand that each transaction
+// in transactions matches, by index. A transaction matches one with the same
+// amount in the same account, dated at most YNAB_MATCH_DAYS apart, whose
+// payee is at least YNAB_MATCH_SIMILARITY similar. The closest in date wins,
+// then the most similar payee, and each is matched at most once. Nothing is
+// written.
+func (w Writer) findMatches(ctx context.Context, transactions []Transaction, sources []ynabber.Transaction) (map[int]manual, error) {
+	days := w.Config.MatchDays
+	since := make(map[string]time.Time)
+	for i, transaction := range transactions {
+		if !w.matchable(sources[i]) {
+			continue
+		}
+		from := sources[i].Date.AddDate(0, 0, -days)
+		if s, ok := since[transaction.AccountID]; !ok || from.Before(s) {
+			since[transaction.AccountID] = from
+		}
+	}
+
+	used := make(map[string]bool)
+	if w.matches != nil {
+		used = w.matches.used()
+	}
+	candidates := make(map[string][]manual)
+	for accountID, from := range since {
+		manuals, err := w.manualTransactions(ctx, accountID, from)
+		if err != nil {
+			return nil, fmt.Errorf("listing transactions of account %s: %w", accountID, err)
+		}
+		for _, m := range manuals {
+			if !used[m.ID] {
+				candidates[accountID] = append(candidates[accountID], m)
+			}
+		}
+	}
+
+	found := make(map[int]manual)
+	for i, transaction := range transactions {
+		if !w.matchable(sources[i]) {
+			continue
+		}
+		best, bestDays, bestSimilarity := -1, 0, 0.0
+		for j, m := range candidates[transaction.AccountID] {
+			if used[m.ID] || strconv.FormatInt(m.Amount, 10) != transaction.Amount {
+				continue
+			}
+			apart, ok := ynabber.DaysApart(m.Date, transaction.Date)
+			if !ok || apart > days {
+				continue
+			}
+			similar := similarity(m.PayeeName, transaction.PayeeName)
+			if similar < w.Config.MatchSimilarity {
+				continue
+			}
+			if best == -1 || apart < bestDays || (apart == bestDays && similar > bestSimilarity) {
+				best, bestDays, bestSimilarity = j, apart, similar
+			}
+		}
+		if best != -1 {
+			m := candidates[transaction.AccountID][best]
+			used[m.ID] = true
+			found[i] = m
+		}
+	}
+	return found, nil
+}
+
+// manualTransactions returns the transactions entered by hand in accountID
+// since the date of since, leaving out reconciled and deleted ones and
+// transfers.
+func (w Writer) manualTransactions(ctx context.Context, accountID string, since time.Time) ([]manual, error) {
+	path := fmt.Sprintf("/accounts/%s/transactions?%s",
+		url.PathEscape(accountID),
+		url.Values{"since_date": {since.Format(dateFormat)}}.Encode(),
+	)
+	status, body, err := w.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status.code != http.StatusOK {
+		return nil, fmt.Errorf("failed to send request: %s", status)
+	}
+
+	var response struct {
+		Data struct {
+			Transactions []manual `json:"transactions"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("parsing response body: %w", err)
+	}
+	var manuals []manual
+	for _, m := range response.Data.Transactions {
+		if m.ImportID == "" && !m.Deleted && m.Transfer == "" && m.Cleared != string(Reconciled) {
+			manuals = append(manuals, m)
+		}
+	}
+	return manuals, nil
+}
+
+// clearing sets the cleared status of the transaction with ID.
+type clearing struct {
+	ID      string `json:"id"`
+	Cleared string `json:"cleared"`
+}
+
+// matchManual matches transactions to the ones entered by hand, see
+// findMatches, and clears those instead of creating new ones. Transactions
+// matched on an earlier run are left out too. It returns the transactions
+// left to create along with their sources, and the sources of the ones that
+// were matched.
+func (w Writer) matchManual(ctx context.Context, transactions []Transaction, sources []ynabber.Transaction) ([]Transaction, []ynabber.Transaction, []ynabber.Transaction, error) {
+	var matched []ynabber.Transaction
+	if w.matches != nil {
+		var keptTransactions []Transaction
+		var keptSources []ynabber.Transaction
+		for i, transaction := range transactions {
+			if id, ok := w.matches.lookup(transaction.ImportID); ok {
+				w.logger.Debug("matched on an earlier run", "transaction", sources[i].ID, "ynab_id", id)
+				matched = append(matched, sources[i])
+				continue
+			}
+			keptTransactions = append(keptTransactions, transaction)
+			keptSources = append(keptSources, sources[i])
+		}
+		transactions, sources = keptTransactions, keptSources
+	}
+
+	found, err := w.findMatches(ctx, transactions, sources)
+	if err != nil || len(found) == 0 {
+		return transactions, sources, matched, err
+	}
+
+	clearings := make([]clearing, 0, len(found))
+	importIDs := make(map[string]string, len(found))
+	var remainingTransactions []Transaction
+	var remainingSources []ynabber.Transaction
+	for i, transaction := range transactions {
+		m, ok := found[i]
+		if !ok {
+			remainingTransactions = append(remainingTransactions, transaction)
+			remainingSources = append(remainingSources, sources[i])
+			continue
+		}
+		w.logger.Info("matched transaction entered by hand", "transaction", sources[i].ID, "ynab_id", m.ID, "payee", m.PayeeName)
+		clearings = append(clearings, clearing{ID: m.ID, Cleared: transaction.Cleared})
+		importIDs[transaction.ImportID] = m.ID
+		matched = append(matched, sources[i])
+	}
+
+	payload, err := json.Marshal(struct {
+		Transactions []clearing `json:"transactions"`
+	}{clearings})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	status, _, err := w.request(ctx, http.MethodPatch, "/transactions", payload)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if status.code < 200 || status.code >= 300 {
+		return nil, nil, nil, fmt.Errorf("failed to send request: %s", status)
+	}
+	if w.matches != nil {
+		if err := w.matches.record(importIDs); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return remainingTransactions, remainingSources, matched, nil
+}
+
+// similarity returns how similar payees a and b are, from 0 to 1. Case,
+// punctuation and spacing are ignored, a payee contained in the other is
+// fully similar, like "Rema" in "REMA 1000 MAJORSTUEN", and other payees are
+// compared by the letter pairs they share.
+func similarity(a, b string) float64 {
+	a, b = normalizePayee(a), normalizePayee(b)
+	switch {
+	case a == "" || b == "":
+		return 0
+	case strings.Contains(a, b) || strings.Contains(b, a):
+		return 1
+	}
+
+	pairs := func(s string) map[string]int {
+		s = strings.ReplaceAll(s, " ", "")
+		counts := make(map[string]int)
+		r := []rune(s)
+		for i := 0; i+1 < len(r); i++ {
+			counts[string(r[i:i+2])]++
+		}
+		return counts
+	}
+	pa, pb := pairs(a), pairs(b)
+	total, shared := 0, 0
+	for pair, n := range pa {
+		total += n
+		shared += min(n, pb[pair])
+	}
+	for _, n := range pb {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	return 2 * float64(shared) / float64(total)
+}
+
+// normalizePayee lowercases payee and replaces everything but letters and
+// digits with single spaces.
+func normalizePayee(payee string) string {
+	fields := strings.FieldsFunc(strings.ToLower(payee), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,190 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{a: "Rema", b: "REMA 1000 MAJORSTUEN", min: 1, max: 1},
+		{a: "Coop Extra", b: "COOP-EXTRA  OSLO", min: 1, max: 1},
+		{a: "Starbucks", b: "STARBUCKS COFFEE", min: 1, max: 1},
+		{a: "Mc Donalds", b: "McDonald's Oslo S", min: 0.5, max: 0.9},
+		{a: "Rema", b: "Kiwi", min: 0, max: 0},
+		{a: "", b: "Kiwi", min: 0, max: 0},
+	}
+	for _, tt := range tests {
+		if got := similarity(tt.a, tt.b); got < tt.min || got > tt.max {
+			t.Errorf("similarity(%q, %q) = %.2f, want between %.2f and %.2f", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
+
+// manualServer serves the transactions in manuals for every account and
+// records the transactions patched and created.
+type manualServer struct {
+	manuals string
+	patched []clearing
+	created []Transaction
+}
+
+func (s *manualServer) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		fmt.Fprintf(response, `{"data":{"transactions":%s}}`, s.manuals)
+	case http.MethodPatch:
+		var body struct {
+			Transactions []clearing `json:"transactions"`
+		}
+		json.NewDecoder(request.Body).Decode(&body)
+		s.patched = append(s.patched, body.Transactions...)
+		fmt.Fprint(response, `{"data":{}}`)
+	case http.MethodPost:
+		var body Transactions
+		json.NewDecoder(request.Body).Decode(&body)
+		s.created = append(s.created, body.Transactions...)
+		response.WriteHeader(http.StatusCreated)
+		fmt.Fprint(response, `{"data":{}}`)
+	}
+}
+
+func TestDeliverMatchesManualTransactions(t *testing.T) {
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -5)
+	tx := func(id, payee string, amount ynabber.Milliunits) ynabber.Transaction {
+		return ynabber.Transaction{Account: ynabber.Account{IBAN: "checking"}, ID: ynabber.ID(id), Date: day, Payee: payee, Amount: amount}
+	}
+	grocer := tx("grocer", "REMA 1000 OSLO", -25000)
+	batch := []ynabber.Transaction{
+		grocer,
+		tx("grocer-again", "REMA 1000 OSLO", -25000),
+		tx("other-payee", "Kiwi", -12000),
+		tx("too-late", "Bakery", -5000),
+	}
+
+	server := &manualServer{manuals: fmt.Sprintf(`[
+		{"id":"manual-grocer","date":%q,"amount":-25000,"payee_name":"Rema","cleared":"uncleared"},
+		{"id":"manual-other","date":%q,"amount":-12000,"payee_name":"Bookshop","cleared":"uncleared"},
+		{"id":"manual-late","date":%q,"amount":-5000,"payee_name":"Bakery","cleared":"uncleared"},
+		{"id":"imported","date":%q,"amount":-25000,"payee_name":"Rema","cleared":"cleared","import_id":"YBBR:other"}
+	]`, day.AddDate(0, 0, 1).Format(dateFormat), day.Format(dateFormat), day.AddDate(0, 0, -4).Format(dateFormat), day.Format(dateFormat))}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	matches, err := openMatchStore(filepath.Join(t.TempDir(), matchFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := Writer{
+		Config: Config{
+			BudgetID:        "budget-id",
+			AccountMap:      AccountMap{"checking": "ynab-checking"},
+			Cleared:         Cleared,
+			Match:           true,
+			MatchDays:       3,
+			MatchSimilarity: 0.5,
+		},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		client:  httpServer.Client(),
+		baseURL: httpServer.URL,
+		matches: matches,
+	}
+
+	delivered, err := writer.Deliver(context.Background(), batch)
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if len(delivered) != 4 {
+		t.Errorf("delivered %d transactions, want all 4", len(delivered))
+	}
+	if len(server.patched) != 1 || server.patched[0] != (clearing{ID: "manual-grocer", Cleared: "cleared"}) {
+		t.Errorf("patched %+v, want manual-grocer cleared", server.patched)
+	}
+	if len(server.created) != 3 {
+		t.Errorf("created %+v, want the 3 unmatched transactions", server.created)
+	}
+
+	// A later run remembers the match even though YNAB has no import ID for it
+	server.patched, server.created = nil, nil
+	reopened, err := openMatchStore(matches.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.matches = reopened
+	if _, err := writer.Deliver(context.Background(), []ynabber.Transaction{grocer}); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if len(server.patched) != 0 || len(server.created) != 0 {
+		t.Errorf("patched %+v and created %+v, want nothing for a transaction matched before", server.patched, server.created)
+	}
+}
+
+func TestPlanMatches(t *testing.T) {
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -5)
+	batch := []ynabber.Transaction{
+		{Account: ynabber.Account{IBAN: "checking"}, ID: "grocer", Date: day, Payee: "REMA 1000", Amount: -25000},
+		{Account: ynabber.Account{IBAN: "checking"}, ID: "new", Date: day, Payee: "Kiwi", Amount: -12000},
+	}
+	server := &manualServer{manuals: fmt.Sprintf(`[{"id":"manual-grocer","date":%q,"amount":-25000,"payee_name":"Rema","cleared":"uncleared"}]`, day.Format(dateFormat))}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	writer := Writer{
+		Config: Config{
+			BudgetID:        "budget-id",
+			AccountMap:      AccountMap{"checking": "ynab-checking"},
+			Match:           true,
+			MatchDays:       3,
+			MatchSimilarity: 0.5,
+		},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		client:  httpServer.Client(),
+		baseURL: httpServer.URL,
+	}
+	changes, err := writer.Plan(context.Background(), batch)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if changes[0].Action != ynabber.ActionMatch || changes[1].Action != ynabber.ActionCreate {
+		t.Errorf("Plan() = %+v, want match and create", changes)
+	}
+	if len(server.patched) != 0 || len(server.created) != 0 {
+		t.Errorf("Plan() wrote to the budget")
+	}
+}
+
+func TestNewWriterMatchStore(t *testing.T) {
+	t.Setenv("YNAB_BUDGETID", "budget-id")
+	t.Setenv("YNAB_TOKEN", "token")
+	dir := t.TempDir()
+
+	writer, err := NewWriter(dir)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if writer.matches != nil {
+		t.Errorf("NewWriter() opened the match store without YNAB_MATCH")
+	}
+
+	t.Setenv("YNAB_MATCH", "true")
+	if writer, err = NewWriter(dir); err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if writer.matches == nil || writer.matches.path != filepath.Join(dir, matchFile) {
+		t.Errorf("NewWriter() match store = %+v, want one in the data directory", writer.matches)
+	}
+}
@@ -0,0 +1,142 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// Promote implements ynabber.Promoter. It updates the transaction imported
+// for pending, found by its import ID, with the date, amount, payee, memo and
+// cleared status of booked. The category and approval are left as they are.
+// YNAB can't change the import ID of a transaction, so it keeps the one of
+// pending.
+func (w Writer) Promote(ctx context.Context, pending, booked ynabber.Transaction) error {
+	transaction, err := w.toYNAB(booked)
+	if err != nil {
+		return err
+	}
+	written, err := w.toYNAB(pending)
+	if err != nil {
+		return err
+	}
+	transaction.ImportID = written.ImportID
+
+	payload, err := json.Marshal(struct {
+		Transactions []promotion `json:"transactions"`
+	}{[]promotion{{
+		ImportID:  transaction.ImportID,
+		AccountID: transaction.AccountID,
+		Date:      transaction.Date,
+		Amount:    transaction.Amount,
+		PayeeName: transaction.PayeeName,
+		Memo:      transaction.Memo,
+		Cleared:   transaction.Cleared,
+	}}})
+	if err != nil {
+		return err
+	}
+
+	status, _, err := w.request(ctx, http.MethodPatch, "/transactions", payload)
+	if err != nil {
+		return err
+	}
+	if status.code < 200 || status.code >= 300 {
+		return fmt.Errorf("failed to send request: %s", status)
+	}
+	return nil
+}
+
+// promotion updates the transaction with ImportID. Fields left out of it keep
+// their value in YNAB.
+type promotion struct {
+	ImportID  string `json:"import_id"`
+	AccountID string `json:"account_id"`
+	Date      string `json:"date"`
+	Amount    string `json:"amount"`
+	PayeeName string `json:"payee_name,omitempty"`
+	Memo      string `json:"memo,omitempty"`
+	Cleared   string `json:"cleared"`
+}
+
+// Remove implements ynabber.Promoter. It deletes the transaction imported for
+// pending, if it is still there.
+func (w Writer) Remove(ctx context.Context, pending ynabber.Transaction) error {
+	written, err := w.toYNAB(pending)
+	if err != nil {
+		return err
+	}
+	existing, ok, err := w.findImported(ctx, written, pending.Date)
+	if err != nil || !ok {
+		return err
+	}
+
+	status, _, err := w.request(ctx, http.MethodDelete, "/transactions/"+url.PathEscape(existing.ID), nil)
+	if err != nil {
+		return err
+	}
+	if status.code == http.StatusNotFound {
+		return nil
+	}
+	if status.code != http.StatusOK {
+		return fmt.Errorf("failed to send request: %s", status)
+	}
+	if w.deleted != nil {
+		return w.deleted.remove(written.AccountID + "/" + existing.ImportID)
+	}
+	return nil
+}
+
+// imported is a transaction in YNAB that Ynabber imported.
+type imported struct {
+	ID        string `json:"id"`
+	ImportID  string `json:"import_id"`
+	PayeeName string `json:"payee_name"`
+	Memo      string `json:"memo"`
+}
+
+// findImported returns the transaction in YNAB with the import ID of written,
+// dated on or after since. The import ID is taken from the mapped transaction
+// since toYNAB computes it after swapping inflow and outflow.
+func (w Writer) findImported(ctx context.Context, written Transaction, since time.Time) (imported, bool, error) {
+	transactions, err := w.importedSince(ctx, written.AccountID, since)
+	if err != nil {
+		return imported{}, false, err
+	}
+	for _, existing := range transactions {
+		if existing.ImportID == written.ImportID {
+			return existing, true, nil
+		}
+	}
+	return imported{}, false, nil
+}
+
+// importedSince returns the transactions of accountID from since onwards.
+func (w Writer) importedSince(ctx context.Context, accountID string, since time.Time) ([]imported, error) {
+	path := fmt.Sprintf("/accounts/%s/transactions?%s",
+		url.PathEscape(accountID),
+		url.Values{"since_date": {since.Format(dateFormat)}}.Encode(),
+	)
+	status, body, err := w.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status.code != http.StatusOK {
+		return nil, fmt.Errorf("failed to send request: %s", status)
+	}
+
+	var response struct {
+		Data struct {
+			Transactions []imported `json:"transactions"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("parsing response body: %w", err)
+	}
+	return response.Data.Transactions, nil
+}
@@ -0,0 +1,104 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+func TestPromoteAndRemove(t *testing.T) {
+	day := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+	pending := ynabber.Transaction{
+		Account: ynabber.Account{IBAN: "mapped"},
+		ID:      "pdng-1",
+		Status:  ynabber.StatusPending,
+		Date:    day,
+		Payee:   "Grocer",
+		Amount:  -1000,
+	}
+	booked := pending
+	booked.ID, booked.Status, booked.Date = "book-1", ynabber.StatusBooked, day.AddDate(0, 0, 2)
+
+	tests := []struct {
+		name     string
+		swapFlow []string
+		importID string // of the pending transaction written to YNAB
+		amount   string
+	}{
+		{name: "plain", importID: makeID(pending), amount: "-1000"},
+		{name: "swapflow", swapFlow: []string{"mapped"}, importID: makeID(pending.Negate()), amount: "1000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				promoted []promotion
+				deleted  []string
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				switch request.Method {
+				case http.MethodPatch:
+					body, _ := io.ReadAll(request.Body)
+					var payload struct {
+						Transactions []promotion `json:"transactions"`
+					}
+					if err := json.Unmarshal(body, &payload); err != nil {
+						t.Errorf("parsing request body: %v", err)
+					}
+					promoted = append(promoted, payload.Transactions...)
+					fmt.Fprint(response, `{"data":{}}`)
+				case http.MethodGet:
+					if got, want := request.URL.Path, "/budgets/budget-id/accounts/ynab-account/transactions"; got != want {
+						t.Errorf("path = %q, want %q", got, want)
+					}
+					fmt.Fprintf(response, `{"data":{"transactions":[{"id":"ynab-1","import_id":%q}]}}`, tt.importID)
+				case http.MethodDelete:
+					deleted = append(deleted, request.URL.Path)
+					fmt.Fprint(response, `{"data":{}}`)
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			writer := Writer{
+				Config: Config{
+					BudgetID:   "budget-id",
+					AccountMap: AccountMap{"mapped": "ynab-account"},
+					Cleared:    Cleared,
+					SwapFlow:   tt.swapFlow,
+				},
+				logger:  slog.Default(),
+				client:  server.Client(),
+				baseURL: server.URL,
+			}
+
+			if err := writer.Promote(context.Background(), pending, booked); err != nil {
+				t.Fatalf("Promote() error = %v", err)
+			}
+			want := promotion{
+				ImportID:  tt.importID,
+				AccountID: "ynab-account",
+				Date:      "2025-03-07",
+				Amount:    tt.amount,
+				PayeeName: "Grocer",
+				Cleared:   string(Cleared),
+			}
+			if len(promoted) != 1 || promoted[0] != want {
+				t.Errorf("promoted %+v, want %+v", promoted, want)
+			}
+
+			if err := writer.Remove(context.Background(), pending); err != nil {
+				t.Fatalf("Remove() error = %v", err)
+			}
+			if len(deleted) != 1 || deleted[0] != "/budgets/budget-id/transactions/ynab-1" {
+				t.Errorf("deleted %v, want the transaction imported for the pending one", deleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// existingTransactions is the part of the list transactions response needed to
+// find duplicates.
+type existingTransactions struct {
+	Data struct {
+		Transactions []struct {
+			AccountID string `json:"account_id"`
+			ImportID  string `json:"import_id"`
+		} `json:"transactions"`
+	} `json:"data"`
+}
+
+// Plan implements ynabber.Planner. It maps every transaction like Deliver
+// does and lists the transactions of the budget since the oldest date in
+// batch to find the ones YNAB would reject as duplicates of their import ID,
+// which is the one toYNAB computes. Transactions that fail to map are skipped.
+// Transactions deleted in YNAB count as duplicates too, unless
+// YNAB_REIMPORT_DELETED is enabled.
+// Receiving sides of transfers are skipped when YNAB would create them, see
+// settleTransfers.
+func (w Writer) Plan(ctx context.Context, batch []ynabber.Transaction) ([]ynabber.Change, error) {
+	changes := make([]ynabber.Change, len(batch))
+	var since time.Time
+	var sending, receiving []transferSide
+	for i, t := range batch {
+		changes[i] = ynabber.Change{Transaction: t, Action: ynabber.ActionCreate}
+		if reason := w.dateSkipReason(t.Date); reason != "" {
+			changes[i].Action, changes[i].Reason = ynabber.ActionSkip, reason
+			continue
+		}
+		accountID, err := accountParser(t.Account, w.Config.AccountMap)
+		if err != nil {
+			changes[i].Action, changes[i].Reason = ynabber.ActionSkip, "account not in YNAB_ACCOUNTMAP"
+			continue
+		}
+		transaction, err := w.toYNAB(t)
+		if err != nil {
+			changes[i].Action, changes[i].Reason = ynabber.ActionSkip, fmt.Sprintf("mapping to YNAB: %v", err)
+			continue
+		}
+		if other, ok := w.transferAccount(t); ok {
+			side := transferSide{index: i, source: t, transaction: transaction, other: other}
+			if w.inflowSide(t, transaction) {
+				receiving = append(receiving, side)
+			} else {
+				sending = append(sending, side)
+			}
+		}
+		changes[i].Account, changes[i].ImportID = accountID, transaction.ImportID
+		if since.IsZero() || t.Date.Before(since) {
+			since = t.Date
+		}
+	}
+	if since.IsZero() {
+		return changes, nil
+	}
+
+	existing, err := w.importIDs(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("listing existing transactions: %w", err)
+	}
+	deleted, err := w.peekDeleted(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("following deleted transactions: %w", err)
+	}
+	for key := range deleted {
+		if _, ok := existing[key]; !ok {
+			existing[key] = "deleted in YNAB, see YNAB_REIMPORT_DELETED"
+		}
+	}
+	for i, c := range changes {
+		if c.Action != ynabber.ActionCreate {
+			continue
+		}
+		key := c.Account + "/" + c.ImportID
+		if reason, ok := existing[key]; ok {
+			changes[i].Action, changes[i].Reason = ynabber.ActionDuplicate, reason
+			continue
+		}
+		existing[key] = "import ID appears earlier in the batch"
+	}
+	if len(sending) > 0 || len(receiving) > 0 {
+		payees, err := w.transferPayees(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing accounts for transfers: %w", err)
+		}
+		_, left, err := w.settleTransfers(ctx, sending, receiving, payees)
+		if err != nil {
+			return nil, fmt.Errorf("settling transfers: %w", err)
+		}
+		for _, side := range left {
+			changes[side.index].Action, changes[side.index].Reason = ynabber.ActionSkip, "receiving side of a transfer, which YNAB creates with the sending side"
+		}
+	}
+	if w.Config.Match {
+		if err := w.planMatches(ctx, changes); err != nil {
+			return nil, fmt.Errorf("matching transactions entered by hand: %w", err)
+		}
+	}
+	return changes, nil
+}
+
+// planMatches marks the changes that would create a transaction matching one
+// entered by hand, see findMatches, and those matched on an earlier run.
+func (w Writer) planMatches(ctx context.Context, changes []ynabber.Change) error {
+	var transactions []Transaction
+	var sources []ynabber.Transaction
+	var indexes []int
+	for i, c := range changes {
+		if c.Action != ynabber.ActionCreate {
+			continue
+		}
+		if w.matches != nil {
+			if _, ok := w.matches.lookup(c.ImportID); ok {
+				changes[i].Action, changes[i].Reason = ynabber.ActionDuplicate, "matched to a transaction entered by hand before"
+				continue
+			}
+		}
+		transaction, err := w.toYNAB(c.Transaction)
+		if err != nil {
+			continue
+		}
+		transactions = append(transactions, transaction)
+		sources = append(sources, c.Transaction)
+		indexes = append(indexes, i)
+	}
+
+	found, err := w.findMatches(ctx, transactions, sources)
+	if err != nil {
+		return err
+	}
+	for j, m := range found {
+		i := indexes[j]
+		changes[i].Action = ynabber.ActionMatch
+		changes[i].Reason = fmt.Sprintf("entered by hand as %q on %s", m.PayeeName, m.Date)
+	}
+	return nil
+}
+
+// importIDs returns the account and import ID of every transaction in the
+// budget since the date of since, keyed like "account/import ID" and mapped
+// to why a new transaction with that key is a duplicate.
+func (w Writer) importIDs(ctx context.Context, since time.Time) (map[string]string, error) {
+	path := "/transactions?" + url.Values{"since_date": {since.Format(dateFormat)}}.Encode()
+	status, body, err := w.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status.code != http.StatusOK {
+		return nil, fmt.Errorf("failed to send request: %s", status)
+	}
+
+	var response existingTransactions
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("parsing response body: %w", err)
+	}
+	ids := make(map[string]string)
+	for _, t := range response.Data.Transactions {
+		if t.ImportID != "" {
+			ids[t.AccountID+"/"+t.ImportID] = "import ID already in the budget"
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,130 @@
+package ynab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tx := func(id, iban string, date time.Time) ynabber.Transaction {
+		return ynabber.Transaction{
+			Account: ynabber.Account{IBAN: iban},
+			ID:      ynabber.ID(id),
+			Date:    date,
+			Amount:  -1000,
+		}
+	}
+	existing := tx("existing", "mapped", now.AddDate(0, 0, -3))
+	batch := []ynabber.Transaction{
+		tx("new", "mapped", now.AddDate(0, 0, -2)),
+		existing,
+		tx("unmapped", "other", now.AddDate(0, 0, -2)),
+		tx("old", "mapped", now.AddDate(0, -2, 0)),
+		tx("recent", "mapped", now),
+		tx("new", "mapped", now.AddDate(0, 0, -2)),
+	}
+
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		requests = append(requests, request)
+		fmt.Fprintf(response, `{"data":{"transactions":[{"account_id":"ynab-account","import_id":%q}]}}`, makeID(existing))
+	}))
+	t.Cleanup(server.Close)
+
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-id",
+			AccountMap: AccountMap{"mapped": "ynab-account"},
+			FromDate:   Date(now.AddDate(0, -1, 0)),
+			Delay:      time.Hour,
+		},
+		logger:  slog.Default(),
+		client:  server.Client(),
+		baseURL: server.URL,
+		now:     func() time.Time { return now },
+	}
+
+	changes, err := writer.Plan(context.Background(), batch)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	want := []ynabber.Action{
+		ynabber.ActionCreate,
+		ynabber.ActionDuplicate,
+		ynabber.ActionSkip,
+		ynabber.ActionSkip,
+		ynabber.ActionSkip,
+		ynabber.ActionDuplicate,
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Plan() returned %d changes, want %d", len(changes), len(want))
+	}
+	for i, change := range changes {
+		if change.Action != want[i] {
+			t.Errorf("change %d (%s) action = %s, want %s, reason %q", i, change.Transaction.ID, change.Action, want[i], change.Reason)
+		}
+	}
+	if got := changes[0].ImportID; got != makeID(batch[0]) {
+		t.Errorf("import ID = %q, want %q", got, makeID(batch[0]))
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	if requests[0].Method != http.MethodGet {
+		t.Errorf("method = %s, want GET, nothing may be written", requests[0].Method)
+	}
+	if got, want := requests[0].URL.Query().Get("since_date"), "2025-03-07"; got != want {
+		t.Errorf("since_date = %q, want %q", got, want)
+	}
+}
+
+func TestPlanSwapFlow(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	existing := ynabber.Transaction{
+		Account: ynabber.Account{IBAN: "mapped"},
+		ID:      ynabber.ID("existing"),
+		Date:    now.AddDate(0, 0, -3),
+		Amount:  -1000,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(response, `{"data":{"transactions":[{"account_id":"ynab-account","import_id":%q}]}}`, makeID(existing.Negate()))
+	}))
+	t.Cleanup(server.Close)
+
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-id",
+			AccountMap: AccountMap{"mapped": "ynab-account"},
+			SwapFlow:   []string{"mapped"},
+		},
+		logger:  slog.Default(),
+		client:  server.Client(),
+		baseURL: server.URL,
+		now:     func() time.Time { return now },
+	}
+
+	changes, err := writer.Plan(context.Background(), []ynabber.Transaction{existing})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("Plan() returned %d changes, want 1", len(changes))
+	}
+	if got, want := changes[0].ImportID, makeID(existing.Negate()); got != want {
+		t.Errorf("import ID = %q, want %q", got, want)
+	}
+	if changes[0].Action != ynabber.ActionDuplicate {
+		t.Errorf("action = %s, want %s, reason %q", changes[0].Action, ynabber.ActionDuplicate, changes[0].Reason)
+	}
+}
@@ -0,0 +1,91 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// adjustmentMemo is the memo of the transactions Adjust adds.
+const adjustmentMemo = "Added by Ynabber to match the bank balance"
+
+// ClearedBalance implements ynabber.Reconciler. It returns the cleared balance
+// of the YNAB account that account is mapped to, swapped if the account is in
+// YNAB_SWAPFLOW.
+func (w Writer) ClearedBalance(ctx context.Context, account ynabber.Account) (ynabber.Milliunits, bool, error) {
+	accountID, err := accountParser(account, w.Config.AccountMap)
+	if err != nil {
+		return 0, false, nil
+	}
+
+	status, body, err := w.request(ctx, http.MethodGet, "/accounts/"+url.PathEscape(accountID), nil)
+	if err != nil {
+		return 0, false, err
+	}
+	if status.code != http.StatusOK {
+		return 0, false, fmt.Errorf("failed to send request: %s", status)
+	}
+
+	var response struct {
+		Data struct {
+			Account struct {
+				ClearedBalance int64 `json:"cleared_balance"`
+			} `json:"account"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, false, fmt.Errorf("parsing response body: %w", err)
+	}
+
+	balance := ynabber.Milliunits(response.Data.Account.ClearedBalance)
+	if w.swapsFlow(account) {
+		balance = balance.Negate()
+	}
+	return balance, true, nil
+}
+
+// Adjust implements ynabber.Reconciler. It adds a cleared transaction of
+// amount to the YNAB account that account is mapped to, with
+// ynabber.AdjustmentPayee as payee.
+func (w Writer) Adjust(ctx context.Context, account ynabber.Account, amount ynabber.Milliunits, date time.Time) error {
+	transaction, err := w.toYNAB(ynabber.Transaction{
+		Account: account,
+		Date:    date,
+		Payee:   ynabber.AdjustmentPayee,
+		Memo:    adjustmentMemo,
+		Amount:  amount,
+	})
+	if err != nil {
+		return err
+	}
+	transaction.Cleared = string(Cleared)
+
+	payload, err := json.Marshal(Transactions{[]Transaction{transaction}})
+	if err != nil {
+		return err
+	}
+	status, _, err := w.request(ctx, http.MethodPost, "/transactions", payload)
+	if err != nil {
+		return err
+	}
+	if status.code != http.StatusCreated {
+		return fmt.Errorf("failed to send request: %s", status)
+	}
+	w.logger.Info("adjusted balance", "account", transaction.AccountID, "amount", transaction.Amount)
+	return nil
+}
+
+// swapsFlow reports whether account is in YNAB_SWAPFLOW.
+func (w Writer) swapsFlow(account ynabber.Account) bool {
+	for _, swapped := range w.Config.SwapFlow {
+		if swapped == account.IBAN || swapped == string(account.ID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+func TestClearedBalance(t *testing.T) {
+	tests := []struct {
+		name     string
+		account  ynabber.Account
+		swapFlow []string
+		want     ynabber.Milliunits
+		wantOK   bool
+	}{
+		{
+			name:    "mapped",
+			account: ynabber.Account{IBAN: "mapped"},
+			want:    -12340,
+			wantOK:  true,
+		},
+		{
+			name:     "swapped",
+			account:  ynabber.Account{IBAN: "mapped"},
+			swapFlow: []string{"mapped"},
+			want:     12340,
+			wantOK:   true,
+		},
+		{
+			name:    "unmapped",
+			account: ynabber.Account{IBAN: "unmapped"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				if request.URL.Path != "/budgets/budget-id/accounts/ynab-account" {
+					http.NotFound(response, request)
+					return
+				}
+				fmt.Fprint(response, `{"data":{"account":{"id":"ynab-account","cleared_balance":-12340}}}`)
+			}))
+			t.Cleanup(server.Close)
+
+			writer := Writer{
+				Config: Config{
+					BudgetID:   "budget-id",
+					AccountMap: AccountMap{"mapped": "ynab-account"},
+					SwapFlow:   tt.swapFlow,
+				},
+				logger:  slog.Default(),
+				client:  server.Client(),
+				baseURL: server.URL,
+			}
+			got, ok, err := writer.ClearedBalance(context.Background(), tt.account)
+			if err != nil {
+				t.Fatalf("ClearedBalance() error = %v", err)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ClearedBalance() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAdjust(t *testing.T) {
+	var sent Transactions
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodPost || request.URL.Path != "/budgets/budget-id/transactions" {
+			http.NotFound(response, request)
+			return
+		}
+		if err := json.NewDecoder(request.Body).Decode(&sent); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		response.WriteHeader(http.StatusCreated)
+		fmt.Fprint(response, `{"data":{}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-id",
+			AccountMap: AccountMap{"mapped": "ynab-account"},
+			Cleared:    Uncleared,
+		},
+		logger:  slog.Default(),
+		client:  server.Client(),
+		baseURL: server.URL,
+	}
+	date := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+	if err := writer.Adjust(context.Background(), ynabber.Account{IBAN: "mapped"}, -2500, date); err != nil {
+		t.Fatalf("Adjust() error = %v", err)
+	}
+
+	if len(sent.Transactions) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(sent.Transactions))
+	}
+	got := sent.Transactions[0]
+	if got.AccountID != "ynab-account" || got.Date != "2025-03-05" || got.Amount != "-2500" ||
+		got.PayeeName != ynabber.AdjustmentPayee || got.Cleared != string(Cleared) {
+		t.Errorf("sent %+v", got)
+	}
+}
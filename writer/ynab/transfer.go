@@ -0,0 +1,207 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// transferPayees returns the transfer payee ID of every account in the budget,
+// keyed by account ID. YNAB records a transaction as a transfer when its payee
+// is the transfer payee of the other account.
+func (w Writer) transferPayees(ctx context.Context) (map[string]string, error) {
+	status, body, err := w.request(ctx, http.MethodGet, "/accounts", nil)
+	if err != nil {
+		return nil, err
+	}
+	if status.code != http.StatusOK {
+		return nil, fmt.Errorf("failed to send request: %s", status)
+	}
+
+	var response struct {
+		Data struct {
+			Accounts []struct {
+				ID              string `json:"id"`
+				TransferPayeeID string `json:"transfer_payee_id"`
+			} `json:"accounts"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("parsing response body: %w", err)
+	}
+	payees := make(map[string]string, len(response.Data.Accounts))
+	for _, account := range response.Data.Accounts {
+		payees[account.ID] = account.TransferPayeeID
+	}
+	return payees, nil
+}
+
+// transferAccount returns the YNAB account source is a transfer to, or false
+// if it isn't a transfer or the other account isn't in YNAB_ACCOUNTMAP.
+func (w Writer) transferAccount(source ynabber.Transaction) (string, bool) {
+	if !source.IsTransfer() {
+		return "", false
+	}
+	accountID, err := accountParser(source.Transfer, w.Config.AccountMap)
+	return accountID, err == nil
+}
+
+// inflowSide reports whether transaction is the receiving side of a transfer
+// between two YNAB accounts. YNAB creates it along with the sending side when
+// that is written with the transfer payee, see settleTransfers.
+func (w Writer) inflowSide(source ynabber.Transaction, transaction Transaction) bool {
+	_, ok := w.transferAccount(source)
+	return ok && !strings.HasPrefix(transaction.Amount, "-")
+}
+
+// transferSide is one side of a transfer between two YNAB accounts.
+type transferSide struct {
+	// index is the position of the transaction in the batch.
+	index       int
+	source      ynabber.Transaction
+	transaction Transaction
+	// other is the YNAB account of the other side.
+	other string
+}
+
+// amount returns the amount of the side in milliunits.
+func (s transferSide) amount() int64 {
+	amount, _ := strconv.ParseInt(s.transaction.Amount, 10, 64)
+	return amount
+}
+
+// side returns s to pair it with the other side, see ynabber.PairSending.
+func (s transferSide) side() ynabber.TransferSide {
+	return ynabber.TransferSide{
+		Account: s.transaction.AccountID,
+		Other:   s.other,
+		Amount:  s.amount(),
+		Date:    s.transaction.Date,
+	}
+}
+
+// budgetTransaction is a transaction in the budget, listed to find the other
+// side of a transfer.
+type budgetTransaction struct {
+	AccountID         string `json:"account_id"`
+	Date              string `json:"date"`
+	Amount            int64  `json:"amount"`
+	ImportID          string `json:"import_id"`
+	TransferAccountID string `json:"transfer_account_id"`
+	Deleted           bool   `json:"deleted"`
+}
+
+// settleTransfers decides how the sides of transfers between two YNAB
+// accounts are written. It returns the sides to send and the receiving sides
+// YNAB creates itself.
+//
+// Sending sides are sent with the transfer payee of the other account, which
+// makes YNAB create the receiving side, unless the budget already has the
+// receiving side imported on its own. Receiving sides are left to YNAB when
+// their sending side is sent with the transfer payee in the same delivery, or
+// the budget has them as a transfer from the other account already. The
+// other receiving sides are imported like any other transaction, so none are
+// lost when the sending side was written as ordinary spending, for example
+// because it was read before the other account was known.
+func (w Writer) settleTransfers(ctx context.Context, sending, receiving []transferSide, payees map[string]string) ([]transferSide, []transferSide, error) {
+	budget, err := w.transferCandidates(ctx, append(slices.Clone(sending), receiving...))
+	if err != nil {
+		return nil, nil, err
+	}
+	used := make(map[int]bool)
+
+	var send []transferSide
+	// linked are the indexes in send of sending sides sent with the transfer
+	// payee, not yet paired with a receiving side
+	linked := make(map[int]bool)
+	for _, side := range sending {
+		payeeID := payees[side.other]
+		if payeeID != "" {
+			// The receiving side imported on its own would be doubled
+			j, ok := findBudgetSide(budget, used, side.other, -side.amount(), side.transaction.Date, func(t budgetTransaction) bool {
+				return t.ImportID != "" && t.TransferAccountID == ""
+			})
+			if ok {
+				w.logger.Debug("receiving side of transfer already imported, sending it as an ordinary transaction", "transaction", side.source)
+				used[j], payeeID = true, ""
+			}
+		}
+		if payeeID != "" {
+			// Transfers between budget accounts have no category
+			side.transaction.PayeeID, side.transaction.PayeeName, side.transaction.CategoryID = payeeID, "", ""
+			linked[len(send)] = true
+		}
+		send = append(send, side)
+	}
+
+	var left []transferSide
+	for _, side := range receiving {
+		if k, ok := ynabber.PairSending(send, linked, side, transferSide.side); ok {
+			delete(linked, k)
+			w.logger.Debug("left to YNAB as the other side of a transfer", "transaction", side.source)
+			left = append(left, side)
+			continue
+		}
+		j, ok := findBudgetSide(budget, used, side.transaction.AccountID, side.amount(), side.transaction.Date, func(t budgetTransaction) bool {
+			return t.TransferAccountID == side.other
+		})
+		if ok {
+			used[j] = true
+			w.logger.Debug("already in YNAB as the other side of a transfer", "transaction", side.source)
+			left = append(left, side)
+			continue
+		}
+		w.logger.Debug("sending side of transfer not sent as a transfer, importing the receiving side on its own", "transaction", side.source)
+		send = append(send, side)
+	}
+	return send, left, nil
+}
+
+// findBudgetSide returns the index of the transaction in budget in accountID
+// with amount, dated at most ynabber.TransferDays from date, that match
+// accepts. The closest in date wins, and transactions in used are left out.
+func findBudgetSide(budget []budgetTransaction, used map[int]bool, accountID string, amount int64, date string, match func(budgetTransaction) bool) (int, bool) {
+	return ynabber.ClosestTransfer(len(budget), date, func(j int) (string, bool) {
+		t := budget[j]
+		return t.Date, !used[j] && !t.Deleted && t.AccountID == accountID && t.Amount == amount && match(t)
+	})
+}
+
+// transferCandidates lists the transactions of the budget that may be the
+// other side of sides, from ynabber.TransferDays before the oldest of them.
+func (w Writer) transferCandidates(ctx context.Context, sides []transferSide) ([]budgetTransaction, error) {
+	if len(sides) == 0 {
+		return nil, nil
+	}
+	since := sides[0].source.Date
+	for _, side := range sides[1:] {
+		if side.source.Date.Before(since) {
+			since = side.source.Date
+		}
+	}
+	query := url.Values{"since_date": {since.AddDate(0, 0, -ynabber.TransferDays).Format(dateFormat)}}
+	status, body, err := w.request(ctx, http.MethodGet, "/transactions?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if status.code != http.StatusOK {
+		return nil, fmt.Errorf("failed to send request: %s", status)
+	}
+
+	var response struct {
+		Data struct {
+			Transactions []budgetTransaction `json:"transactions"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("parsing response body: %w", err)
+	}
+	return response.Data.Transactions, nil
+}
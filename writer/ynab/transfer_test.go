@@ -0,0 +1,226 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// fakeBudget is a YNAB budget that keeps the transactions posted to it and,
+// like YNAB, creates the receiving side of a transfer sent with a transfer
+// payee.
+type fakeBudget struct {
+	t *testing.T
+	// payees maps transfer payee IDs to their accounts.
+	payees       map[string]string
+	transactions []budgetTransaction
+	posted       []Transaction
+}
+
+func (b *fakeBudget) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	switch {
+	case request.Method == http.MethodGet && request.URL.Path == "/budgets/budget-id/accounts":
+		var accounts []string
+		for payeeID, accountID := range b.payees {
+			accounts = append(accounts, fmt.Sprintf(`{"id":%q,"transfer_payee_id":%q}`, accountID, payeeID))
+		}
+		fmt.Fprintf(response, `{"data":{"accounts":[%s]}}`, strings.Join(accounts, ","))
+	case request.Method == http.MethodGet && request.URL.Path == "/budgets/budget-id/transactions":
+		body, _ := json.Marshal(b.transactions)
+		fmt.Fprintf(response, `{"data":{"transactions":%s}}`, body)
+	case request.Method == http.MethodPost:
+		var body Transactions
+		if err := json.NewDecoder(request.Body).Decode(&body); err != nil {
+			b.t.Errorf("parsing request body: %v", err)
+		}
+		for _, t := range body.Transactions {
+			b.posted = append(b.posted, t)
+			if b.imported(t.AccountID, t.ImportID) {
+				continue
+			}
+			amount, _ := strconv.ParseInt(t.Amount, 10, 64)
+			other := b.payees[t.PayeeID]
+			b.transactions = append(b.transactions, budgetTransaction{AccountID: t.AccountID, Date: t.Date, Amount: amount, ImportID: t.ImportID, TransferAccountID: other})
+			if other != "" {
+				b.transactions = append(b.transactions, budgetTransaction{AccountID: other, Date: t.Date, Amount: -amount, TransferAccountID: t.AccountID})
+			}
+		}
+		response.WriteHeader(http.StatusCreated)
+		fmt.Fprint(response, `{"data":{}}`)
+	default:
+		b.t.Errorf("unexpected request %s %s", request.Method, request.URL.Path)
+	}
+}
+
+func (b *fakeBudget) imported(accountID, importID string) bool {
+	for _, t := range b.transactions {
+		if t.AccountID == accountID && t.ImportID == importID {
+			return true
+		}
+	}
+	return false
+}
+
+// balances returns the number of transactions and their sum per account.
+func (b *fakeBudget) balances() map[string][2]int64 {
+	balances := make(map[string][2]int64)
+	for _, t := range b.transactions {
+		balance := balances[t.AccountID]
+		balances[t.AccountID] = [2]int64{balance[0] + 1, balance[1] + t.Amount}
+	}
+	return balances
+}
+
+func newTransferWriter(t *testing.T) (Writer, *fakeBudget) {
+	budget := &fakeBudget{t: t, payees: map[string]string{"payee-checking": "ynab-checking", "payee-savings": "ynab-savings"}}
+	server := httptest.NewServer(budget)
+	t.Cleanup(server.Close)
+	return Writer{
+		Config: Config{
+			BudgetID:   "budget-id",
+			AccountMap: AccountMap{"checking": "ynab-checking", "savings": "ynab-savings"},
+		},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		client:  server.Client(),
+		baseURL: server.URL,
+	}, budget
+}
+
+func TestDeliverTransfers(t *testing.T) {
+	checking := ynabber.Account{IBAN: "checking"}
+	savings := ynabber.Account{IBAN: "savings"}
+	date := time.Now().UTC().AddDate(0, 0, -2)
+	batch := []ynabber.Transaction{
+		{Account: checking, ID: "out", Date: date, Payee: "Savings", Amount: -5000, Transfer: savings},
+		{Account: savings, ID: "in", Date: date, Payee: "Checking", Amount: 5000, Transfer: checking},
+		{Account: checking, ID: "elsewhere", Date: date, Payee: "Broker", Amount: -1000, Transfer: ynabber.Account{IBAN: "unmapped"}},
+	}
+
+	writer, budget := newTransferWriter(t)
+	delivered, err := writer.Deliver(context.Background(), batch)
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if len(delivered) != 3 {
+		t.Errorf("delivered %d transactions, want all 3", len(delivered))
+	}
+
+	if len(budget.posted) != 2 {
+		t.Fatalf("sent %+v, want the sending side and the unmapped transfer", budget.posted)
+	}
+	if got := budget.posted[0]; got.PayeeID != "" || got.PayeeName != "Broker" {
+		t.Errorf("unmapped transfer payee = %q/%q, want the payee name", got.PayeeID, got.PayeeName)
+	}
+	if got := budget.posted[1]; got.PayeeID != "payee-savings" || got.PayeeName != "" {
+		t.Errorf("sending side payee = %q/%q, want the transfer payee of savings", got.PayeeID, got.PayeeName)
+	}
+}
+
+// TestDeliverTransfersAcrossReaders delivers the two sides of a transfer
+// read by two readers, in separate batches, and checks that the budget ends
+// up with each side exactly once.
+func TestDeliverTransfersAcrossReaders(t *testing.T) {
+	checking := ynabber.Account{IBAN: "checking"}
+	savings := ynabber.Account{IBAN: "savings"}
+	date := time.Now().UTC().AddDate(0, 0, -2)
+	plainOut := ynabber.Transaction{Account: checking, ID: "out", Date: date, Payee: "Savings", Amount: -5000}
+	out := plainOut
+	out.Transfer = savings
+	in := ynabber.Transaction{Account: savings, ID: "in", Date: date.AddDate(0, 0, 1), Payee: "Checking", Amount: 5000, Transfer: checking}
+
+	tests := []struct {
+		name    string
+		batches [][]ynabber.Transaction
+		// posted are the payees of the transactions sent, in order
+		posted []string
+	}{
+		{
+			// The transfer transformer doesn't know savings yet when the
+			// checking batch is read, so the sending side is ordinary
+			// spending and the receiving side must be imported on its own
+			name:    "first run",
+			batches: [][]ynabber.Transaction{{plainOut}, {in}},
+			posted:  []string{"Savings", "Checking"},
+		},
+		{
+			name:    "accounts known",
+			batches: [][]ynabber.Transaction{{out}, {in}},
+			posted:  []string{"payee-savings"},
+		},
+		{
+			name:    "receiving side read first",
+			batches: [][]ynabber.Transaction{{in}, {out}},
+			posted:  []string{"Checking", "Savings"},
+		},
+		{
+			name:    "next run after the first",
+			batches: [][]ynabber.Transaction{{plainOut}, {in}, {out}, {in}},
+			posted:  []string{"Savings", "Checking", "Savings", "Checking"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer, budget := newTransferWriter(t)
+			for _, batch := range tt.batches {
+				delivered, err := writer.Deliver(context.Background(), batch)
+				if err != nil {
+					t.Fatalf("Deliver() error = %v", err)
+				}
+				if len(delivered) != len(batch) {
+					t.Errorf("delivered %d transactions, want %d", len(delivered), len(batch))
+				}
+			}
+
+			var posted []string
+			for _, p := range budget.posted {
+				posted = append(posted, p.PayeeName+p.PayeeID)
+			}
+			if fmt.Sprint(posted) != fmt.Sprint(tt.posted) {
+				t.Errorf("posted %q, want %q", posted, tt.posted)
+			}
+			want := map[string][2]int64{"ynab-checking": {1, -5000}, "ynab-savings": {1, 5000}}
+			if got := budget.balances(); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("budget has %v (count, sum), want each side once: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPlanTransfers(t *testing.T) {
+	checking := ynabber.Account{IBAN: "checking"}
+	savings := ynabber.Account{IBAN: "savings"}
+	date := time.Now().UTC().AddDate(0, 0, -2)
+	out := ynabber.Transaction{Account: checking, ID: "out", Date: date, Payee: "Savings", Amount: -5000, Transfer: savings}
+	in := ynabber.Transaction{Account: savings, ID: "in", Date: date, Payee: "Checking", Amount: 5000, Transfer: checking}
+
+	writer, budget := newTransferWriter(t)
+	changes, err := writer.Plan(context.Background(), []ynabber.Transaction{out, in})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if changes[0].Action != ynabber.ActionCreate || changes[1].Action != ynabber.ActionSkip {
+		t.Errorf("Plan() = %+v, want the sending side created and the receiving side skipped", changes)
+	}
+
+	// Without its sending side the receiving side is imported on its own
+	changes, err = writer.Plan(context.Background(), []ynabber.Transaction{in})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if changes[0].Action != ynabber.ActionCreate {
+		t.Errorf("Plan() = %+v, want the receiving side created", changes)
+	}
+	if len(budget.posted) != 0 {
+		t.Errorf("Plan() wrote to the budget")
+	}
+}
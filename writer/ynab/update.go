@@ -0,0 +1,104 @@
+package ynab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+// update changes the payee and memo of the transaction with ID. Fields left
+// out of it keep their value in YNAB.
+type update struct {
+	ID        string  `json:"id"`
+	PayeeName *string `json:"payee_name,omitempty"`
+	Memo      *string `json:"memo,omitempty"`
+}
+
+// Update implements ynabber.Updater. It looks up the transactions imported
+// for the previous versions and sets their payee and memo to those of the
+// current versions, where YNAB_UPDATE_PAYEE and YNAB_UPDATE_MEMO allow it. The
+// import IDs stay the same since they don't depend on the payee or memo.
+//
+// YNAB limits the number of requests per hour, so the transactions are looked
+// up with one request per account, from the oldest date, and all changes are
+// sent in one request. Transactions that fail to map are logged and left out.
+func (w Writer) Update(ctx context.Context, enrichments []ynabber.Enrichment) error {
+	type enrichment struct {
+		written, updated Transaction
+	}
+	accounts := make(map[string][]enrichment)
+	since := make(map[string]time.Time)
+	for _, e := range enrichments {
+		written, err := w.toYNAB(e.Previous)
+		if err != nil {
+			w.logger.Error("mapping transaction", "transaction", e.Previous, "error", err)
+			continue
+		}
+		updated, err := w.toYNAB(e.Current)
+		if err != nil {
+			w.logger.Error("mapping transaction", "transaction", e.Current, "error", err)
+			continue
+		}
+		accounts[written.AccountID] = append(accounts[written.AccountID], enrichment{written, updated})
+		if oldest, ok := since[written.AccountID]; !ok || e.Previous.Date.Before(oldest) {
+			since[written.AccountID] = e.Previous.Date
+		}
+	}
+
+	var changes []update
+	for _, accountID := range slices.Sorted(maps.Keys(accounts)) {
+		pairs := accounts[accountID]
+		transactions, err := w.importedSince(ctx, accountID, since[accountID])
+		if err != nil {
+			return err
+		}
+		byImportID := make(map[string]imported, len(transactions))
+		for _, t := range transactions {
+			byImportID[t.ImportID] = t
+		}
+
+		for _, pair := range pairs {
+			written, updated := pair.written, pair.updated
+			existing, ok := byImportID[written.ImportID]
+			if !ok {
+				continue
+			}
+			change := update{ID: existing.ID}
+			if updated.PayeeName != written.PayeeName && w.Config.UpdatePayee.Allows(written.PayeeName, existing.PayeeName) {
+				change.PayeeName = &updated.PayeeName
+			}
+			if updated.Memo != written.Memo && w.Config.UpdateMemo.Allows(written.Memo, existing.Memo) {
+				change.Memo = &updated.Memo
+			}
+			if change.PayeeName == nil && change.Memo == nil {
+				w.logger.Debug("keeping transaction as it is", "transaction", existing.ID, "update_payee", w.Config.UpdatePayee, "update_memo", w.Config.UpdateMemo)
+				continue
+			}
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(struct {
+		Transactions []update `json:"transactions"`
+	}{changes})
+	if err != nil {
+		return err
+	}
+	status, _, err := w.request(ctx, http.MethodPatch, "/transactions", payload)
+	if err != nil {
+		return err
+	}
+	if status.code < 200 || status.code >= 300 {
+		return fmt.Errorf("failed to send request: %s", status)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package ynab
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/martinohansen/ynabber"
+)
+
+func TestUpdate(t *testing.T) {
+	previous := ynabber.Transaction{
+		Account: ynabber.Account{IBAN: "mapped"},
+		ID:      "tx-1",
+		Date:    time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC),
+		Payee:   "VISA",
+		Memo:    "VISA",
+		Amount:  -1000,
+	}
+	current := previous
+	current.Payee, current.Memo = "Grocer", "VISA 1234 Grocer"
+
+	tests := []struct {
+		name   string
+		payee  ynabber.UpdatePolicy
+		memo   ynabber.UpdatePolicy
+		budget string // payee and memo in YNAB
+		want   string // PATCH body, empty for none
+	}{
+		{
+			name:   "unedited",
+			payee:  ynabber.UpdateUnedited,
+			memo:   ynabber.UpdateUnedited,
+			budget: "VISA",
+			want:   `{"transactions":[{"id":"ynab-1","payee_name":"Grocer","memo":"VISA 1234 Grocer"}]}`,
+		},
+		{
+			name:   "edited in YNAB",
+			payee:  ynabber.UpdateUnedited,
+			memo:   ynabber.UpdateUnedited,
+			budget: "Lunch",
+		},
+		{
+			name:   "always memo only",
+			payee:  ynabber.UpdateNever,
+			memo:   ynabber.UpdateAlways,
+			budget: "Lunch",
+			want:   `{"transactions":[{"id":"ynab-1","memo":"VISA 1234 Grocer"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patched []string
+			server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				switch request.Method {
+				case http.MethodGet:
+					fmt.Fprintf(response, `{"data":{"transactions":[{"id":"ynab-1","import_id":%q,"payee_name":%q,"memo":%q}]}}`,
+						makeID(previous), tt.budget, tt.budget)
+				case http.MethodPatch:
+					body, _ := io.ReadAll(request.Body)
+					patched = append(patched, string(body))
+					fmt.Fprint(response, `{"data":{}}`)
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			writer := Writer{
+				Config: Config{
+					BudgetID:    "budget-id",
+					AccountMap:  AccountMap{"mapped": "ynab-account"},
+					UpdatePayee: tt.payee,
+					UpdateMemo:  tt.memo,
+				},
+				logger:  slog.Default(),
+				client:  server.Client(),
+				baseURL: server.URL,
+			}
+			if err := writer.Update(context.Background(), []ynabber.Enrichment{{Previous: previous, Current: current}}); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			switch {
+			case tt.want == "" && len(patched) != 0:
+				t.Errorf("patched %v, want no update", patched)
+			case tt.want != "" && (len(patched) != 1 || patched[0] != tt.want):
+				t.Errorf("patched %v, want %s", patched, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateBatchesRequests(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC) }
+	tx := func(id, iban string, d int) ynabber.Transaction {
+		return ynabber.Transaction{
+			Account: ynabber.Account{IBAN: iban},
+			ID:      ynabber.ID(id),
+			Date:    day(d),
+			Payee:   "VISA",
+			Memo:    "VISA",
+			Amount:  -1000,
+		}
+	}
+	previous := []ynabber.Transaction{tx("tx-1", "one", 5), tx("tx-2", "one", 3), tx("tx-3", "two", 4)}
+	var enrichments []ynabber.Enrichment
+	for _, p := range previous {
+		current := p
+		current.Memo = "VISA 1234 Grocer"
+		enrichments = append(enrichments, ynabber.Enrichment{Previous: p, Current: current})
+	}
+
+	var gets []string
+	var patched []string
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+			gets = append(gets, request.URL.RequestURI())
+			var transactions []string
+			for i, p := range previous {
+				transactions = append(transactions, fmt.Sprintf(`{"id":"ynab-%d","import_id":%q,"payee_name":"VISA","memo":"VISA"}`, i+1, makeID(p)))
+			}
+			fmt.Fprintf(response, `{"data":{"transactions":[%s]}}`, strings.Join(transactions, ","))
+		case http.MethodPatch:
+			body, _ := io.ReadAll(request.Body)
+			patched = append(patched, string(body))
+			fmt.Fprint(response, `{"data":{}}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	writer := Writer{
+		Config: Config{
+			BudgetID:   "budget-id",
+			AccountMap: AccountMap{"one": "account-one", "two": "account-two"},
+			UpdateMemo: ynabber.UpdateUnedited,
+		},
+		logger:  slog.Default(),
+		client:  server.Client(),
+		baseURL: server.URL,
+	}
+	if err := writer.Update(context.Background(), enrichments); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	wantGets := []string{
+		"/budgets/budget-id/accounts/account-one/transactions?since_date=2025-03-03",
+		"/budgets/budget-id/accounts/account-two/transactions?since_date=2025-03-04",
+	}
+	if !slices.Equal(gets, wantGets) {
+		t.Errorf("requested %v, want %v", gets, wantGets)
+	}
+	want := `{"transactions":[` +
+		`{"id":"ynab-1","memo":"VISA 1234 Grocer"},` +
+		`{"id":"ynab-2","memo":"VISA 1234 Grocer"},` +
+		`{"id":"ynab-3","memo":"VISA 1234 Grocer"}]}`
+	if len(patched) != 1 || patched[0] != want {
+		t.Errorf("patched %v, want %s", patched, want)
+	}
+}
@@ -10,13 +10,14 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
-	"github.com/kelseyhightower/envconfig"
 	"github.com/martinohansen/ynabber"
 	"github.com/martinohansen/ynabber/internal/log"
+	"github.com/martinohansen/ynabber/internal/metrics"
 )
 
 const maxMemoSize int = 200                   // Max size of memo field
@@ -31,11 +32,24 @@ type Transaction struct {
 	AccountID string `json:"account_id"`
 	Date      string `json:"date"`
 	Amount    string `json:"amount"`
+	PayeeID   string `json:"payee_id,omitempty"`
 	PayeeName string `json:"payee_name"`
-	Memo      string `json:"memo"`
-	ImportID  string `json:"import_id"`
-	Cleared   string `json:"cleared"`
-	Approved  bool   `json:"approved"`
+	// CategoryID is left out for YNAB to categorize by the payee
+	CategoryID string `json:"category_id,omitempty"`
+	Memo       string `json:"memo"`
+	ImportID   string `json:"import_id"`
+	Cleared    string `json:"cleared"`
+	Approved   bool   `json:"approved"`
+	FlagColor  string `json:"flag_color,omitempty"`
+	// Subtransactions split the transaction, each with its own category
+	Subtransactions []Subtransaction `json:"subtransactions,omitempty"`
+}
+
+// Subtransaction is a part of a split YNAB transaction
+type Subtransaction struct {
+	Amount     string `json:"amount"`
+	CategoryID string `json:"category_id,omitempty"`
+	Memo       string `json:"memo,omitempty"`
 }
 
 // Transactions is multiple YNAB transactions
@@ -54,6 +68,14 @@ type Writer struct {
 	baseURL string
 	// now keeps date filtering deterministic in tests.
 	now func() time.Time
+	// categories caches the category IDs of the budget.
+	categories *ynabber.CategoryCache
+	// matches remembers transactions matched to ones entered by hand, if
+	// YNAB_MATCH is enabled.
+	matches *matchStore
+	// deleted remembers transactions deleted in YNAB, unless
+	// YNAB_REIMPORT_DELETED is enabled.
+	deleted *deletedStore
 }
 
 // String returns the name of the writer
@@ -61,24 +83,54 @@ func (w Writer) String() string {
 	return "ynab"
 }
 
-// NewWriter returns a new YNAB writer
-func NewWriter() (Writer, error) {
+func init() {
+	ynabber.RegisterWriter("ynab", func(opts ynabber.Options) (ynabber.Writer, error) {
+		return NewWriter(opts.DataDir)
+	}, &Config{})
+}
+
+// NewWriter returns a new YNAB writer keeping its state in dataDir
+func NewWriter(dataDir string) (Writer, error) {
 	cfg := Config{}
-	err := envconfig.Process("", &cfg)
+	err := ynabber.ProcessEnv("", &cfg)
 	if err != nil {
 		return Writer{}, fmt.Errorf("processing config: %w", err)
 	}
 
-	return Writer{
-		Config: cfg,
-		logger: slog.Default().With(
-			"writer", "ynab",
-			"budget_id", cfg.BudgetID,
-		),
-		client:  &http.Client{Timeout: 30 * time.Second},
-		baseURL: defaultBaseURL,
-		now:     time.Now,
-	}, nil
+	logger := slog.Default().With(
+		"writer", "ynab",
+		"budget_id", cfg.BudgetID,
+	)
+	logger.Debug("config loaded", "config", &cfg)
+	if len(cfg.SwapFlow) > 0 {
+		logger.Warn("YNAB_SWAPFLOW is deprecated, use the swapflow transformer instead")
+	}
+
+	w := Writer{
+		Config:     cfg,
+		logger:     logger,
+		client:     &http.Client{Timeout: 30 * time.Second, Transport: metrics.Transport("ynab", nil)},
+		baseURL:    defaultBaseURL,
+		now:        time.Now,
+		categories: &ynabber.CategoryCache{},
+	}
+	if cfg.Match {
+		if w.matches, err = openMatchStore(filepath.Join(dataDir, matchFile)); err != nil {
+			return Writer{}, err
+		}
+	}
+	if !cfg.ReimportDeleted {
+		if w.deleted, err = openDeletedStore(filepath.Join(dataDir, deletedFile)); err != nil {
+			return Writer{}, err
+		}
+	}
+	return w, nil
+}
+
+// MapAccount implements ynabber.AccountMapper.
+func (w Writer) MapAccount(account ynabber.Account) (string, bool) {
+	id, err := accountParser(account, w.Config.AccountMap)
+	return id, err == nil
 }
 
 // accountParser takes an Account and returns the matching YNAB account ID in
@@ -160,11 +212,17 @@ func (w Writer) toYNAB(source ynabber.Transaction) (Transaction, error) {
 	if w.Config.SwapFlow != nil {
 		for _, account := range w.Config.SwapFlow {
 			if account == source.Account.IBAN || account == string(source.Account.ID) {
-				source.Amount = source.Amount.Negate()
+				source = source.Negate()
 			}
 		}
 	}
 
+	// Pending transactions are uncleared until they are promoted
+	cleared := w.Config.Cleared
+	if source.Status == ynabber.StatusPending {
+		cleared = Uncleared
+	}
+
 	transaction := Transaction{
 		ImportID:  makeID(source),
 		AccountID: accountID,
@@ -172,8 +230,16 @@ func (w Writer) toYNAB(source ynabber.Transaction) (Transaction, error) {
 		Amount:    source.Amount.String(),
 		PayeeName: payee,
 		Memo:      memo,
-		Cleared:   string(w.Config.Cleared),
+		Cleared:   string(cleared),
 		Approved:  false,
+		FlagColor: source.Flag,
+	}
+	if len(source.Splits) > 0 {
+		// Categories are looked up by name when the transaction is sent
+		transaction.Subtransactions = make([]Subtransaction, len(source.Splits))
+		for i, split := range source.Splits {
+			transaction.Subtransactions[i] = Subtransaction{Amount: split.Amount.String(), Memo: split.Memo}
+		}
 	}
 	w.logger.Debug("mapped transaction", "from", source, "to", transaction)
 	return transaction, nil
@@ -182,6 +248,12 @@ func (w Writer) toYNAB(source ynabber.Transaction) (Transaction, error) {
 // checkTransactionDateValidity checks if date is within the limits of YNAB and
 // ynabber.Config.
 func (w Writer) checkTransactionDateValidity(date time.Time) bool {
+	return w.dateSkipReason(date) == ""
+}
+
+// dateSkipReason returns why date is outside the limits of YNAB and
+// ynabber.Config, or an empty string if it is within them.
+func (w Writer) dateSkipReason(date time.Time) string {
 	now := time.Now()
 	if w.now != nil {
 		now = w.now()
@@ -190,17 +262,70 @@ func (w Writer) checkTransactionDateValidity(date time.Time) bool {
 	fromDate := time.Time(w.Config.FromDate)
 	delay := w.Config.Delay
 
-	return date.After(fiveYearsAgo) && date.After(fromDate) && date.Before(now.Add(-delay))
+	switch {
+	case !date.After(fiveYearsAgo):
+		return "more than five years old, which YNAB rejects"
+	case !date.After(fromDate):
+		return "not after YNAB_FROM_DATE"
+	case !date.Before(now.Add(-delay)):
+		return "within YNAB_DELAY or in the future"
+	}
+	return ""
 }
 
+// Bulk writes t to YNAB.
 func (w Writer) Bulk(ctx context.Context, t []ynabber.Transaction) error {
+	_, err := w.Deliver(ctx, t)
+	return err
+}
+
+// Deliver writes t to YNAB and returns the transactions that were sent.
+// Transactions skipped by the date filters or that failed to map are left out.
+// Transfers between two YNAB accounts are sent with the transfer payee of the
+// other account from the sending side, and the receiving side is left to YNAB
+// when it creates it, see settleTransfers. Categories are sent by ID, looked
+// up by name, and left out if the budget has no category of that name. With
+// YNAB_MATCH, transactions matching one entered by hand clear it instead of
+// being created. Unless YNAB_REIMPORT_DELETED is enabled, transactions
+// deleted in YNAB are not sent again and count as delivered.
+func (w Writer) Deliver(ctx context.Context, t []ynabber.Transaction) ([]ynabber.Transaction, error) {
 	// skipped and failed counters
 	skipped := 0
 	failed := 0
 
-	// Build array of transactions to send to YNAB
+	var transferPayees map[string]string
+	if ynabber.HasTransfers(t) {
+		var err error
+		if transferPayees, err = w.transferPayees(ctx); err != nil {
+			return nil, fmt.Errorf("listing accounts for transfers: %w", err)
+		}
+	}
+	var categoryIDs ynabber.Categories
+	if ynabber.HasCategories(t) {
+		var err error
+		if categoryIDs, err = w.categoryIDs(ctx, t); err != nil {
+			return nil, fmt.Errorf("listing categories: %w", err)
+		}
+	}
+	var deleted map[string]bool
+	if len(t) > 0 {
+		var err error
+		if deleted, err = w.deletedTransactions(ctx); err != nil {
+			return nil, fmt.Errorf("following deleted transactions: %w", err)
+		}
+	}
+
+	// Build array of transactions to send to YNAB along with their sources
 	y := new(Transactions)
-	for _, v := range t {
+	sources := make([]ynabber.Transaction, 0, len(t))
+	// unsent are delivered without being sent: the receiving sides of
+	// transfers, delivered by sending the other side, transactions deleted
+	// in YNAB and those matched to a transaction entered by hand
+	var unsent []ynabber.Transaction
+	// sending and receiving are the sides of transfers between two budget
+	// accounts, settled once the whole batch is mapped
+	var sending, receiving []transferSide
+	for i, v := range t {
 		// Skip transactions that are not within the valid date range.
 		if !w.checkTransactionDateValidity(v.Date) {
 			w.logger.Debug("date out of range", "transaction", v)
@@ -216,68 +341,147 @@ func (w Writer) Bulk(ctx context.Context, t []ynabber.Transaction) error {
 			failed += 1
 			continue
 		}
+		if deleted[transaction.AccountID+"/"+transaction.ImportID] {
+			w.logger.Debug("deleted in YNAB", "transaction", v)
+			skipped += 1
+			unsent = append(unsent, v)
+			continue
+		}
+		// Split transactions are categorized by their parts only
+		if len(v.Splits) == 0 {
+			transaction.CategoryID = w.categoryID(categoryIDs, v.Category, v)
+		}
+		for j, split := range v.Splits {
+			transaction.Subtransactions[j].CategoryID = w.categoryID(categoryIDs, split.Category, v)
+		}
+		if accountID, ok := w.transferAccount(v); ok {
+			side := transferSide{index: i, source: v, transaction: transaction, other: accountID}
+			if w.inflowSide(v, transaction) {
+				receiving = append(receiving, side)
+			} else {
+				sending = append(sending, side)
+			}
+			continue
+		}
 		y.Transactions = append(y.Transactions, transaction)
+		sources = append(sources, v)
+	}
+	if len(sending) > 0 || len(receiving) > 0 {
+		send, left, err := w.settleTransfers(ctx, sending, receiving, transferPayees)
+		if err != nil {
+			return nil, fmt.Errorf("settling transfers: %w", err)
+		}
+		for _, side := range send {
+			y.Transactions = append(y.Transactions, side.transaction)
+			sources = append(sources, side.source)
+		}
+		for _, side := range left {
+			unsent = append(unsent, side.source)
+		}
+	}
+
+	// matched are delivered by clearing a transaction entered by hand
+	var matched []ynabber.Transaction
+	if w.Config.Match && len(y.Transactions) > 0 {
+		var err error
+		y.Transactions, sources, matched, err = w.matchManual(ctx, y.Transactions, sources)
+		if err != nil {
+			return nil, fmt.Errorf("matching transactions entered by hand: %w", err)
+		}
+		unsent = append(unsent, matched...)
 	}
 
+	if len(y.Transactions) == 0 && len(unsent) > 0 {
+		return unsent, nil
+	}
 	if len(t) == 0 || len(y.Transactions) == 0 {
 		w.logger.Info("no transactions to write")
-		return nil
+		return nil, nil
+	}
+
+	payload, err := json.Marshal(y)
+	if err != nil {
+		return nil, err
+	}
+
+	status, _, err := w.request(ctx, http.MethodPost, "/transactions", payload)
+	if err != nil {
+		return nil, err
 	}
 
+	if status.code != http.StatusCreated {
+		return nil, fmt.Errorf("failed to send request: %s", status)
+	} else {
+		w.logger.Info(
+			"sent transactions",
+			"status",
+			status.code,
+			"transactions",
+			len(y.Transactions),
+			"skipped",
+			skipped,
+			"failed",
+			failed,
+			"matched",
+			len(matched),
+		)
+	}
+	return append(sources, unsent...), nil
+}
+
+// status is the status of an HTTP response.
+type status struct {
+	code int
+	text string
+}
+
+func (s status) String() string {
+	return s.text
+}
+
+// request sends body, if any, to path under the budget and returns the status
+// and body of the response.
+func (w Writer) request(ctx context.Context, method, path string, body []byte) (status, []byte, error) {
 	baseURL := w.baseURL
 	if baseURL == "" {
 		baseURL = defaultBaseURL
 	}
 	requestURL := fmt.Sprintf(
-		"%s/budgets/%s/transactions",
+		"%s/budgets/%s%s",
 		strings.TrimRight(baseURL, "/"),
 		url.PathEscape(w.Config.BudgetID),
+		path,
 	)
 
-	payload, err := json.Marshal(y)
-	if err != nil {
-		return err
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
 	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, bytes.NewReader(payload))
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reader)
 	if err != nil {
-		return err
+		return status{}, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
-	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", w.Config.Token))
 
-	log.Trace(w.logger, "http request", "method", req.Method, "url", req.URL.String(), "body", payload)
+	log.Trace(w.logger, "http request", "method", req.Method, "url", req.URL.String(), "body", body)
 	client := w.client
 	if client == nil {
-		client = &http.Client{Timeout: 30 * time.Second}
+		client = &http.Client{Timeout: 30 * time.Second, Transport: metrics.Transport("ynab", nil)}
 	}
 	res, err := client.Do(req)
 	if err != nil {
-		return err
+		return status{}, nil, err
 	}
 	defer res.Body.Close()
 	resPayload, err := io.ReadAll(io.LimitReader(res.Body, maxResponseBodyBytes))
 	if err != nil {
-		return fmt.Errorf("reading response body: %w", err)
+		return status{}, nil, fmt.Errorf("reading response body: %w", err)
 	}
 	log.Trace(w.logger, "http response", "status", res.Status, "body", resPayload)
-
-	if res.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to send request: %s", res.Status)
-	} else {
-		w.logger.Info(
-			"sent transactions",
-			"status",
-			res.StatusCode,
-			"transactions",
-			len(y.Transactions),
-			"skipped",
-			skipped,
-			"failed",
-			failed,
-		)
-	}
-	return nil
+	return status{res.StatusCode, res.Status}, resPayload, nil
 }
 
 // Runner reads batches of transactions from in and writes them using Bulk.
@@ -214,6 +214,28 @@ func TestYnabberToYNAB(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Flag",
+			args: args{
+				cfg: Config{
+					AccountMap: map[string]string{"foobar": "abc"},
+				},
+				t: ynabber.Transaction{
+					Account: ynabber.Account{IBAN: "foobar"},
+					Amount:  10000,
+					Flag:    "red",
+				},
+			},
+			want: Transaction{
+				AccountID: "abc",
+				Date:      "0001-01-01",
+				Amount:    "10000",
+				ImportID:  "YBBR:e066d58050f67a602720e5f123f",
+				Approved:  false,
+				FlagColor: "red",
+			},
+			wantErr: false,
+		},
 		{
 			name: "SwapFlow with IBAN",
 			args: args{
@@ -235,6 +257,27 @@ func TestYnabberToYNAB(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			// The swapflow transformer gives the import ID of YNAB_SWAPFLOW
+			name: "Swapped by transformer",
+			args: args{
+				cfg: Config{
+					AccountMap: map[string]string{"foobar": "abc"},
+				},
+				t: ynabber.Transaction{
+					Account: ynabber.Account{IBAN: "foobar"},
+					Amount:  -10000,
+				},
+			},
+			want: Transaction{
+				AccountID: "abc",
+				Date:      "0001-01-01",
+				Amount:    "-10000",
+				ImportID:  "YBBR:2e18b15a1a51f0c2278147a4ca5",
+				Approved:  false,
+			},
+			wantErr: false,
+		},
 		{
 			name: "SwapFlow with Account ID",
 			args: args{
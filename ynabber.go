@@ -2,23 +2,55 @@ package ynabber
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
+	"github.com/martinohansen/ynabber/internal/metrics"
+	"github.com/martinohansen/ynabber/internal/status"
 	"golang.org/x/sync/errgroup"
 )
 
 type Ynabber struct {
-	Readers []Reader
-	Writers []Writer
+	Readers      []Reader
+	Transformers []Transformer
+	Writers      []Writer
+
+	// Routes selects which transactions each writer receives. Writers
+	// without routes receive every transaction.
+	Routes Routes
+
+	// Ledger, when set, records every delivery so writers only receive
+	// transactions that are new or changed since they were last written.
+	Ledger *Ledger
+
+	// Pending, when set, lets writers implementing Promoter receive pending
+	// transactions. It tracks them so they are promoted when their booked
+	// counterpart arrives and removed when the bank drops them. Without it,
+	// pending transactions are never written.
+	Pending *PendingTracker
 
 	config *Config
 	logger slog.Logger
+	// afterFn lets tests skip the writer backoff without waiting on
+	// wall-clock timers.
+	afterFn func(time.Duration) <-chan time.Time
 }
 
+const (
+	defaultWriterAttempts = 5
+	defaultWriterBackoff  = 30 * time.Second
+	// maxWriterBackoff caps the exponential backoff between writer retries.
+	maxWriterBackoff       = 30 * time.Minute
+	defaultShutdownTimeout = 30 * time.Second
+)
+
 // NewYnabber creates a new Ynabber instance
 func NewYnabber(config *Config) *Ynabber {
 	return &Ynabber{
+		Routes: config.Routes,
 		config: config,
 		logger: *slog.Default(),
 	}
@@ -34,20 +66,59 @@ type Writer interface {
 	String() string
 }
 
-// Run starts Ynabber by reading transactions from all readers into a channel to
-// fan out to all writers. Returns immediately on first error from any reader or
-// writer.
+// Transformer modifies each batch of transactions after it is read and before
+// it is fanned out to the writers. Transformers run in order, each receiving
+// the output of the previous one. Dropping a transaction from the returned
+// batch stops it from reaching any writer.
+type Transformer interface {
+	Transform(ctx context.Context, batch []Transaction) ([]Transaction, error)
+	String() string
+}
+
+// Deliverer is implemented by writers that can write a single batch and report
+// which of its transactions they actually delivered. Transactions that were
+// skipped, for example because their date is out of range or their account is
+// not mapped, must not be returned. Ynabber calls Deliver directly instead of
+// Runner so it can keep the Ledger up to date.
+type Deliverer interface {
+	Deliver(ctx context.Context, batch []Transaction) ([]Transaction, error)
+}
+
+// Run starts Ynabber by reading transactions from all readers into a channel,
+// passing each batch through the transformers and fanning it out to all
+// writers selected by Routes. Returns immediately on first error from any
+// reader or transformer.
+//
+// Writers are isolated from each other and from the readers: each one has its
+// own queue, and a failing batch is retried with exponential backoff while
+// everything else keeps running. Batches that still fail after
+// Config.WriterAttempts are dropped, and Run reports them once all readers
+// are done.
 func (y *Ynabber) Run() error {
-	g, ctx := errgroup.WithContext(context.Background())
+	return y.RunContext(context.Background())
+}
+
+// RunContext is like Run but shuts down gracefully when ctx is cancelled.
+// Readers stop right away, while writers get Config.ShutdownTimeout to finish
+// the batch they are writing. Batches still queued are dropped, they are read
+// again on the next start. A clean shutdown returns nil.
+func (y *Ynabber) RunContext(parent context.Context) error {
+	g, ctx := errgroup.WithContext(parent)
+
+	// Writers write with their own context so an in-flight batch survives
+	// the shutdown of everything else, until the deadline.
+	writeCtx, cancelWrites := context.WithCancelCause(context.WithoutCancel(parent))
+	defer cancelWrites(nil)
+	go y.cancelWritesAfterShutdown(parent, ctx, writeCtx, cancelWrites)
 
 	// Move transactions from reader to writer in batches on this channel.
 	// Multiple readers and writer can be used
-	batches := make(chan []Transaction)
+	batches := make(chan readerBatch)
 
-	// Create a channel for each writer and fan out transactions to each one
-	channels := make([]chan []Transaction, len(y.Writers))
-	for c := range channels {
-		channels[c] = make(chan []Transaction)
+	// Create a queue for each writer and fan out transactions to each one
+	queues := make([]*batchQueue, len(y.Writers))
+	for q, writer := range y.Writers {
+		queues[q] = newBatchQueue(y.logger.With("writer", writer.String()))
 	}
 
 	// Track when all readers are done
@@ -60,52 +131,405 @@ func (y *Ynabber) Run() error {
 		close(batches)
 	}()
 
-	// Fan out transactions to all writer channels
+	// Transform and fan out transactions to all writer queues
 	g.Go(func() error {
 		defer func() {
-			for _, c := range channels {
-				close(c)
+			for _, q := range queues {
+				q.close()
 			}
 		}()
 		for {
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
-			case batch, ok := <-batches:
+			case read, ok := <-batches:
 				if !ok {
 					return nil
 				}
-				for _, c := range channels {
-					select {
-					case c <- batch:
-					case <-ctx.Done():
-						return ctx.Err()
+				batch, err := y.transform(ctx, read.transactions)
+				if err != nil {
+					return err
+				}
+				for w, q := range queues {
+					routed := y.Routes.Filter(y.Writers[w].String(), read.reader, batch)
+					if !y.promotes(y.Writers[w]) {
+						routed = withoutPending(routed)
 					}
+					if len(routed) == 0 {
+						continue
+					}
+					q.push(queuedBatch{transactions: routed, read: batch})
 				}
 			}
 		}
 	})
 
-	// Start all writers
-	for c, writer := range y.Writers {
+	// Start all writers. Their failures never cancel the group, they are
+	// collected and reported after everything else has completed.
+	writerErrs := make([]error, len(y.Writers))
+	for w, writer := range y.Writers {
 		g.Go(func() error {
-			return writer.Runner(ctx, channels[c])
+			writerErrs[w] = y.runWriter(ctx, writeCtx, writer, queues[w])
+			return nil
 		})
 	}
 
-	// Start all readers
+	// Start all readers. Their batches are tagged with the reader name on
+	// the way to the fan-out so they can be routed.
 	for _, reader := range y.Readers {
+		out := make(chan []Transaction)
 		g.Go(func() error {
-			defer readerWg.Done()
-			return reader.Runner(ctx, batches)
+			defer close(out)
+			err := reader.Runner(ctx, out)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				status.Register("reader", reader.String()).Fail(err)
+			}
+			return err
 		})
+		go func() {
+			defer readerWg.Done()
+			for batch := range out {
+				metrics.TransactionsRead.Add(float64(len(batch)), reader.String())
+				metrics.Succeeded(reader.String())
+				select {
+				case batches <- readerBatch{reader: reader.String(), transactions: batch}:
+				case <-ctx.Done():
+				}
+			}
+		}()
 	}
 
 	// Wait for all goroutines to complete or first error
-	if err := g.Wait(); err != nil && err != context.Canceled {
+	if err := g.Wait(); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	if err := errors.Join(writerErrs...); err != nil {
 		return err
 	}
+	if errors.Is(context.Cause(writeCtx), ErrShutdownTimeout) {
+		return ErrShutdownTimeout
+	}
+	if parent.Err() != nil {
+		y.logger.Info("shutdown complete")
+		return nil
+	}
 
 	y.logger.Info("all readers and writers completed successfully")
 	return nil
 }
+
+// cancelWritesAfterShutdown cancels in-flight writes once ctx is done. When the
+// parent was cancelled, writers get the shutdown timeout to finish first. Any
+// other reason, like a failing reader, cancels them right away.
+func (y *Ynabber) cancelWritesAfterShutdown(parent, ctx, writeCtx context.Context, cancel context.CancelCauseFunc) {
+	select {
+	case <-ctx.Done():
+	case <-writeCtx.Done():
+		return
+	}
+	if parent.Err() == nil {
+		cancel(nil)
+		return
+	}
+
+	timeout := y.shutdownTimeout()
+	y.logger.Info("shutting down, waiting for writers to finish", "timeout", timeout)
+	select {
+	case <-y.after(timeout):
+		y.logger.Warn("shutdown timeout exceeded, cancelling writers")
+		cancel(ErrShutdownTimeout)
+	case <-writeCtx.Done():
+	}
+}
+
+// readerBatch is a batch of transactions along with the name of the reader
+// that read it.
+type readerBatch struct {
+	reader       string
+	transactions []Transaction
+}
+
+// transform passes batch through every transformer in order.
+func (y *Ynabber) transform(ctx context.Context, batch []Transaction) ([]Transaction, error) {
+	for _, transformer := range y.Transformers {
+		transformed, err := transformer.Transform(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("transforming with %s: %w", transformer, err)
+		}
+		y.logger.Debug("transformed batch", "transformer", transformer.String(), "in", len(batch), "out", len(transformed))
+		batch = transformed
+	}
+	return batch, nil
+}
+
+// writerFailures counts the batches a writer failed to write so Run can report
+// them without holding on to every error of a long running daemon.
+type writerFailures struct {
+	writer string
+	count  int
+	last   error
+}
+
+func (f *writerFailures) add(err error) {
+	f.count++
+	f.last = err
+}
+
+func (f *writerFailures) err() error {
+	if f.count == 0 {
+		return nil
+	}
+	return fmt.Errorf("writer %s failed %d time(s), last error: %w", f.writer, f.count, f.last)
+}
+
+// runWriter feeds batches from queue to writer until the queue is drained or
+// ctx is done. Writes use writeCtx, which outlives ctx during a graceful
+// shutdown so the batch being written can finish. Writers implementing
+// Deliverer are driven one batch at a time so deliveries can be retried,
+// filtered against and recorded in the ledger. All other writers consume the
+// queue through their Runner, which is restarted after a backoff when it
+// fails.
+func (y *Ynabber) runWriter(ctx, writeCtx context.Context, writer Writer, queue *batchQueue) error {
+	logger := y.logger.With("writer", writer.String())
+	failures := &writerFailures{writer: writer.String()}
+	state := status.Register("writer", writer.String())
+
+	deliverer, ok := writer.(Deliverer)
+	if !ok {
+		return y.restartRunner(ctx, writeCtx, logger, writer, state, queue, failures)
+	}
+
+	for {
+		queued, ok := queue.next(ctx)
+		if !ok {
+			return failures.err()
+		}
+		batch := queued.transactions
+		if y.Ledger != nil {
+			y.Ledger.Prune(writer.String(), queued.read)
+		}
+
+		if promoter, ok := writer.(Promoter); ok && y.Pending != nil {
+			batch = y.promotePending(writeCtx, logger, writer, promoter, batch)
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		if y.Ledger != nil {
+			pending := y.Ledger.Pending(writer.String(), batch)
+			logger.Debug("filtered batch against ledger", "received", len(batch), "pending", len(pending))
+			if len(pending) == 0 {
+				logger.Info("no new or changed transactions")
+				continue
+			}
+			batch = pending
+
+			if updater, ok := writer.(Updater); ok {
+				batch = y.updateEnriched(writeCtx, logger, writer, updater, batch)
+				if len(batch) == 0 {
+					continue
+				}
+			}
+		}
+
+		if err := y.deliver(ctx, writeCtx, logger, writer, deliverer, state, batch); err != nil {
+			if ctx.Err() != nil {
+				return failures.err()
+			}
+			logger.Error("dropping batch after failed delivery", "error", err, "transactions", len(batch))
+			failures.add(err)
+			metrics.BatchesFailed.Inc(writer.String())
+			// Stay failed until a later batch is delivered
+			state.Fail(err)
+		}
+	}
+}
+
+// promotes reports whether writer can be given pending transactions.
+func (y *Ynabber) promotes(writer Writer) bool {
+	_, promoter := writer.(Promoter)
+	_, deliverer := writer.(Deliverer)
+	return promoter && deliverer && y.Pending != nil
+}
+
+// promotePending returns the transactions in batch writer should still
+// receive. Booked transactions replacing a pending one written before are
+// promoted in place, and pending ones already written or promoted are left
+// out. Pending transactions the bank dropped are removed. Failures are logged
+// and retried with the next batch.
+func (y *Ynabber) promotePending(ctx context.Context, logger *slog.Logger, writer Writer, promoter Promoter, batch []Transaction) []Transaction {
+	name := writer.String()
+	remaining := make([]Transaction, 0, len(batch))
+	tracked := y.Pending.Tracked(name, batch)
+	for i, t := range batch {
+		if t.Status == StatusPending {
+			if !tracked[i] {
+				remaining = append(remaining, t)
+			}
+			continue
+		}
+		if y.Pending.Promoted(name, t) {
+			continue
+		}
+		pending, ok := y.Pending.Match(name, t)
+		if !ok {
+			remaining = append(remaining, t)
+			continue
+		}
+		if err := promoter.Promote(ctx, pending, t); err != nil {
+			logger.Error("promoting pending transaction", "pending", pending, "booked", t, "error", err)
+			continue
+		}
+		logger.Info("promoted pending transaction", "pending", pending.ID, "booked", t.ID)
+		if err := y.Pending.Promote(name, pending, t); err != nil {
+			logger.Error("tracking promoted transaction", "error", err)
+		}
+	}
+
+	for _, pending := range y.Pending.Vanished(name, batch) {
+		if err := promoter.Remove(ctx, pending); err != nil {
+			logger.Error("removing vanished pending transaction", "pending", pending, "error", err)
+			continue
+		}
+		logger.Info("removed vanished pending transaction", "pending", pending.ID)
+		if err := y.Pending.Forget(name, pending); err != nil {
+			logger.Error("tracking removed transaction", "error", err)
+		}
+	}
+	return remaining
+}
+
+// deliver writes batch with writer, retrying with exponential backoff. Only
+// the transactions not yet delivered are retried, and no retries are made
+// once ctx is done.
+func (y *Ynabber) deliver(ctx, writeCtx context.Context, logger *slog.Logger, writer Writer, deliverer Deliverer, state *status.Component, batch []Transaction) error {
+	attempts, delay := y.writerRetry()
+	for attempt := 1; ; attempt++ {
+		state.Set(status.Writing)
+		delivered, err := deliverer.Deliver(writeCtx, batch)
+		metrics.TransactionsWritten.Add(float64(len(delivered)), writer.String())
+		if y.Ledger != nil {
+			// Record partial deliveries too, a writer can fail halfway
+			// through a batch after some transactions were accepted.
+			if recordErr := y.Ledger.Record(writer.String(), delivered); recordErr != nil {
+				logger.Error("recording deliveries in ledger", "error", recordErr)
+			}
+		}
+		if y.Pending != nil {
+			if trackErr := y.Pending.Track(writer.String(), delivered); trackErr != nil {
+				logger.Error("tracking pending transactions", "error", trackErr)
+			}
+		}
+		if err == nil {
+			metrics.BatchesWritten.Inc(writer.String())
+			metrics.Succeeded(writer.String())
+			state.Set(status.Idle)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		batch = undelivered(batch, delivered)
+		if attempt >= attempts {
+			metrics.TransactionsFailed.Add(float64(len(batch)), writer.String())
+			return fmt.Errorf("giving up after %d attempt(s): %w", attempt, err)
+		}
+
+		logger.Warn("writing batch failed, backing off before retry",
+			"error", err,
+			"attempt", attempt,
+			"delay", delay,
+		)
+		state.BackOff(time.Now().Add(delay), err)
+		select {
+		case <-y.after(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay = min(delay*2, maxWriterBackoff)
+	}
+}
+
+// restartRunner runs writer.Runner on the batches from queue and restarts it
+// with exponential backoff whenever it fails. The input channel is closed once
+// ctx is done so the runner can return after its current batch.
+func (y *Ynabber) restartRunner(ctx, writeCtx context.Context, logger *slog.Logger, writer Writer, state *status.Component, queue *batchQueue, failures *writerFailures) error {
+	in := make(chan []Transaction)
+	go func() {
+		defer close(in)
+		for {
+			batch, ok := queue.next(ctx)
+			if !ok {
+				return
+			}
+			select {
+			case in <- batch.transactions:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	_, delay := y.writerRetry()
+	for {
+		err := writer.Runner(writeCtx, in)
+		if err == nil || ctx.Err() != nil {
+			return failures.err()
+		}
+
+		failures.add(err)
+		logger.Error("writer failed, restarting after backoff", "error", err, "delay", delay)
+		state.BackOff(time.Now().Add(delay), err)
+		select {
+		case <-y.after(delay):
+		case <-ctx.Done():
+			return failures.err()
+		}
+		delay = min(delay*2, maxWriterBackoff)
+	}
+}
+
+// undelivered returns the transactions in batch that are not in delivered.
+func undelivered(batch, delivered []Transaction) []Transaction {
+	if len(delivered) == 0 {
+		return batch
+	}
+	done := make(map[string]struct{}, len(delivered))
+	for _, t := range delivered {
+		done[ledgerKey(t)] = struct{}{}
+	}
+	remaining := make([]Transaction, 0, len(batch)-len(delivered))
+	for _, t := range batch {
+		if _, ok := done[ledgerKey(t)]; !ok {
+			remaining = append(remaining, t)
+		}
+	}
+	return remaining
+}
+
+// writerRetry returns how many attempts a batch gets and the initial backoff
+// between them. Defaults apply when Ynabber was created without a config.
+func (y *Ynabber) writerRetry() (int, time.Duration) {
+	attempts, backoff := defaultWriterAttempts, defaultWriterBackoff
+	if y.config != nil {
+		attempts, backoff = y.config.WriterAttempts, y.config.WriterBackoff
+	}
+	return max(attempts, 1), backoff
+}
+
+// shutdownTimeout returns how long writers get to finish after shutdown is
+// requested.
+func (y *Ynabber) shutdownTimeout() time.Duration {
+	if y.config != nil {
+		return y.config.ShutdownTimeout
+	}
+	return defaultShutdownTimeout
+}
+
+func (y *Ynabber) after(delay time.Duration) <-chan time.Time {
+	if y.afterFn != nil {
+		return y.afterFn(delay)
+	}
+	return time.After(delay)
+}
@@ -2,7 +2,9 @@ package ynabber
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -104,3 +106,332 @@ func TestOneShotBehavior(t *testing.T) {
 		t.Fatalf("expected 1 transaction in batch, got %d", len(batches[0]))
 	}
 }
+
+// Mock writer that implements Deliverer and delivers every transaction
+type mockDeliverer struct {
+	mockWriter
+}
+
+func (w *mockDeliverer) Deliver(ctx context.Context, batch []Transaction) ([]Transaction, error) {
+	w.mu.Lock()
+	w.batches = append(w.batches, batch)
+	w.mu.Unlock()
+	return batch, nil
+}
+
+func TestLedgerSkipsDeliveredTransactions(t *testing.T) {
+	ledger, err := OpenLedger(t.TempDir() + "/ledger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delivered := Transaction{
+		Account: Account{IBAN: "test-iban"},
+		ID:      "delivered",
+		Payee:   "test-payee",
+		Amount:  Milliunits(1000),
+		Date:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	fresh := delivered
+	fresh.ID = "fresh"
+
+	writer := &mockDeliverer{}
+	if err := ledger.Record(writer.String(), []Transaction{delivered}); err != nil {
+		t.Fatal(err)
+	}
+
+	y := &Ynabber{
+		Readers: []Reader{&mockOneShotReader{data: []Transaction{delivered, fresh}}},
+		Writers: []Writer{writer},
+		Ledger:  ledger,
+		logger:  *slog.Default(),
+	}
+	if err := y.Run(); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	batches := writer.getBatches()
+	if len(batches) != 1 || len(batches[0]) != 1 || batches[0][0].ID != "fresh" {
+		t.Fatalf("writer received %+v, want only the fresh transaction", batches)
+	}
+	if pending := ledger.Pending(writer.String(), []Transaction{fresh}); len(pending) != 0 {
+		t.Errorf("fresh transaction was not recorded in the ledger")
+	}
+}
+
+// Mock transformer that appends its name to every payee
+type mockTransformer struct {
+	name string
+}
+
+func (t mockTransformer) String() string { return t.name }
+
+func (t mockTransformer) Transform(ctx context.Context, batch []Transaction) ([]Transaction, error) {
+	out := make([]Transaction, len(batch))
+	for i, tx := range batch {
+		tx.Payee += "+" + t.name
+		out[i] = tx
+	}
+	return out, nil
+}
+
+func TestTransformersRunInOrder(t *testing.T) {
+	writer := &mockWriter{}
+	y := &Ynabber{
+		Readers: []Reader{&mockOneShotReader{data: []Transaction{{Payee: "payee"}}}},
+		Transformers: []Transformer{
+			mockTransformer{name: "first"},
+			mockTransformer{name: "second"},
+		},
+		Writers: []Writer{writer},
+		logger:  *slog.Default(),
+	}
+	if err := y.Run(); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	batches := writer.getBatches()
+	if len(batches) != 1 || len(batches[0]) != 1 {
+		t.Fatalf("expected 1 batch with 1 transaction, got %+v", batches)
+	}
+	if got, want := batches[0][0].Payee, "payee+first+second"; got != want {
+		t.Errorf("payee = %q, want %q", got, want)
+	}
+}
+
+// Mock writer that fails the first failures deliveries
+type flakyDeliverer struct {
+	mockWriter
+	failures int
+	attempts int
+}
+
+func (w *flakyDeliverer) String() string { return "flaky-writer" }
+
+func (w *flakyDeliverer) Deliver(ctx context.Context, batch []Transaction) ([]Transaction, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.attempts++
+	if w.attempts <= w.failures {
+		return nil, errors.New("server error")
+	}
+	w.batches = append(w.batches, batch)
+	return batch, nil
+}
+
+func immediately(time.Duration) <-chan time.Time {
+	ready := make(chan time.Time, 1)
+	ready <- time.Now()
+	return ready
+}
+
+func TestWriterRetriesFailedBatch(t *testing.T) {
+	writer := &flakyDeliverer{failures: 2}
+	y := &Ynabber{
+		Readers: []Reader{&mockOneShotReader{data: []Transaction{{ID: "tx"}}}},
+		Writers: []Writer{writer},
+		logger:  *slog.Default(),
+		afterFn: immediately,
+	}
+	if err := y.Run(); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if writer.attempts != 3 {
+		t.Errorf("attempts = %d, want 3", writer.attempts)
+	}
+	if batches := writer.getBatches(); len(batches) != 1 {
+		t.Errorf("expected 1 delivered batch, got %d", len(batches))
+	}
+}
+
+func TestFailingWriterDoesNotStopOthers(t *testing.T) {
+	failing := &flakyDeliverer{failures: 100}
+	healthy := &mockWriter{}
+	y := &Ynabber{
+		Readers: []Reader{&mockOneShotReader{data: []Transaction{{ID: "tx"}}}},
+		Writers: []Writer{failing, healthy},
+		logger:  *slog.Default(),
+		afterFn: immediately,
+	}
+
+	err := y.Run()
+	if err == nil {
+		t.Fatal("Run() error = nil, want error from failing writer")
+	}
+	if !strings.Contains(err.Error(), "flaky-writer") {
+		t.Errorf("Run() error = %v, want it to name the failing writer", err)
+	}
+	if failing.attempts != defaultWriterAttempts {
+		t.Errorf("attempts = %d, want %d", failing.attempts, defaultWriterAttempts)
+	}
+	if batches := healthy.getBatches(); len(batches) != 1 {
+		t.Errorf("healthy writer received %d batches, want 1", len(batches))
+	}
+}
+
+// Mock writer whose Runner fails on its first batch
+type failingRunner struct {
+	mockWriter
+	failed bool
+}
+
+func (w *failingRunner) Runner(ctx context.Context, in <-chan []Transaction) error {
+	if !w.failed {
+		<-in
+		w.failed = true
+		return errors.New("runner failed")
+	}
+	return w.mockWriter.Runner(ctx, in)
+}
+
+func TestFailingRunnerIsRestarted(t *testing.T) {
+	writer := &failingRunner{}
+	reader := &mockMultiBatchReader{batches: [][]Transaction{{{ID: "first"}}, {{ID: "second"}}}}
+	y := &Ynabber{
+		Readers: []Reader{reader},
+		Writers: []Writer{writer},
+		logger:  *slog.Default(),
+		afterFn: immediately,
+	}
+
+	if err := y.Run(); err == nil {
+		t.Fatal("Run() error = nil, want the runner failure to be reported")
+	}
+	batches := writer.getBatches()
+	if len(batches) != 1 || batches[0][0].ID != "second" {
+		t.Fatalf("restarted runner received %+v, want only the second batch", batches)
+	}
+}
+
+// Mock reader that sends several batches and exits
+type mockMultiBatchReader struct {
+	batches [][]Transaction
+}
+
+func (r *mockMultiBatchReader) String() string { return "mock-multi-batch-reader" }
+
+func (r *mockMultiBatchReader) Runner(ctx context.Context, out chan<- []Transaction) error {
+	for _, batch := range r.batches {
+		select {
+		case out <- batch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func TestRoutesSelectTransactionsPerWriter(t *testing.T) {
+	joint := Transaction{ID: "joint", Account: Account{IBAN: "NO1"}, Amount: -100}
+	card := Transaction{ID: "card", Account: Account{IBAN: "NO2"}, Amount: -200}
+
+	ynab := &mockWriter{}
+	actual := &flakyDeliverer{}
+	y := &Ynabber{
+		Readers: []Reader{&mockOneShotReader{data: []Transaction{joint, card}}},
+		Writers: []Writer{ynab, actual},
+		Routes: Routes{
+			"mock-writer":  {{Account: "NO1"}},
+			"flaky-writer": {{Account: "NO3"}},
+		},
+		logger: *slog.Default(),
+	}
+	if err := y.Run(); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	batches := ynab.getBatches()
+	if len(batches) != 1 || len(batches[0]) != 1 || batches[0][0].ID != "joint" {
+		t.Errorf("mock-writer received %+v, want only the joint transaction", batches)
+	}
+	if actual.attempts != 0 {
+		t.Errorf("flaky-writer was called %d time(s), want no calls for an empty batch", actual.attempts)
+	}
+}
+
+// Mock reader that sends one batch and keeps running until cancelled
+type mockDaemonReader struct {
+	data []Transaction
+}
+
+func (r *mockDaemonReader) String() string { return "mock-daemon-reader" }
+
+func (r *mockDaemonReader) Runner(ctx context.Context, out chan<- []Transaction) error {
+	select {
+	case out <- r.data:
+	case <-ctx.Done():
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Mock writer whose deliveries block until released or cancelled
+type blockingDeliverer struct {
+	mockWriter
+	started chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingDeliverer) Deliver(ctx context.Context, batch []Transaction) ([]Transaction, error) {
+	close(w.started)
+	select {
+	case <-w.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.batches = append(w.batches, batch)
+	return batch, nil
+}
+
+func TestShutdownLetsWritersFinish(t *testing.T) {
+	writer := &blockingDeliverer{started: make(chan struct{}), release: make(chan struct{})}
+	y := &Ynabber{
+		Readers: []Reader{&mockDaemonReader{data: []Transaction{{ID: "tx"}}}},
+		Writers: []Writer{writer},
+		config:  &Config{ShutdownTimeout: time.Minute},
+		logger:  *slog.Default(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- y.RunContext(ctx) }()
+
+	<-writer.started
+	cancel()
+	// The write must still be running after shutdown was requested
+	time.Sleep(10 * time.Millisecond)
+	close(writer.release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("RunContext() error = %v, want nil", err)
+	}
+	if batches := writer.getBatches(); len(batches) != 1 {
+		t.Errorf("writer finished %d batches, want 1", len(batches))
+	}
+}
+
+func TestShutdownTimeoutCancelsWriters(t *testing.T) {
+	writer := &blockingDeliverer{started: make(chan struct{}), release: make(chan struct{})}
+	y := &Ynabber{
+		Readers: []Reader{&mockDaemonReader{data: []Transaction{{ID: "tx"}}}},
+		Writers: []Writer{writer},
+		config:  &Config{ShutdownTimeout: time.Millisecond, WriterAttempts: 1},
+		logger:  *slog.Default(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- y.RunContext(ctx) }()
+
+	<-writer.started
+	cancel()
+
+	if err := <-done; !errors.Is(err, ErrShutdownTimeout) {
+		t.Fatalf("RunContext() error = %v, want %v", err, ErrShutdownTimeout)
+	}
+	if batches := writer.getBatches(); len(batches) != 0 {
+		t.Errorf("writer finished %d batches, want none", len(batches))
+	}
+}